@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/config"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers"
+	"github.com/joho/godotenv"
+)
+
+// Usage:
+//
+//	go run ./cmd/link_achievements          # dry run, prints what would be linked
+//	go run ./cmd/link_achievements -apply   # create the achievement_members rows
+//
+// Links class_achievements entered before the registry (comma separated student_names) to
+// student profiles so they count on the student leaderboard.
+func main() {
+	apply := flag.Bool("apply", false, "write the links (default is a dry run)")
+	flag.Parse()
+
+	// 1. Load env variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️  .env not found in CWD, trying parent directories...")
+		_ = godotenv.Load("../../.env")
+	}
+
+	// 2. Initialize DB using the EXACT SAME function as the server
+	db, err := config.InitDatabase()
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database using factory config: %v", err)
+	}
+	log.Println("✅ Connected to Database via GORM!")
+
+	// 3. Link
+	linked, unmatched, err := handlers.LinkAchievementMembers(db, !*apply)
+	if err != nil {
+		log.Fatalf("❌ Linking failed: %v", err)
+	}
+	log.Printf("🔗 %d achievements linked to student profiles", linked)
+	for _, name := range unmatched {
+		log.Printf("⚠️  No unique student named %s, link it by editing the achievement", name)
+	}
+
+	if !*apply {
+		log.Println("🔍 DRY RUN: nothing was saved. Re-run with -apply to create the links.")
+		return
+	}
+	log.Println("🎉 SUCCESS: Achievements linked!")
+}
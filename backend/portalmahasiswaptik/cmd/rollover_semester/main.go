@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/config"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/rollover"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+// Usage:
+//
+//	go run ./cmd/rollover_semester                      # dry run, prints what would change
+//	go run ./cmd/rollover_semester -apply               # archive the active term and activate the next
+//	go run ./cmd/rollover_semester -apply -start 2027-02-01 -end 2027-07-31
+//	go run ./cmd/rollover_semester -apply -next <term-uuid>
+func main() {
+	apply := flag.Bool("apply", false, "write the changes (default is a dry run)")
+	next := flag.String("next", "", "ID of a planned term to switch to")
+	start := flag.String("start", "", "first day of the next term (YYYY-MM-DD)")
+	end := flag.String("end", "", "last day of the next term (YYYY-MM-DD)")
+	flag.Parse()
+
+	// 1. Load env variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️  .env not found in CWD, trying parent directories...")
+		_ = godotenv.Load("../../.env")
+	}
+
+	opts := rollover.Options{DryRun: !*apply}
+	if *next != "" {
+		id, err := uuid.Parse(*next)
+		if err != nil {
+			log.Fatalf("❌ Invalid -next term ID: %v", err)
+		}
+		opts.NextTermID = &id
+	}
+	opts.StartDate = parseDate("start", *start)
+	opts.EndDate = parseDate("end", *end)
+
+	// 2. Initialize DB using the EXACT SAME function as the server
+	db, err := config.InitDatabase()
+	if err != nil {
+		log.Fatalf("❌ Failed to connect to database using factory config: %v", err)
+	}
+	log.Println("✅ Connected to Database via GORM!")
+
+	// 3. Rollover
+	report, err := rollover.Run(db, opts)
+	if err != nil {
+		log.Fatalf("❌ Rollover failed: %v", err)
+	}
+
+	if report.CreatedNextTerm {
+		log.Printf("🆕 Created term %s", report.NextTerm.Name)
+	}
+	log.Printf("📅 %s → %s (%s – %s)", report.PreviousTerm.Name, report.NextTerm.Name,
+		report.NextTerm.StartDate.Format("2006-01-02"), report.NextTerm.EndDate.Format("2006-01-02"))
+	log.Printf("🗄️  Archived %d attendance sessions and %d schedule slots", report.ArchivedSessions, report.ArchivedSlots)
+	log.Printf("💰 Billing range %d–%d archived, new range %d–%d",
+		report.BillingArchived[0], report.BillingArchived[1], report.BillingNext[0], report.BillingNext[1])
+	for _, p := range report.Promoted {
+		log.Printf("🎓 %s: semester %d → %d", p.Name, p.From, p.To)
+	}
+	for _, name := range report.NotPromoted {
+		log.Printf("⚠️  %s not promoted (no current semester or already at semester %d)", name, rollover.MaxSemester)
+	}
+
+	if report.DryRun {
+		log.Println("🔍 DRY RUN: nothing was saved. Re-run with -apply to commit the rollover.")
+		return
+	}
+	log.Println("🎉 SUCCESS: Semester rollover completed!")
+}
+
+func parseDate(name, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", value, handlers.WIB)
+	if err != nil {
+		log.Fatalf("❌ Invalid -%s date %q, expected YYYY-MM-DD", name, value)
+	}
+	return &d
+}
@@ -81,8 +81,31 @@ func autoMigrate(db *gorm.DB) error {
 	// USER REQUESTED: Force add billing_selected_month column (even if we use key-value store, we follow owner's lead)
 	db.Exec(`ALTER TABLE global_configs ADD COLUMN IF NOT EXISTS billing_selected_month INT DEFAULT 0`)
 
+	// Concurrent first loads of a grade sheet could create the default components twice. Fold
+	// duplicates into the oldest component before the (subject_id, name) unique index is built.
+	db.Exec(`
+		DO $$
+		BEGIN
+			IF to_regclass('grade_components') IS NOT NULL THEN
+				CREATE TEMP TABLE dup_grade_components ON COMMIT DROP AS
+					SELECT id, first_value(id) OVER (PARTITION BY subject_id, name ORDER BY created_at, id) AS keep
+					FROM grade_components;
+				DELETE FROM dup_grade_components WHERE id = keep;
+				UPDATE grade_scores s SET component_id = d.keep
+				FROM dup_grade_components d
+				WHERE s.component_id = d.id
+				  AND NOT EXISTS (SELECT 1 FROM grade_scores o WHERE o.student_id = s.student_id AND o.component_id = d.keep);
+				DELETE FROM grade_scores s USING dup_grade_components d WHERE s.component_id = d.id;
+				DELETE FROM grade_components g USING dup_grade_components d WHERE g.id = d.id;
+			END IF;
+		END $$;
+	`)
+
 	err := db.AutoMigrate(
 		&models.GlobalConfig{}, // Moved to TOP for priority
+		&models.Semester{},
+		&models.AcademicYear{},
+		&models.AcademicTerm{},
 		&models.Class{},
 		&models.Profile{},
 		&models.UserRole{},
@@ -90,9 +113,41 @@ func autoMigrate(db *gorm.DB) error {
 		&models.Meeting{},
 		&models.AttendanceSession{},
 		&models.AttendanceRecord{},
+		&models.SubjectTimingRule{},
+		&models.LeaveRequest{},
+		&models.ScanFlag{},
+		&models.OfflineDevice{},
+		&models.OfflineClaimUse{},
+		&models.TeachingAssignment{},
+		&models.ScheduleSlot{},
+		&models.Holiday{},
+		&models.CalendarToken{},
+		&models.GradeComponent{},
+		&models.GradeScore{},
+		&models.StudentGrade{},
+		&models.KRS{},
+		&models.KRSItem{},
+		&models.Enrollment{},
+		&models.Exam{},
+		&models.ExamRoom{},
+		&models.ExamSeat{},
 		&models.Transaction{},
 		&models.WeeklyDue{},
+		&models.DuesReminder{},
+		&models.DuesDispensation{},
 		&models.Announcement{},
+		&models.AnnouncementRead{},
+		&models.Notification{},
+		&models.NotificationDelivery{},
+		&models.NotificationPreference{},
+		&models.Competition{},
+		&models.CompetitionBookmark{},
+		&models.CompetitionTeam{},
+		&models.CompetitionTeamMember{},
+		&models.ClassAchievement{},
+		&models.AchievementMember{},
+		&models.PointRule{},
+		&models.PointEntry{},
 		&models.Material{},
 		&models.WebAuthnCredential{},
 	)
@@ -106,6 +161,76 @@ func autoMigrate(db *gorm.DB) error {
 	db.Exec(`INSERT INTO global_configs (key, value) VALUES ('billing_start_month', '1') ON CONFLICT (key) DO NOTHING`)
 	db.Exec(`INSERT INTO global_configs (key, value) VALUES ('billing_end_month', '6') ON CONFLICT (key) DO NOTHING`)
 	db.Exec(`INSERT INTO global_configs (key, value) VALUES ('billing_selected_month', '0') ON CONFLICT (key) DO NOTHING`)
+	db.Exec(`INSERT INTO global_configs (key, value) VALUES ('exam_min_attendance', '75') ON CONFLICT (key) DO NOTHING`)
+
+	// Default point rules for the participation leaderboard (admin dev tunes them afterwards)
+	for _, rule := range models.DefaultPointRules {
+		db.Exec(`INSERT INTO point_rules (key, name, points, active, description) VALUES (?, ?, ?, ?, ?) ON CONFLICT (key) DO NOTHING`,
+			rule.Key, rule.Name, rule.Points, rule.Active, rule.Description)
+	}
+
+	// Curriculum semesters (same rows as migrations/create_semesters_table.sql) when the table is new
+	db.Exec(`INSERT INTO semesters (name) SELECT 'Semester ' || n FROM generate_series(1, 8) AS n WHERE NOT EXISTS (SELECT 1 FROM semesters)`)
+
+	// Attendance statuses hadir/terlambat/alpa/izin/sakit next to the legacy English ones
+	// (same as supabase/migrations/20261018090000_attendance_status_values.sql)
+	db.Exec(`ALTER TABLE attendance_records DROP CONSTRAINT IF EXISTS attendance_records_status_check`)
+	db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'attendance_records_status_valid') THEN
+				ALTER TABLE attendance_records
+				ADD CONSTRAINT attendance_records_status_valid
+				CHECK (status IN ('hadir', 'terlambat', 'alpa', 'izin', 'sakit', 'present', 'late', 'absent', 'excused'));
+			END IF;
+		END $$;
+	`)
+
+	// Reordering or deleting meetings swaps numbers inside one transaction; the uniqueness of
+	// (subject_id, meeting_number) is checked at commit (supabase/migrations/20261018100000_meetings_deferrable_number.sql)
+	db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'meetings_subject_id_meeting_number_key' AND condeferrable) THEN
+				ALTER TABLE meetings DROP CONSTRAINT IF EXISTS meetings_subject_id_meeting_number_key;
+				ALTER TABLE meetings
+				ADD CONSTRAINT meetings_subject_id_meeting_number_key
+				UNIQUE (subject_id, meeting_number) DEFERRABLE INITIALLY IMMEDIATE;
+			END IF;
+		END $$;
+	`)
+
+	// Grades and scores are kept per term now (idx_student_grade_term, idx_grade_score_term);
+	// the old per-subject keys would make a retake overwrite the earlier attempt
+	db.Exec(`DROP INDEX IF EXISTS idx_student_grade`)
+	db.Exec(`DROP INDEX IF EXISTS idx_grade_score`)
+
+	// Dues rows get their payment time from the database, since the finance pages upsert them
+	// straight from the browser (same as supabase/migrations/20261018110000_weekly_dues_paid_at.sql)
+	db.Exec(`
+		CREATE OR REPLACE FUNCTION set_weekly_due_paid_at()
+		RETURNS TRIGGER AS $$
+		BEGIN
+			IF NEW.status IN ('paid', 'lunas') THEN
+				IF NEW.paid_at IS NULL OR (TG_OP = 'UPDATE' AND COALESCE(OLD.status, '') NOT IN ('paid', 'lunas')) THEN
+					NEW.paid_at = now();
+				END IF;
+			ELSE
+				NEW.paid_at = NULL;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+	`)
+	db.Exec(`DROP TRIGGER IF EXISTS set_weekly_dues_paid_at ON weekly_dues`)
+	db.Exec(`
+		CREATE TRIGGER set_weekly_dues_paid_at
+		BEFORE INSERT OR UPDATE OF status, paid_at ON weekly_dues
+		FOR EACH ROW EXECUTE FUNCTION set_weekly_due_paid_at()
+	`)
+
+	// Full-text search over announcements (GET /api/announcements?q=)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_announcements_search ON announcements USING GIN (to_tsvector('simple', title || ' ' || content))`)
 
 	// ✅ USER REQUESTED: Database Cascading Delete (Enforce Integrity)
 	// 1. subjects -> semesters
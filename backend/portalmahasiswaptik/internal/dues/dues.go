@@ -0,0 +1,264 @@
+package dues
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WIB is the clock due dates are compared in
+var WIB = time.FixedZone("WIB", 7*3600)
+
+// WeeklyAmount is charged for weeks without a weekly_dues row
+const WeeklyAmount = 5000
+
+// FriendlyWindow is how many days before its due date a week gets a friendly reminder
+const FriendlyWindow = 2
+
+// Week is one billing week of the current range
+type Week struct {
+	Year    int       `json:"year"`
+	Month   int       `json:"month"`
+	Number  int       `json:"week"`
+	DueDate time.Time `json:"due_date"`
+}
+
+// Label is the short name used in reminders, e.g. "W3 10/2026"
+func (w Week) Label() string {
+	return fmt.Sprintf("W%d %02d/%d", w.Number, w.Month, w.Year)
+}
+
+// Period is the throttling key of the week, e.g. "2026-10-W3"
+func (w Week) Period() string {
+	return fmt.Sprintf("%d-%02d-W%d", w.Year, w.Month, w.Number)
+}
+
+// StudentArrears is one student's unpaid weeks up to today
+type StudentArrears struct {
+	StudentID    uuid.UUID  `json:"student_id"`
+	Name         string     `json:"name"`
+	NIM          string     `json:"nim"`
+	ClassID      uuid.UUID  `json:"class_id"`
+	ClassName    string     `json:"class_name"`
+	Overdue      []Week     `json:"overdue"`
+	Amount       float64    `json:"amount"`             // total of the overdue weeks
+	DueSoon      *Week      `json:"due_soon,omitempty"` // unpaid week due within FriendlyWindow days
+	Dispensation bool       `json:"dispensation"`
+	Until        *time.Time `json:"dispensation_until,omitempty"`
+}
+
+// BillingRange reads the billed months from global_configs (default January–June). A start
+// after the end wraps around the new year, e.g. 8–1 bills August to January.
+func BillingRange(db *gorm.DB) (int, int) {
+	start, end := 1, 6
+	var configs []models.GlobalConfig
+	db.Where("key IN ?", []string{"billing_start_month", "billing_end_month"}).Find(&configs)
+	for _, cfg := range configs {
+		if v, err := strconv.Atoi(cfg.Value); err == nil {
+			if cfg.Key == "billing_start_month" {
+				start = v
+			} else {
+				end = v
+			}
+		}
+	}
+	return start, end
+}
+
+// Helper: Calendar date of t in WIB, at midnight UTC like WeeklyDue.DueDate
+func dateOf(t time.Time) time.Time {
+	local := t.In(WIB)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// BillingWeeks lists every billing week of the range that started most recently by now.
+// A wrapping range started last year until now reaches its start month again.
+func BillingWeeks(db *gorm.DB, now time.Time) []Week {
+	start, end := BillingRange(db)
+	today := dateOf(now)
+	year, months := today.Year(), end-start+1
+	if start > end {
+		months += 12
+		if int(today.Month()) < start {
+			year--
+		}
+	}
+
+	var weeks []Week
+	for i := 0; i < months; i++ {
+		y, month := year+(start-1+i)/12, (start-1+i)%12+1
+		for n := 1; n <= 4; n++ {
+			due := models.WeeklyDue{Year: y, Month: month, WeekNumber: n}.DueDate()
+			weeks = append(weeks, Week{Year: y, Month: month, Number: n, DueDate: due})
+		}
+	}
+	return weeks
+}
+
+// Helper: Month key of the week (year*100 + month), matches weekly_dues rows across years
+func (w Week) monthKey() int {
+	return w.Year*100 + w.Month
+}
+
+// Weeks lists the billing weeks of the current range that are already due or due within
+// FriendlyWindow days
+func Weeks(db *gorm.DB, now time.Time) []Week {
+	today := dateOf(now)
+	horizon := today.AddDate(0, 0, FriendlyWindow)
+
+	var weeks []Week
+	for _, w := range BillingWeeks(db, now) {
+		if w.DueDate.After(horizon) {
+			break
+		}
+		weeks = append(weeks, w)
+	}
+	return weeks
+}
+
+// Helper: Whether a dues status no longer needs reminding (pending waits for the treasurer)
+func settled(status string) bool {
+	return status == "paid" || status == "lunas" || status == "pending"
+}
+
+// Arrears returns every student with unpaid weeks that are overdue or due soon, optionally
+// limited to one class. Students with an active dispensation are included and flagged.
+func Arrears(db *gorm.DB, now time.Time, classID *uuid.UUID) ([]StudentArrears, error) {
+	result := []StudentArrears{}
+	weeks := Weeks(db, now)
+	if len(weeks) == 0 {
+		return result, nil
+	}
+	today := dateOf(now)
+
+	var students []struct {
+		UserID    uuid.UUID
+		FullName  string
+		NIM       string
+		ClassID   uuid.UUID
+		ClassName string
+	}
+	query := db.Table("profiles p").
+		Select("p.user_id, p.full_name, p.nim, p.class_id, c.name AS class_name").
+		Joins("JOIN classes c ON c.id = p.class_id").
+		Where("p.role IN ?", []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas})
+	if classID != nil {
+		query = query.Where("p.class_id = ?", *classID)
+	}
+	if err := query.Order("c.name, p.nim").Scan(&students).Error; err != nil {
+		return nil, err
+	}
+	if len(students) == 0 {
+		return result, nil
+	}
+	ids := make([]uuid.UUID, len(students))
+	for i, s := range students {
+		ids[i] = s.UserID
+	}
+
+	months := make([]int, 0, len(weeks))
+	for _, w := range weeks {
+		if len(months) == 0 || months[len(months)-1] != w.monthKey() {
+			months = append(months, w.monthKey())
+		}
+	}
+	var rows []models.WeeklyDue
+	if err := db.Where("student_id IN ? AND year * 100 + month IN ?", ids, months).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	type key struct {
+		student            uuid.UUID
+		year, month, weekN int
+	}
+	recorded := make(map[key]models.WeeklyDue, len(rows))
+	for _, r := range rows {
+		recorded[key{r.StudentID, r.Year, r.Month, r.WeekNumber}] = r
+	}
+
+	dispensed, err := ActiveDispensations(db, now, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range students {
+		a := StudentArrears{
+			StudentID: s.UserID,
+			Name:      s.FullName,
+			NIM:       s.NIM,
+			ClassID:   s.ClassID,
+			ClassName: s.ClassName,
+			Overdue:   []Week{},
+		}
+		for _, w := range weeks {
+			amount := float64(WeeklyAmount)
+			if r, ok := recorded[key{s.UserID, w.Year, w.Month, w.Number}]; ok {
+				if settled(r.Status) {
+					continue
+				}
+				amount = r.Amount
+			}
+			if w.DueDate.Before(today) {
+				a.Overdue = append(a.Overdue, w)
+				a.Amount += amount
+			} else if a.DueSoon == nil {
+				week := w
+				a.DueSoon = &week
+			}
+		}
+		if len(a.Overdue) == 0 && a.DueSoon == nil {
+			continue
+		}
+		if d, ok := dispensed[s.UserID]; ok {
+			a.Dispensation = true
+			a.Until = d.Until
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// ActiveDispensations returns the dispensation in force today for each of the students
+func ActiveDispensations(db *gorm.DB, now time.Time, studentIDs []uuid.UUID) (map[uuid.UUID]models.DuesDispensation, error) {
+	var rows []models.DuesDispensation
+	err := db.Where("student_id IN ? AND (until IS NULL OR until >= ?)", studentIDs, dateOf(now)).
+		Order("created_at").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[uuid.UUID]models.DuesDispensation, len(rows))
+	for _, d := range rows {
+		active[d.StudentID] = d
+	}
+	return active, nil
+}
+
+// Rupiah formats an amount like the finance pages, e.g. "Rp 15.000"
+func Rupiah(amount float64) string {
+	s := strconv.FormatInt(int64(amount), 10)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return "Rp " + b.String()
+}
+
+// Helper: Overdue week labels, oldest first, e.g. "W1 09/2026, W2 09/2026"
+func labels(weeks []Week) string {
+	sorted := append([]Week(nil), weeks...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].DueDate.Before(sorted[j].DueDate) })
+	parts := make([]string, len(sorted))
+	for i, w := range sorted {
+		parts[i] = w.Label()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,269 @@
+package dues
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// reminderLock is the advisory lock key that keeps two runs from reminding twice
+const reminderLock = 728302
+
+// Throttling: a student gets at most one reminder per MinGap, an overdue reminder at most
+// every OverdueEvery and a friendly one once per week. Treasurers get a digest at most every
+// DigestEvery, listing students with at least DigestAfter overdue weeks.
+const (
+	MinGap       = 20 * time.Hour
+	OverdueEvery = 3 * 24 * time.Hour
+	DigestEvery  = 7 * 24 * time.Hour
+	DigestAfter  = 2
+)
+
+// digestListMax caps the names spelled out in a digest
+const digestListMax = 10
+
+// ErrBusy is returned when another instance is sending reminders right now
+var ErrBusy = errors.New("pengingat iuran sedang diproses oleh proses lain")
+
+// errDryRun rolls the transaction back after the report has been built
+var errDryRun = errors.New("dry run")
+
+// Reminder is one message the run sent (or would send)
+type Reminder struct {
+	Stage     string     `json:"stage"`
+	StudentID *uuid.UUID `json:"student_id,omitempty"`
+	ClassID   uuid.UUID  `json:"class_id"`
+	Name      string     `json:"name"` // student, or class for digests
+	Weeks     int        `json:"weeks"`
+	Amount    float64    `json:"amount"`
+	Period    string     `json:"period,omitempty"`
+}
+
+// Report summarizes a reminder run
+type Report struct {
+	Date        string     `json:"date"`
+	Billing     [2]int     `json:"billing"` // start, end month
+	Reminders   []Reminder `json:"reminders"`
+	Throttled   int        `json:"throttled"`    // students skipped because they were reminded recently
+	Dispensed   int        `json:"dispensed"`    // students skipped because of a dispensation
+	NoTreasurer []string   `json:"no_treasurer"` // classes whose digest had nobody to go to
+	DryRun      bool       `json:"dry_run"`
+}
+
+// Run sends the reminders that are due: a friendly one shortly before a week's due date,
+// overdue reminders while weeks stay unpaid, and a digest to each class treasurer (admin
+// kelas). Sent reminders are logged for throttling; a dry run only reports.
+func Run(db *gorm.DB, notifier *notify.Service, now time.Time, dryRun bool) (*Report, error) {
+	start, end := BillingRange(db)
+	report := &Report{
+		Date:        dateOf(now).Format("2006-01-02"),
+		Billing:     [2]int{start, end},
+		Reminders:   []Reminder{},
+		NoTreasurer: []string{},
+		DryRun:      dryRun,
+	}
+	var events []notify.Event
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", reminderLock).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return ErrBusy
+		}
+
+		arrears, err := Arrears(tx, now, nil)
+		if err != nil {
+			return err
+		}
+
+		var recent []models.DuesReminder
+		if err := tx.Where("sent_at > ?", now.Add(-DigestEvery-24*time.Hour)).Find(&recent).Error; err != nil {
+			return err
+		}
+
+		var logs []models.DuesReminder
+		for _, a := range arrears {
+			if a.Dispensation {
+				report.Dispensed++
+				continue
+			}
+			r, ev, ok := studentReminder(a, recent, now)
+			if !ok {
+				report.Throttled++
+				continue
+			}
+			report.Reminders = append(report.Reminders, r)
+			events = append(events, ev)
+			logs = append(logs, models.DuesReminder{StudentID: r.StudentID, ClassID: &a.ClassID, Stage: r.Stage, Period: r.Period, Weeks: r.Weeks, SentAt: now})
+		}
+
+		digests, err := classDigests(tx, arrears, recent, now, report)
+		if err != nil {
+			return err
+		}
+		for _, d := range digests {
+			report.Reminders = append(report.Reminders, d.Reminder)
+			events = append(events, d.Event)
+			classID := d.ClassID
+			logs = append(logs, models.DuesReminder{ClassID: &classID, Stage: models.DuesReminderDigest, Weeks: d.Weeks, SentAt: now})
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		if len(logs) > 0 {
+			return tx.Create(&logs).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Published after the log is committed so a failed run never leaves unlogged messages
+	for _, ev := range events {
+		if err := notifier.Publish(ev); err != nil {
+			log.Printf("⚠️ Dues reminder %s: %v", ev.Type, err)
+		}
+	}
+	return report, nil
+}
+
+// Helper: The reminder a student is due, or false when throttled
+func studentReminder(a StudentArrears, recent []models.DuesReminder, now time.Time) (Reminder, notify.Event, bool) {
+	r := Reminder{StudentID: &a.StudentID, ClassID: a.ClassID, Name: a.Name}
+	if len(a.Overdue) > 0 {
+		r.Stage, r.Weeks, r.Amount = models.DuesReminderOverdue, len(a.Overdue), a.Amount
+	} else {
+		r.Stage, r.Period = models.DuesReminderFriendly, a.DueSoon.Period()
+	}
+
+	for _, prev := range recent {
+		if prev.StudentID == nil || *prev.StudentID != a.StudentID {
+			continue
+		}
+		since := now.Sub(prev.SentAt)
+		switch {
+		case since < MinGap:
+			return r, notify.Event{}, false
+		case r.Stage == models.DuesReminderOverdue && prev.Stage == models.DuesReminderOverdue && since < OverdueEvery:
+			return r, notify.Event{}, false
+		case r.Stage == models.DuesReminderFriendly && prev.Stage == models.DuesReminderFriendly && prev.Period == r.Period:
+			return r, notify.Event{}, false
+		}
+	}
+
+	ev := notify.Event{UserIDs: []uuid.UUID{a.StudentID}, Link: "/dashboard/payment"}
+	if r.Stage == models.DuesReminderOverdue {
+		ev.Type = notify.EventDuesOverdue
+		ev.Data = map[string]interface{}{"weeks": r.Weeks, "periods": labels(a.Overdue), "amount": Rupiah(a.Amount)}
+	} else {
+		ev.Type = notify.EventDuesReminder
+		ev.Data = map[string]interface{}{"week": a.DueSoon.Label(), "due": a.DueSoon.DueDate.Format("02/01/2006"), "amount": Rupiah(WeeklyAmount)}
+	}
+	return r, ev, true
+}
+
+type digest struct {
+	Reminder
+	Event notify.Event
+}
+
+// Helper: Digests for classes with students DigestAfter weeks or more behind, skipping
+// classes that got one within DigestEvery
+func classDigests(tx *gorm.DB, arrears []StudentArrears, recent []models.DuesReminder, now time.Time, report *Report) ([]digest, error) {
+	type classArrears struct {
+		name      string
+		students  []StudentArrears
+		dispensed int
+		amount    float64
+	}
+	var order []uuid.UUID
+	byClass := make(map[uuid.UUID]*classArrears)
+	for _, a := range arrears {
+		if len(a.Overdue) < DigestAfter {
+			continue
+		}
+		ca, ok := byClass[a.ClassID]
+		if !ok {
+			ca = &classArrears{name: a.ClassName}
+			byClass[a.ClassID] = ca
+			order = append(order, a.ClassID)
+		}
+		if a.Dispensation {
+			ca.dispensed++
+			continue
+		}
+		ca.students = append(ca.students, a)
+		ca.amount += a.Amount
+	}
+
+	var digests []digest
+	for _, classID := range order {
+		ca := byClass[classID]
+		if len(ca.students) == 0 || digestedRecently(classID, recent, now) {
+			continue
+		}
+
+		var treasurers []uuid.UUID
+		if err := tx.Model(&models.Profile{}).
+			Where("class_id = ? AND role = ?", classID, models.RoleAdminKelas).
+			Pluck("user_id", &treasurers).Error; err != nil {
+			return nil, err
+		}
+		if len(treasurers) == 0 {
+			report.NoTreasurer = append(report.NoTreasurer, ca.name)
+			continue
+		}
+
+		names := make([]string, 0, digestListMax+1)
+		weeks := 0
+		for i, a := range ca.students {
+			weeks += len(a.Overdue)
+			if i < digestListMax {
+				names = append(names, fmt.Sprintf("%s (%d minggu, %s)", a.Name, len(a.Overdue), Rupiah(a.Amount)))
+			}
+		}
+		if extra := len(ca.students) - digestListMax; extra > 0 {
+			names = append(names, fmt.Sprintf("dan %d lainnya", extra))
+		}
+
+		digests = append(digests, digest{
+			Reminder: Reminder{Stage: models.DuesReminderDigest, ClassID: classID, Name: ca.name, Weeks: weeks, Amount: ca.amount},
+			Event: notify.Event{
+				Type:    notify.EventDuesDigest,
+				UserIDs: treasurers,
+				Link:    "/dashboard/finance",
+				Data: map[string]interface{}{
+					"class":     ca.name,
+					"students":  len(ca.students),
+					"amount":    Rupiah(ca.amount),
+					"list":      strings.Join(names, "; "),
+					"dispensed": ca.dispensed,
+				},
+			},
+		})
+	}
+	return digests, nil
+}
+
+// Helper: Whether the class treasurer got a digest within DigestEvery
+func digestedRecently(classID uuid.UUID, recent []models.DuesReminder, now time.Time) bool {
+	for _, prev := range recent {
+		if prev.Stage == models.DuesReminderDigest && prev.ClassID != nil && *prev.ClassID == classID && now.Sub(prev.SentAt) < DigestEvery {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package enrollment
+
+import (
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// wib is the clock term dates are compared in
+var wib = time.FixedZone("WIB", 7*3600)
+
+// sessionTermSQL is the term of session s: the one whose dates contain the (WIB) day the
+// session was scheduled or opened, else the active term
+const sessionTermSQL = `COALESCE(
+		(SELECT t.id FROM academic_terms t
+		 WHERE (COALESCE(s.scheduled_start, s.created_at) AT TIME ZONE 'Asia/Jakarta')::date BETWEEN t.start_date AND t.end_date
+		 ORDER BY t.start_date DESC LIMIT 1),
+		(SELECT id FROM academic_terms WHERE is_active LIMIT 1))`
+
+// RosterSQL is the roster rule for raw queries. It expects the aliases p (profiles),
+// s (attendance_sessions) and m (meetings): students enrolled in the section through an
+// approved KRS of the session's term, plus class members without an approved KRS that term.
+const RosterSQL = `(EXISTS (
+		SELECT 1 FROM enrollments e
+		WHERE e.subject_id = m.subject_id AND e.class_id = s.class_id AND e.student_id = p.user_id
+		  AND e.term_id = ` + sessionTermSQL + `
+	) OR (p.class_id = s.class_id AND NOT EXISTS (
+		SELECT 1 FROM krs k
+		WHERE k.student_id = p.user_id AND k.status = 'approved'
+		  AND k.term_id = ` + sessionTermSQL + `
+	)))`
+
+// CurrentAssignment is the SQL condition that keeps only teaching assignments (alias) of the
+// semester the class is in now (classes.current_semester, promoted by the rollover). Classes
+// without a current semester accept assignments of any semester.
+func CurrentAssignment(alias string) string {
+	return alias + ".semester = COALESCE((SELECT cls.current_semester FROM classes cls WHERE cls.id = " + alias + ".class_id), " + alias + ".semester)"
+}
+
+// ActiveTermID returns the ID of the active academic term, if any
+func ActiveTermID(db *gorm.DB) (uuid.UUID, bool) {
+	var term models.AcademicTerm
+	if err := db.Select("id").Where("is_active = ?", true).First(&term).Error; err != nil {
+		return uuid.Nil, false
+	}
+	return term.ID, true
+}
+
+// TermAt returns the term whose dates contain t (in WIB), falling back to the active term
+func TermAt(db *gorm.DB, t time.Time) (uuid.UUID, bool) {
+	var term models.AcademicTerm
+	day := t.In(wib).Format("2006-01-02")
+	if err := db.Select("id").Where("start_date <= ? AND end_date >= ?", day, day).
+		Order("start_date DESC").First(&term).Error; err == nil {
+		return term.ID, true
+	}
+	return ActiveTermID(db)
+}
+
+// SessionTermID returns the term an attendance session belongs to
+func SessionTermID(db *gorm.DB, session *models.AttendanceSession) uuid.UUID {
+	at := session.CreatedAt
+	if session.ScheduledStart != nil {
+		at = *session.ScheduledStart
+	}
+	termID, _ := TermAt(db, at)
+	return termID
+}
+
+// ExamTermID returns the term of an exam: its own term, else the term of its date
+func ExamTermID(db *gorm.DB, exam *models.Exam) uuid.UUID {
+	if exam.TermID != nil {
+		return *exam.TermID
+	}
+	termID, _ := TermAt(db, exam.StartsAt)
+	return termID
+}
+
+// Helper: Students with an approved KRS in the term; their enrollments alone decide their sections
+func planned(db *gorm.DB, termID uuid.UUID) *gorm.DB {
+	return db.Model(&models.KRS{}).Select("student_id").Where("term_id = ? AND status = ?", termID, models.KRSApproved)
+}
+
+// Roster returns a profiles query for the students taking subjectID with classID in the term:
+// those enrolled through an approved KRS, plus members of the class whose KRS is not approved
+// yet, so a section never loses students while KRS approvals trickle in.
+func Roster(db *gorm.DB, termID, subjectID, classID uuid.UUID) *gorm.DB {
+	enrolled := db.Model(&models.Enrollment{}).Select("student_id").
+		Where("term_id = ? AND subject_id = ? AND class_id = ?", termID, subjectID, classID)
+	return db.Model(&models.Profile{}).
+		Where("(user_id IN (?) OR (class_id = ? AND user_id NOT IN (?)))", enrolled, classID, planned(db, termID))
+}
+
+// InRoster reports whether the student is on the roster of the section in the term
+func InRoster(db *gorm.DB, student *models.Profile, termID, subjectID, classID uuid.UUID) bool {
+	var count int64
+	db.Model(&models.Enrollment{}).
+		Where("term_id = ? AND subject_id = ? AND class_id = ? AND student_id = ?", termID, subjectID, classID, student.UserID).
+		Count(&count)
+	if count > 0 {
+		return true
+	}
+	if student.ClassID == nil || *student.ClassID != classID {
+		return false
+	}
+	planned(db, termID).Where("student_id = ?", student.UserID).Count(&count)
+	return count == 0
+}
@@ -0,0 +1,630 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AcademicHandler manages subjects, classes and meetings
+type AcademicHandler struct {
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+// NewAcademicHandler creates a new academic handler
+func NewAcademicHandler(db *gorm.DB, validate *validator.Validate) *AcademicHandler {
+	return &AcademicHandler{
+		DB:       db,
+		Validate: validate,
+	}
+}
+
+// ========================================
+// SUBJECTS
+// ========================================
+
+// SubjectRequest represents create/update subject payload
+type SubjectRequest struct {
+	Code     string `json:"code" validate:"required,max=20"`
+	Name     string `json:"name" validate:"required,max=150"`
+	Semester int    `json:"semester" validate:"required,min=1"`
+	SKS      int    `json:"sks" validate:"required,min=1,max=6"`
+}
+
+// Helper: Shared validation for subject payloads (semester FK + unique code)
+func (h *AcademicHandler) checkSubject(req *SubjectRequest, excludeID *uuid.UUID) (int, string) {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+
+	var semesterCount int64
+	h.DB.Table("semesters").Where("id = ?", req.Semester).Count(&semesterCount)
+	if semesterCount == 0 {
+		return fiber.StatusBadRequest, "Semester tidak ditemukan"
+	}
+
+	query := h.DB.Model(&models.Subject{}).Where("UPPER(code) = ?", req.Code)
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	var dup int64
+	query.Count(&dup)
+	if dup > 0 {
+		return fiber.StatusConflict, "Kode mata kuliah sudah dipakai"
+	}
+	return 0, ""
+}
+
+// CreateSubject creates a new subject
+// POST /api/academic/subjects
+func (h *AcademicHandler) CreateSubject(c *fiber.Ctx) error {
+	var req SubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+	if status, msg := h.checkSubject(&req, nil); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	subject := models.Subject{Code: req.Code, Name: req.Name, Semester: req.Semester, SKS: req.SKS}
+	if err := h.DB.Create(&subject).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat mata kuliah"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    subject,
+		"message": "Mata kuliah berhasil dibuat",
+	})
+}
+
+// UpdateSubject updates an existing subject
+// PUT /api/academic/subjects/:id
+func (h *AcademicHandler) UpdateSubject(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+	}
+
+	var req SubjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+	if status, msg := h.checkSubject(&req, &subjectID); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	subject.Code = req.Code
+	subject.Name = req.Name
+	subject.Semester = req.Semester
+	subject.SKS = req.SKS
+	if err := h.DB.Save(&subject).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui mata kuliah"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    subject,
+		"message": "Mata kuliah berhasil diperbarui",
+	})
+}
+
+// DeleteSubject deletes a subject. Refuses when attendance exists unless ?cascade=true.
+// DELETE /api/academic/subjects/:id
+func (h *AcademicHandler) DeleteSubject(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+	}
+
+	var meetingIDs []uuid.UUID
+	h.DB.Model(&models.Meeting{}).Where("subject_id = ?", subjectID).Pluck("id", &meetingIDs)
+
+	var sessionCount, gradeCount int64
+	if len(meetingIDs) > 0 {
+		h.DB.Model(&models.AttendanceSession{}).Where("meeting_id IN ?", meetingIDs).Count(&sessionCount)
+	}
+	h.DB.Model(&models.StudentGrade{}).Where("subject_id = ?", subjectID).Count(&gradeCount)
+	if (sessionCount > 0 || gradeCount > 0) && c.Query("cascade") != "true" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":  false,
+			"error":    fmt.Sprintf("Mata kuliah masih dipakai %d sesi absensi dan %d nilai. Kirim ?cascade=true untuk menghapus beserta datanya.", sessionCount, gradeCount),
+			"sessions": sessionCount,
+			"grades":   gradeCount,
+		})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if len(meetingIDs) > 0 {
+			if err := deleteMeetingAttendance(tx, meetingIDs); err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", meetingIDs).Delete(&models.Meeting{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("subject_id = ?", subjectID).Delete(&models.SubjectTimingRule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_id = ?", subjectID).Delete(&models.TeachingAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subject_id = ?", subjectID).Delete(&models.ScheduleSlot{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.GradeScore{}, &models.GradeComponent{}, &models.StudentGrade{}, &models.KRSItem{}, &models.Enrollment{}} {
+			if err := tx.Where("subject_id = ?", subjectID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		var examIDs []uuid.UUID
+		tx.Model(&models.Exam{}).Where("subject_id = ?", subjectID).Pluck("id", &examIDs)
+		if err := deleteExams(tx, examIDs); err != nil {
+			return err
+		}
+		return tx.Delete(&subject).Error
+	})
+	if err != nil {
+		fmt.Printf("❌ Delete subject failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus mata kuliah"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Mata kuliah " + subject.Name + " berhasil dihapus",
+	})
+}
+
+// ========================================
+// CLASSES
+// ========================================
+
+// ClassRequest represents create/update class payload
+type ClassRequest struct {
+	Name            string `json:"name" validate:"required,max=50"`
+	CurrentSemester *int   `json:"current_semester" validate:"omitempty,min=1,max=14"` // cohort's curriculum semester
+}
+
+// CreateClass creates a new class
+// POST /api/academic/classes
+func (h *AcademicHandler) CreateClass(c *fiber.Ctx) error {
+	var req ClassRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	var dup int64
+	h.DB.Model(&models.Class{}).Where("LOWER(name) = LOWER(?)", name).Count(&dup)
+	if dup > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Nama kelas sudah ada"})
+	}
+
+	class := models.Class{Name: name, CurrentSemester: req.CurrentSemester}
+	if err := h.DB.Create(&class).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat kelas"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    class,
+		"message": "Kelas berhasil dibuat",
+	})
+}
+
+// UpdateClass renames a class and optionally moves the cohort to another semester
+// PUT /api/academic/classes/:id
+func (h *AcademicHandler) UpdateClass(c *fiber.Ctx) error {
+	classID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid class ID"})
+	}
+
+	var class models.Class
+	if err := h.DB.Where("id = ?", classID).First(&class).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Kelas tidak ditemukan"})
+	}
+
+	var req ClassRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	var dup int64
+	h.DB.Model(&models.Class{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, classID).Count(&dup)
+	if dup > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Nama kelas sudah ada"})
+	}
+
+	class.Name = name
+	if req.CurrentSemester != nil {
+		class.CurrentSemester = req.CurrentSemester
+	}
+	if err := h.DB.Save(&class).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui kelas"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    class,
+		"message": "Kelas berhasil diperbarui",
+	})
+}
+
+// DeleteClass deletes an empty class. Classes with students, sessions or transactions are refused.
+// DELETE /api/academic/classes/:id
+func (h *AcademicHandler) DeleteClass(c *fiber.Ctx) error {
+	classID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid class ID"})
+	}
+
+	var class models.Class
+	if err := h.DB.Where("id = ?", classID).First(&class).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Kelas tidak ditemukan"})
+	}
+
+	var students, sessions, transactions int64
+	h.DB.Model(&models.Profile{}).Where("class_id = ?", classID).Count(&students)
+	h.DB.Model(&models.AttendanceSession{}).Where("class_id = ?", classID).Count(&sessions)
+	h.DB.Model(&models.Transaction{}).Where("class_id = ?", classID).Count(&transactions)
+	if students > 0 || sessions > 0 || transactions > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Kelas masih memiliki mahasiswa, sesi absensi atau transaksi. Pindahkan datanya terlebih dahulu.",
+			"usage": fiber.Map{
+				"students":     students,
+				"sessions":     sessions,
+				"transactions": transactions,
+			},
+		})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("class_id = ?", classID).Delete(&models.TeachingAssignment{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.ScheduleSlot{}, &models.KRSItem{}, &models.Enrollment{}} {
+			if err := tx.Where("class_id = ?", classID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&class).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus kelas"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kelas " + class.Name + " berhasil dihapus",
+	})
+}
+
+// ========================================
+// MEETINGS
+// ========================================
+
+// GenerateMeetingsRequest represents bulk meeting generation
+type GenerateMeetingsRequest struct {
+	Count        int      `json:"count" validate:"required,min=1,max=14"`
+	StartDate    string   `json:"start_date"`    // YYYY-MM-DD, optional
+	IntervalDays int      `json:"interval_days"` // default 7 (weekly)
+	Topics       []string `json:"topics"`        // topics[i] goes to the i-th generated meeting
+}
+
+// GenerateMeetings appends N meetings to a subject, numbered after the existing ones
+// POST /api/academic/subjects/:id/meetings/generate
+func (h *AcademicHandler) GenerateMeetings(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+
+	if !teachesSubject(h.DB, c.Locals("user").(middleware.UserContext), subjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini"})
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+	}
+
+	var req GenerateMeetingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var start *time.Time
+	if req.StartDate != "" {
+		d, err := time.ParseInLocation("2006-01-02", req.StartDate, WIB)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "start_date harus berformat YYYY-MM-DD"})
+		}
+		start = &d
+	}
+	interval := req.IntervalDays
+	if interval <= 0 {
+		interval = 7
+	}
+
+	var maxNumber int
+	h.DB.Model(&models.Meeting{}).Where("subject_id = ?", subjectID).Select("COALESCE(MAX(meeting_number), 0)").Scan(&maxNumber)
+	if maxNumber+req.Count > models.MaxMeetings {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Maksimal %d pertemuan per mata kuliah; %s sudah punya %d, sisa %d", models.MaxMeetings, subject.Name, maxNumber, max(models.MaxMeetings-maxNumber, 0)),
+		})
+	}
+
+	meetings := make([]models.Meeting, req.Count)
+	for i := range meetings {
+		meetings[i] = models.Meeting{
+			SubjectID:     subjectID,
+			MeetingNumber: maxNumber + i + 1,
+		}
+		if i < len(req.Topics) && strings.TrimSpace(req.Topics[i]) != "" {
+			topic := strings.TrimSpace(req.Topics[i])
+			meetings[i].Topic = &topic
+		}
+		if start != nil {
+			date := start.AddDate(0, 0, i*interval)
+			meetings[i].Date = &date
+		}
+	}
+
+	if err := h.DB.Create(&meetings).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat pertemuan"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    meetings,
+		"message": fmt.Sprintf("%d pertemuan ditambahkan ke %s", len(meetings), subject.Name),
+	})
+}
+
+// UpdateMeetingRequest represents editable meeting fields
+type UpdateMeetingRequest struct {
+	Topic *string `json:"topic"`
+	Date  *string `json:"date"` // YYYY-MM-DD, empty string clears it
+}
+
+// UpdateMeeting edits the topic/date of a meeting
+// PUT /api/academic/meetings/:id
+func (h *AcademicHandler) UpdateMeeting(c *fiber.Ctx) error {
+	meetingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid meeting ID"})
+	}
+
+	var meeting models.Meeting
+	if err := h.DB.Where("id = ?", meetingID).First(&meeting).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pertemuan tidak ditemukan"})
+	}
+
+	if !teachesSubject(h.DB, c.Locals("user").(middleware.UserContext), meeting.SubjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini"})
+	}
+
+	var req UpdateMeetingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	if req.Topic != nil {
+		topic := strings.TrimSpace(*req.Topic)
+		meeting.Topic = &topic
+		if topic == "" {
+			meeting.Topic = nil
+		}
+	}
+	if req.Date != nil {
+		if *req.Date == "" {
+			meeting.Date = nil
+		} else {
+			d, err := time.ParseInLocation("2006-01-02", *req.Date, WIB)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "date harus berformat YYYY-MM-DD"})
+			}
+			meeting.Date = &d
+		}
+	}
+
+	if err := h.DB.Save(&meeting).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui pertemuan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    meeting,
+	})
+}
+
+// ReorderMeetingsRequest lists every meeting of the subject in the desired order
+type ReorderMeetingsRequest struct {
+	MeetingIDs []uuid.UUID `json:"meeting_ids" validate:"required,min=1"`
+}
+
+// ReorderMeetings renumbers the meetings of a subject (1..N) in the given order
+// PUT /api/academic/subjects/:id/meetings/order
+func (h *AcademicHandler) ReorderMeetings(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+
+	if !teachesSubject(h.DB, c.Locals("user").(middleware.UserContext), subjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini"})
+	}
+
+	var req ReorderMeetingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var existing []uuid.UUID
+	h.DB.Model(&models.Meeting{}).Where("subject_id = ?", subjectID).Pluck("id", &existing)
+
+	// The list must be a permutation of the subject's meetings
+	known := make(map[uuid.UUID]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	seen := make(map[uuid.UUID]bool, len(req.MeetingIDs))
+	for _, id := range req.MeetingIDs {
+		if !known[id] || seen[id] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Daftar pertemuan tidak valid atau duplikat"})
+		}
+		seen[id] = true
+	}
+	if len(req.MeetingIDs) != len(existing) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Semua pertemuan mata kuliah harus disertakan"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		return renumberMeetings(tx, req.MeetingIDs)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengurutkan pertemuan"})
+	}
+
+	var meetings []models.Meeting
+	h.DB.Where("subject_id = ?", subjectID).Order("meeting_number ASC").Find(&meetings)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    meetings,
+		"message": "Urutan pertemuan diperbarui",
+	})
+}
+
+// DeleteMeeting deletes a meeting and renumbers the following ones.
+// Refuses when attendance sessions reference it unless ?cascade=true.
+// DELETE /api/academic/meetings/:id
+func (h *AcademicHandler) DeleteMeeting(c *fiber.Ctx) error {
+	meetingID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid meeting ID"})
+	}
+
+	var meeting models.Meeting
+	if err := h.DB.Where("id = ?", meetingID).First(&meeting).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pertemuan tidak ditemukan"})
+	}
+
+	if !teachesSubject(h.DB, c.Locals("user").(middleware.UserContext), meeting.SubjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini"})
+	}
+
+	var sessionCount int64
+	h.DB.Model(&models.AttendanceSession{}).Where("meeting_id = ?", meetingID).Count(&sessionCount)
+	if sessionCount > 0 && c.Query("cascade") != "true" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":  false,
+			"error":    fmt.Sprintf("Pertemuan masih dipakai %d sesi absensi. Kirim ?cascade=true untuk menghapus beserta datanya.", sessionCount),
+			"sessions": sessionCount,
+		})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteMeetingAttendance(tx, []uuid.UUID{meetingID}); err != nil {
+			return err
+		}
+		if err := tx.Delete(&meeting).Error; err != nil {
+			return err
+		}
+		// Close the gap so meeting numbers stay sequential
+		var remaining []uuid.UUID
+		if err := tx.Model(&models.Meeting{}).Where("subject_id = ?", meeting.SubjectID).
+			Order("meeting_number ASC").Pluck("id", &remaining).Error; err != nil {
+			return err
+		}
+		return renumberMeetings(tx, remaining)
+	})
+	if err != nil {
+		fmt.Printf("❌ Delete meeting failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus pertemuan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("Pertemuan %d berhasil dihapus", meeting.MeetingNumber),
+	})
+}
+
+// Helper: Number the given meetings 1..N in order, inside the caller's transaction. Updating
+// in place would collide with UNIQUE(subject_id, meeting_number) halfway, and the 1..14 CHECK
+// leaves no room for a temporary offset, so the unique check is deferred to commit.
+func renumberMeetings(tx *gorm.DB, ids []uuid.UUID) error {
+	if err := tx.Exec("SET CONSTRAINTS meetings_subject_id_meeting_number_key DEFERRED").Error; err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := tx.Model(&models.Meeting{}).Where("id = ? AND meeting_number <> ?", id, i+1).
+			Update("meeting_number", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper: Remove sessions of the given meetings together with their records and flags
+func deleteMeetingAttendance(tx *gorm.DB, meetingIDs []uuid.UUID) error {
+	sessions := tx.Model(&models.AttendanceSession{}).Select("id").Where("meeting_id IN ?", meetingIDs)
+	if err := tx.Where("session_id IN (?)", sessions).Delete(&models.ScanFlag{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("session_id IN (?)", sessions).Delete(&models.AttendanceRecord{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("meeting_id IN ?", meetingIDs).Delete(&models.AttendanceSession{}).Error
+}
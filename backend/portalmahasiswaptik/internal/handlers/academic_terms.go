@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/rollover"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ========================================
+// SEMESTERS & ACADEMIC YEARS
+// ========================================
+
+// SemesterSummary is a curriculum semester with its subjects and the cohorts currently in it
+type SemesterSummary struct {
+	models.Semester
+	Subjects []models.Subject `json:"subjects"`
+	Classes  []models.Class   `json:"classes"`
+}
+
+// AcademicYearRequest represents the create academic year payload. Both terms are created
+// with it: ganjil for the first six months, genap for the rest.
+type AcademicYearRequest struct {
+	Name      string `json:"name" validate:"required,max=20"` // "2026/2027"
+	StartDate string `json:"start_date" validate:"required"`  // YYYY-MM-DD
+	EndDate   string `json:"end_date" validate:"required"`    // YYYY-MM-DD
+}
+
+// TermRequest represents the update term payload
+type TermRequest struct {
+	StartDate string `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" validate:"required"`   // YYYY-MM-DD
+}
+
+// RolloverRequest represents the semester rollover payload. Dry run unless dry_run is false.
+type RolloverRequest struct {
+	NextTermID *uuid.UUID `json:"next_term_id"`
+	StartDate  string     `json:"start_date"` // YYYY-MM-DD, optional
+	EndDate    string     `json:"end_date"`   // YYYY-MM-DD, optional
+	DryRun     *bool      `json:"dry_run"`
+}
+
+// Helper: Parse a start/end date pair (YYYY-MM-DD, WIB); either may be empty when optional
+func parseDateRange(startRaw, endRaw string) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+	if startRaw != "" {
+		d, err := time.ParseInLocation("2006-01-02", startRaw, WIB)
+		if err != nil {
+			return nil, nil, fmt.Errorf("start_date harus berformat YYYY-MM-DD")
+		}
+		start = &d
+	}
+	if endRaw != "" {
+		d, err := time.ParseInLocation("2006-01-02", endRaw, WIB)
+		if err != nil {
+			return nil, nil, fmt.Errorf("end_date harus berformat YYYY-MM-DD")
+		}
+		end = &d
+	}
+	if start != nil && end != nil && !end.After(*start) {
+		return nil, nil, fmt.Errorf("end_date harus setelah start_date")
+	}
+	return start, end, nil
+}
+
+// GetSemesters lists the curriculum semesters with their subjects and current cohorts
+// GET /api/academic/semesters
+func (h *AcademicHandler) GetSemesters(c *fiber.Ctx) error {
+	var semesters []models.Semester
+	h.DB.Order("id ASC").Find(&semesters)
+
+	var subjects []models.Subject
+	h.DB.Order("code ASC").Find(&subjects)
+	var classes []models.Class
+	h.DB.Where("current_semester IS NOT NULL").Order("name ASC").Find(&classes)
+
+	result := make([]SemesterSummary, 0, len(semesters))
+	for _, sem := range semesters {
+		summary := SemesterSummary{Semester: sem, Subjects: []models.Subject{}, Classes: []models.Class{}}
+		for _, s := range subjects {
+			if s.Semester == sem.ID {
+				summary.Subjects = append(summary.Subjects, s)
+			}
+		}
+		for _, cl := range classes {
+			if *cl.CurrentSemester == sem.ID {
+				summary.Classes = append(summary.Classes, cl)
+			}
+		}
+		result = append(result, summary)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetAcademicYears lists academic years with their terms, newest first
+// GET /api/academic/years
+func (h *AcademicHandler) GetAcademicYears(c *fiber.Ctx) error {
+	var years []models.AcademicYear
+	h.DB.Preload("Terms", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date ASC")
+	}).Order("start_date DESC").Find(&years)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    years,
+	})
+}
+
+// CreateAcademicYear creates an academic year together with its ganjil and genap terms
+// POST /api/academic/years
+func (h *AcademicHandler) CreateAcademicYear(c *fiber.Ctx) error {
+	var req AcademicYearRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	var first, second int
+	if _, err := fmt.Sscanf(name, "%d/%d", &first, &second); err != nil || second != first+1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Nama tahun akademik harus berformat YYYY/YYYY, mis. 2026/2027"})
+	}
+	start, end, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	var dup int64
+	h.DB.Model(&models.AcademicYear{}).Where("name = ?", name).Count(&dup)
+	if dup > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Tahun akademik sudah ada"})
+	}
+
+	split := start.AddDate(0, 6, 0)
+	if !split.Before(*end) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Tahun akademik harus lebih dari 6 bulan"})
+	}
+
+	year := models.AcademicYear{Name: name, StartDate: *start, EndDate: *end}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&year).Error; err != nil {
+			return err
+		}
+		year.Terms = []models.AcademicTerm{
+			{AcademicYearID: year.ID, Parity: models.TermGanjil, Name: rollover.TermName(models.TermGanjil, name), StartDate: *start, EndDate: split.AddDate(0, 0, -1)},
+			{AcademicYearID: year.ID, Parity: models.TermGenap, Name: rollover.TermName(models.TermGenap, name), StartDate: split, EndDate: *end},
+		}
+		return tx.Create(&year.Terms).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat tahun akademik"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    year,
+		"message": "Tahun akademik " + name + " berhasil dibuat",
+	})
+}
+
+// GetActiveTerm returns the active term with its academic year
+// GET /api/academic/terms/active
+func (h *AcademicHandler) GetActiveTerm(c *fiber.Ctx) error {
+	var term models.AcademicTerm
+	if err := h.DB.Preload("AcademicYear").Where("is_active = ?", true).First(&term).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Belum ada semester aktif"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    term,
+	})
+}
+
+// UpdateTerm changes the first and last day of a term that is not archived yet
+// PUT /api/academic/terms/:id
+func (h *AcademicHandler) UpdateTerm(c *fiber.Ctx) error {
+	termID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid term ID"})
+	}
+
+	var term models.AcademicTerm
+	if err := h.DB.Where("id = ?", termID).First(&term).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Semester tidak ditemukan"})
+	}
+	if term.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Semester sudah diarsipkan"})
+	}
+
+	var req TermRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	start, end, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	term.StartDate, term.EndDate = *start, *end
+	if err := h.DB.Save(&term).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui semester"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    term,
+		"message": "Semester berhasil diperbarui",
+	})
+}
+
+// ActivateTerm marks a term as the active one without rolling over. Meant for the first
+// setup; switching terms later should go through the rollover.
+// POST /api/academic/terms/:id/activate
+func (h *AcademicHandler) ActivateTerm(c *fiber.Ctx) error {
+	termID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid term ID"})
+	}
+
+	var term models.AcademicTerm
+	if err := h.DB.Where("id = ?", termID).First(&term).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Semester tidak ditemukan"})
+	}
+	if term.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Semester sudah diarsipkan"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AcademicTerm{}).Where("is_active = ? AND id <> ?", true, termID).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&term).Update("is_active", true).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengaktifkan semester"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    term,
+		"message": "Semester " + term.Name + " sekarang aktif",
+	})
+}
+
+// RolloverTerm archives the active term and moves everyone to the next one. Dry run by
+// default; the same logic backs cmd/rollover_semester.
+// POST /api/academic/terms/rollover
+func (h *AcademicHandler) RolloverTerm(c *fiber.Ctx) error {
+	var req RolloverRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	start, end, err := parseDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	report, err := rollover.Run(h.DB, rollover.Options{
+		NextTermID: req.NextTermID,
+		StartDate:  start,
+		EndDate:    end,
+		DryRun:     dryRun,
+	})
+	if errors.Is(err, rollover.ErrNoActiveTerm) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": err.Error()})
+	} else if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Rollover gagal: " + err.Error()})
+	}
+
+	message := "Pergantian semester ke " + report.NextTerm.Name + " berhasil"
+	if dryRun {
+		message = "Simulasi pergantian semester (belum disimpan)"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+		"message": message,
+	})
+}
@@ -0,0 +1,660 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/storage"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxCertificateSize caps certificate uploads (5 MB)
+const maxCertificateSize = 5 << 20
+
+// AchievementHandler manages the achievements registry, its review and the leaderboards
+type AchievementHandler struct {
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Storage  *storage.SupabaseStorage
+	Notify   *notify.Service
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(db *gorm.DB, validate *validator.Validate, storageSrv *storage.SupabaseStorage, notifier *notify.Service) *AchievementHandler {
+	return &AchievementHandler{DB: db, Validate: validate, Storage: storageSrv, Notify: notifier}
+}
+
+// AchievementRequest represents create/update achievement payload
+type AchievementRequest struct {
+	ClassID         *uuid.UUID `json:"class_id"`       // admin dev/dosen only; others credit their own class
+	CompetitionID   *uuid.UUID `json:"competition_id"` // optional link to the competitions board
+	CompetitionName string     `json:"competition_name" validate:"max=200"`
+	MemberNIMs      []string   `json:"member_nims" validate:"max=20,dive,required"`
+	Rank            string     `json:"rank" validate:"required,max=50"`
+	Level           string     `json:"level" validate:"omitempty,oneof=internal regional nasional internasional"`
+	EventDate       string     `json:"event_date" validate:"required"` // YYYY-MM-DD
+}
+
+// ReviewAchievementRequest represents the verify/reject decision
+type ReviewAchievementRequest struct {
+	Approve bool    `json:"approve"`
+	Note    *string `json:"note"`
+}
+
+// AchievementStanding is one row of an achievement leaderboard
+type AchievementStanding struct {
+	Rank         int       `json:"rank"`
+	ID           uuid.UUID `json:"id"` // class ID or student user ID
+	Name         string    `json:"name"`
+	NIM          string    `json:"nim,omitempty"`
+	ClassName    string    `json:"class_name,omitempty"`
+	Points       int       `json:"points"`
+	Achievements int       `json:"achievements"`
+	Gold         int       `json:"gold"`
+	Silver       int       `json:"silver"`
+	Bronze       int       `json:"bronze"`
+}
+
+// Helper: Roles whose achievements need no review and who review the others
+func isAchievementVerifier(role models.AppRole) bool {
+	return role == models.RoleAdminDev || role == models.RoleAdminDosen
+}
+
+// Helper: Achievements the user may see. Verified ones are public; pending and rejected ones
+// are visible to verifiers, the class admin, members and the submitter.
+func achievementsVisibleTo(db *gorm.DB, user middleware.UserContext) *gorm.DB {
+	query := db.Model(&models.ClassAchievement{})
+	if isAchievementVerifier(user.Role) {
+		return query
+	}
+	memberOf := db.Model(&models.AchievementMember{}).Select("achievement_id").Where("student_id = ?", user.UserID)
+	if user.Role == models.RoleAdminKelas && user.ClassID != nil {
+		return query.Where("status = ? OR class_id = ? OR created_by = ? OR id IN (?)", models.AchievementVerified, *user.ClassID, user.UserID, memberOf)
+	}
+	return query.Where("status = ? OR created_by = ? OR id IN (?)", models.AchievementVerified, user.UserID, memberOf)
+}
+
+// Helper: Verifiers and the class admin edit anything; submitters edit until it is verified
+func canEditAchievement(user middleware.UserContext, a *models.ClassAchievement) bool {
+	switch {
+	case isAchievementVerifier(user.Role):
+		return true
+	case user.Role == models.RoleAdminKelas && user.ClassID != nil && *user.ClassID == a.ClassID:
+		return true
+	}
+	return a.CreatedBy != nil && *a.CreatedBy == user.UserID && a.Status != models.AchievementVerified
+}
+
+// Helper: Load an achievement visible to the user by the :id param
+func (h *AchievementHandler) findAchievement(c *fiber.Ctx) (*models.ClassAchievement, error) {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var a models.ClassAchievement
+	if err := achievementsVisibleTo(h.DB, user).Preload("Members.Student").Preload("Class").
+		Where("id = ?", id).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Helper: Validate the payload and copy it onto the achievement, returning the members
+func (h *AchievementHandler) applyRequest(c *fiber.Ctx, user middleware.UserContext, a *models.ClassAchievement) ([]models.Profile, error) {
+	var req AchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return nil, errors.New("Validasi Gagal: " + err.Error())
+	}
+
+	eventDate, err := time.Parse("2006-01-02", req.EventDate)
+	if err != nil {
+		return nil, errors.New("event_date harus berformat YYYY-MM-DD")
+	}
+
+	name := strings.TrimSpace(req.CompetitionName)
+	if req.CompetitionID != nil {
+		var comp models.Competition
+		if err := h.DB.Where("id = ?", *req.CompetitionID).First(&comp).Error; err != nil {
+			return nil, errors.New("Lomba tidak ditemukan")
+		}
+		if name == "" {
+			name = comp.Title
+		}
+	}
+	if name == "" {
+		return nil, errors.New("competition_name atau competition_id wajib diisi")
+	}
+
+	// Students always credit themselves
+	nims := req.MemberNIMs
+	if user.Role == models.RoleMahasiswa {
+		var self models.Profile
+		if err := h.DB.Where("user_id = ?", user.UserID).First(&self).Error; err == nil {
+			nims = append([]string{self.NIM}, nims...)
+		}
+	}
+	members, unknown := resolveStudentsByNIM(h.DB, nims)
+	if len(unknown) > 0 {
+		return nil, errors.New("NIM tidak ditemukan: " + strings.Join(unknown, ", "))
+	}
+	if len(members) == 0 {
+		return nil, errors.New("Minimal satu mahasiswa harus dicantumkan (member_nims)")
+	}
+
+	switch {
+	case isAchievementVerifier(user.Role) && req.ClassID != nil:
+		a.ClassID = *req.ClassID
+	case user.Role == models.RoleAdminKelas || user.Role == models.RoleMahasiswa:
+		if user.ClassID == nil {
+			return nil, errors.New("Akun Anda belum terhubung ke kelas")
+		}
+		a.ClassID = *user.ClassID
+	case members[0].ClassID != nil:
+		a.ClassID = *members[0].ClassID
+	default:
+		return nil, errors.New("class_id wajib diisi")
+	}
+
+	a.CompetitionID = req.CompetitionID
+	a.CompetitionName = name
+	a.Rank = strings.TrimSpace(req.Rank)
+	a.EventDate = eventDate
+	a.Level = req.Level
+	if a.Level == "" {
+		a.Level = "nasional"
+	}
+	names := make([]string, len(members))
+	for i, m := range members {
+		names[i] = m.FullName
+	}
+	a.StudentNames = strings.Join(names, ", ")
+
+	// Anything not entered by a verifier goes (back) to review
+	if isAchievementVerifier(user.Role) {
+		now := time.Now()
+		a.Status, a.ReviewedBy, a.ReviewedAt = models.AchievementVerified, &user.UserID, &now
+	} else {
+		a.Status, a.ReviewedBy, a.ReviewedAt, a.ReviewNote = models.AchievementPending, nil, nil, nil
+	}
+	return members, nil
+}
+
+// Helper: Student profiles for the NIMs (deduplicated, in order) and the NIMs not found
+func resolveStudentsByNIM(db *gorm.DB, nims []string) ([]models.Profile, []string) {
+	var ordered []string
+	seen := make(map[string]bool, len(nims))
+	for _, nim := range nims {
+		nim = strings.TrimSpace(nim)
+		if nim != "" && !seen[nim] {
+			seen[nim] = true
+			ordered = append(ordered, nim)
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+
+	var found []models.Profile
+	db.Where("nim IN ? AND role IN ?", ordered, []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas}).Find(&found)
+	byNIM := make(map[string]models.Profile, len(found))
+	for _, p := range found {
+		byNIM[p.NIM] = p
+	}
+
+	var members []models.Profile
+	var unknown []string
+	for _, nim := range ordered {
+		if p, ok := byNIM[nim]; ok {
+			members = append(members, p)
+		} else {
+			unknown = append(unknown, nim)
+		}
+	}
+	return members, unknown
+}
+
+// Helper: Replace the achievement's members
+func setAchievementMembers(tx *gorm.DB, achievementID uuid.UUID, members []models.Profile) error {
+	if err := tx.Where("achievement_id = ?", achievementID).Delete(&models.AchievementMember{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.AchievementMember, len(members))
+	for i, m := range members {
+		rows[i] = models.AchievementMember{AchievementID: achievementID, StudentID: m.UserID}
+	}
+	return tx.Create(&rows).Error
+}
+
+// GetAchievements lists achievements, newest event first.
+// Filters: ?class_id=, ?student_id=, ?competition_id=, ?status=
+// GET /api/achievements
+func (h *AchievementHandler) GetAchievements(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := achievementsVisibleTo(h.DB, user).Preload("Members.Student").Preload("Class")
+	if classID := c.Query("class_id"); classID != "" {
+		query = query.Where("class_id = ?", classID)
+	}
+	if studentID := c.Query("student_id"); studentID != "" {
+		query = query.Where("id IN (?)", h.DB.Model(&models.AchievementMember{}).Select("achievement_id").Where("student_id = ?", studentID))
+	}
+	if competitionID := c.Query("competition_id"); competitionID != "" {
+		query = query.Where("competition_id = ?", competitionID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var achievements []models.ClassAchievement
+	if err := query.Order("event_date DESC, created_at DESC").Find(&achievements).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch achievements"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": achievements})
+}
+
+// GetAchievement returns one achievement with its members
+// GET /api/achievements/:id
+func (h *AchievementHandler) GetAchievement(c *fiber.Ctx) error {
+	a, err := h.findAchievement(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Prestasi tidak ditemukan"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": a, "points": a.Points()})
+}
+
+// CreateAchievement records an achievement. Entries by admin dev or lecturers are verified
+// right away; the rest wait for review.
+// POST /api/achievements
+func (h *AchievementHandler) CreateAchievement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	a := models.ClassAchievement{CreatedBy: &user.UserID}
+	members, err := h.applyRequest(c, user, &a)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		return setAchievementMembers(tx, a.ID, members)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to create achievement"})
+	}
+	h.DB.Preload("Members.Student").Preload("Class").First(&a, "id = ?", a.ID)
+
+	message := "Prestasi berhasil dicatat"
+	if a.Status == models.AchievementPending {
+		message = "Prestasi dikirim dan menunggu verifikasi"
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": a, "message": message})
+}
+
+// UpdateAchievement edits an achievement. Edits by anyone but a verifier send it back to review.
+// PUT /api/achievements/:id
+func (h *AchievementHandler) UpdateAchievement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	a, err := h.findAchievement(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Prestasi tidak ditemukan"})
+	}
+	if !canEditAchievement(user, a) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak memiliki akses untuk mengubah prestasi ini"})
+	}
+
+	members, err := h.applyRequest(c, user, a)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	a.Members, a.Class = nil, nil
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(a).Error; err != nil {
+			return err
+		}
+		return setAchievementMembers(tx, a.ID, members)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to update achievement"})
+	}
+	h.DB.Preload("Members.Student").Preload("Class").First(a, "id = ?", a.ID)
+
+	return c.JSON(fiber.Map{"success": true, "data": a, "message": "Prestasi berhasil diperbarui"})
+}
+
+// DeleteAchievement removes an achievement with its members
+// DELETE /api/achievements/:id
+func (h *AchievementHandler) DeleteAchievement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	a, err := h.findAchievement(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Prestasi tidak ditemukan"})
+	}
+	if !canEditAchievement(user, a) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak memiliki akses untuk menghapus prestasi ini"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("achievement_id = ?", a.ID).Delete(&models.AchievementMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ClassAchievement{}, "id = ?", a.ID).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to delete achievement"})
+	}
+	h.removeCertificate(a.CertificateURL)
+	return c.JSON(fiber.Map{"success": true, "message": "Prestasi berhasil dihapus"})
+}
+
+// Helper: Remove a certificate object from storage; links to files elsewhere are left alone
+func (h *AchievementHandler) removeCertificate(url *string) {
+	if url == nil || h.Storage == nil {
+		return
+	}
+	name, ok := h.Storage.ObjectPath(*url)
+	if !ok {
+		return
+	}
+	if err := h.Storage.DeleteFile(name); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to delete certificate %s: %v\n", name, err)
+	}
+}
+
+// UploadCertificate attaches a certificate (PDF, JPEG or PNG, max 5 MB), replacing and deleting
+// the previous one. A new certificate on a verified achievement sends it back to review unless
+// a verifier uploads it.
+// POST /api/achievements/:id/certificate
+func (h *AchievementHandler) UploadCertificate(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	a, err := h.findAchievement(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Prestasi tidak ditemukan"})
+	}
+	isMember := false
+	for _, m := range a.Members {
+		isMember = isMember || m.StudentID == user.UserID
+	}
+	if !canEditAchievement(user, a) && !isMember && !(a.CreatedBy != nil && *a.CreatedBy == user.UserID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak memiliki akses untuk mengunggah sertifikat ini"})
+	}
+	if h.Storage == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Storage service not initialized"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "file is required"})
+	}
+	if file.Size > maxCertificateSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Ukuran sertifikat maksimal 5 MB"})
+	}
+	content, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "failed to open file"})
+	}
+	defer content.Close()
+
+	// Sniff the content instead of trusting the extension
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(content, head)
+	detected := http.DetectContentType(head[:n])
+	ext := map[string]string{"application/pdf": ".pdf", "image/jpeg": ".jpg", "image/png": ".png"}[detected]
+	if ext == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Sertifikat harus berupa PDF, JPG atau PNG"})
+	}
+	if _, err := content.(io.Seeker).Seek(0, io.SeekStart); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "failed to read file"})
+	}
+
+	objectName := filepath.ToSlash(filepath.Join("certificates", a.ID.String()+"-"+uuid.NewString()[:8]+ext))
+	url, err := h.Storage.UploadFile(objectName, detected, content)
+	if err != nil {
+		fmt.Printf("❌ Error: Certificate upload failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengunggah sertifikat ke storage."})
+	}
+
+	updates := map[string]interface{}{"certificate_url": url}
+	if !isAchievementVerifier(user.Role) && a.Status == models.AchievementVerified {
+		updates["status"] = models.AchievementPending
+		updates["reviewed_by"], updates["reviewed_at"] = nil, nil
+	}
+	if err := h.DB.Model(&models.ClassAchievement{}).Where("id = ?", a.ID).Updates(updates).Error; err != nil {
+		h.removeCertificate(&url)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to save certificate"})
+	}
+	h.removeCertificate(a.CertificateURL)
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"certificate_url": url}, "message": "Sertifikat berhasil diunggah"})
+}
+
+// ReviewAchievement verifies or rejects a submitted achievement and tells the students
+// POST /api/achievements/:id/review
+func (h *AchievementHandler) ReviewAchievement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req ReviewAchievementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+	a, err := h.findAchievement(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Prestasi tidak ditemukan"})
+	}
+
+	status := models.AchievementRejected
+	if req.Approve {
+		status = models.AchievementVerified
+	} else if req.Note == nil || strings.TrimSpace(*req.Note) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Alasan penolakan (note) wajib diisi"})
+	}
+	now := time.Now()
+	if err := h.DB.Model(&models.ClassAchievement{}).Where("id = ?", a.ID).Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": user.UserID,
+		"reviewed_at": now,
+		"review_note": req.Note,
+	}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to review achievement"})
+	}
+
+	var reviewer models.Profile
+	h.DB.Where("user_id = ?", user.UserID).First(&reviewer)
+	recipients := make([]uuid.UUID, 0, len(a.Members)+1)
+	for _, m := range a.Members {
+		recipients = append(recipients, m.StudentID)
+	}
+	if a.CreatedBy != nil {
+		recipients = append(recipients, *a.CreatedBy)
+	}
+	data := map[string]interface{}{
+		"rank":        a.Rank,
+		"competition": a.CompetitionName,
+		"approved":    req.Approve,
+		"reviewer":    reviewer.FullName,
+	}
+	if req.Note != nil {
+		data["note"] = *req.Note
+	}
+	h.Notify.PublishAsync(notify.Event{Type: notify.EventAchievementReviewed, UserIDs: recipients, Link: "/dashboard/leaderboard", Data: data})
+
+	message := "Prestasi diverifikasi"
+	if !req.Approve {
+		message = "Prestasi ditolak"
+	}
+	return c.JSON(fiber.Map{"success": true, "message": message})
+}
+
+// GetAchievementLeaderboard ranks classes (?scope=class, default) or students (?scope=student)
+// by the points of their verified achievements. Filters: ?class_id= (students of one class),
+// ?from=&to= (event dates, YYYY-MM-DD) and ?limit= (students only, default 50).
+// GET /api/achievements/leaderboard
+func (h *AchievementHandler) GetAchievementLeaderboard(c *fiber.Ctx) error {
+	scope := c.Query("scope", "class")
+	if scope != "class" && scope != "student" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "scope must be class or student"})
+	}
+	from, to, err := parseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	query := h.DB.Preload("Members.Student.Class").Where("status = ?", models.AchievementVerified)
+	if from != nil {
+		query = query.Where("event_date >= ?", from.Format("2006-01-02"))
+	}
+	if to != nil {
+		query = query.Where("event_date <= ?", to.Format("2006-01-02"))
+	}
+	var achievements []models.ClassAchievement
+	if err := query.Find(&achievements).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch achievements"})
+	}
+
+	standings := make(map[uuid.UUID]*AchievementStanding)
+	add := func(id uuid.UUID, entry AchievementStanding, a models.ClassAchievement) {
+		s, ok := standings[id]
+		if !ok {
+			entry.ID = id
+			s = &entry
+			standings[id] = s
+		}
+		s.Points += a.Points()
+		s.Achievements++
+		switch a.Placement() {
+		case 1:
+			s.Gold++
+		case 2:
+			s.Silver++
+		case 3:
+			s.Bronze++
+		}
+	}
+
+	if scope == "class" {
+		var classes []models.Class
+		h.DB.Order("name").Find(&classes)
+		for _, class := range classes {
+			standings[class.ID] = &AchievementStanding{ID: class.ID, Name: class.Name}
+		}
+		for _, a := range achievements {
+			add(a.ClassID, AchievementStanding{Name: "Unknown"}, a)
+		}
+	} else {
+		classFilter := c.Query("class_id")
+		for _, a := range achievements {
+			// Team achievements count fully for every member
+			for _, m := range a.Members {
+				if m.Student == nil {
+					continue
+				}
+				if classFilter != "" && (m.Student.ClassID == nil || m.Student.ClassID.String() != classFilter) {
+					continue
+				}
+				entry := AchievementStanding{Name: m.Student.FullName, NIM: m.Student.NIM}
+				if m.Student.Class != nil {
+					entry.ClassName = m.Student.Class.Name
+				}
+				add(m.StudentID, entry, a)
+			}
+		}
+	}
+
+	board := make([]AchievementStanding, 0, len(standings))
+	for _, s := range standings {
+		board = append(board, *s)
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Points != board[j].Points {
+			return board[i].Points > board[j].Points
+		}
+		if board[i].Achievements != board[j].Achievements {
+			return board[i].Achievements > board[j].Achievements
+		}
+		return board[i].Name < board[j].Name
+	})
+	// Standard competition ranking: equal points share a rank
+	for i := range board {
+		board[i].Rank = i + 1
+		if i > 0 && board[i].Points == board[i-1].Points {
+			board[i].Rank = board[i-1].Rank
+		}
+	}
+	if scope == "student" {
+		limit, _ := strconv.Atoi(c.Query("limit", "50"))
+		if limit > 0 && len(board) > limit {
+			board = board[:limit]
+		}
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": board, "scope": scope})
+}
+
+// LinkAchievementMembers matches the comma separated student_names of achievements without
+// members to student profiles (same class first, then a unique name elsewhere) and reports
+// the names it could not match. Backs cmd/link_achievements.
+func LinkAchievementMembers(db *gorm.DB, dryRun bool) (linked int, unmatched []string, err error) {
+	var achievements []models.ClassAchievement
+	if err := db.Where("id NOT IN (?)", db.Model(&models.AchievementMember{}).Select("achievement_id")).Find(&achievements).Error; err != nil {
+		return 0, nil, err
+	}
+
+	splitter := strings.NewReplacer(";", ",", "&", ",", " dan ", ",", "\n", ",")
+	for _, a := range achievements {
+		var members []models.Profile
+		seen := make(map[uuid.UUID]bool)
+		for _, raw := range strings.Split(splitter.Replace(a.StudentNames), ",") {
+			name := strings.TrimSpace(raw)
+			if name == "" {
+				continue
+			}
+			var matches []models.Profile
+			db.Where("LOWER(full_name) = LOWER(?) AND class_id = ?", name, a.ClassID).Find(&matches)
+			if len(matches) == 0 {
+				db.Where("LOWER(full_name) = LOWER(?)", name).Find(&matches)
+			}
+			if len(matches) != 1 {
+				unmatched = append(unmatched, fmt.Sprintf("%s (%s)", name, a.CompetitionName))
+				continue
+			}
+			if !seen[matches[0].UserID] {
+				seen[matches[0].UserID] = true
+				members = append(members, matches[0])
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		linked++
+		if dryRun {
+			continue
+		}
+		if err := setAchievementMembers(db, a.ID, members); err != nil {
+			return linked, unmatched, err
+		}
+	}
+	return linked, unmatched, nil
+}
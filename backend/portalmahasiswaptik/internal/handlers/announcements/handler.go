@@ -1,100 +1,612 @@
 package announcements
 
 import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Announcement times are shown in WIB
+var wib = time.FixedZone("WIB", 7*3600)
+
 type AnnouncementsHandler struct {
-	DB *gorm.DB
-}
-
-func NewAnnouncementsHandler(db *gorm.DB) *AnnouncementsHandler {
-	return &AnnouncementsHandler{DB: db}
-}
-
-// Mock Data (Moved from Frontend)
-type Announcement struct {
-	ID       int    `json:"id"`
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	Date     string `json:"date"`
-	Category string `json:"category"`
-	IsPinned bool   `json:"isPinned"`
-	IsNew    bool   `json:"isNew"`
-	Type     string `json:"type"`
-}
-
-var mockAnnouncements = []Announcement{
-	{
-		ID:       1,
-		Title:    "Jadwal UAS Semester Ganjil 2024/2025",
-		Content:  "Ujian Akhir Semester akan dilaksanakan pada tanggal 15-27 Januari 2025. Mahasiswa diharapkan mempersiapkan diri dengan baik. Jadwal lengkap dapat dilihat di SIAKAD.",
-		Date:     "20 Jan 2025",
-		Category: "Akademik",
-		IsPinned: true,
-		IsNew:    true,
-		Type:     "warning",
-	},
-	{
-		ID:       2,
-		Title:    "Pembayaran SPP Semester Genap",
-		Content:  "Batas akhir pembayaran SPP semester genap adalah 31 Januari 2025. Mahasiswa yang belum melakukan pembayaran tidak dapat mengakses KRS online.",
-		Date:     "18 Jan 2025",
-		Category: "Keuangan",
-		IsPinned: true,
-		IsNew:    true,
-		Type:     "alert",
-	},
-	{
-		ID:       3,
-		Title:    "Workshop AI & Machine Learning",
-		Content:  "Himpunan PTIK mengadakan workshop AI & Machine Learning pada Sabtu, 25 Januari 2025 di Lab Komputer Gedung D. Pendaftaran melalui link di grup angkatan.",
-		Date:     "15 Jan 2025",
-		Category: "Event",
-		IsPinned: false,
-		IsNew:    true,
-		Type:     "info",
-	},
-	{
-		ID:       4,
-		Title:    "Pendaftaran KKN Semester Genap",
-		Content:  "Pendaftaran KKN untuk semester genap telah dibuka. Mahasiswa semester 6 ke atas dapat mendaftar melalui portal KKN UNJ.",
-		Date:     "10 Jan 2025",
-		Category: "Akademik",
-		IsPinned: false,
-		IsNew:    false,
-		Type:     "info",
-	},
-	{
-		ID:       5,
-		Title:    "Maintenance Server SIAKAD",
-		Content:  "Server SIAKAD akan mengalami maintenance pada Minggu, 12 Januari 2025 pukul 00.00-06.00 WIB. Harap simpan pekerjaan sebelum waktu tersebut.",
-		Date:     "08 Jan 2025",
-		Category: "Sistem",
-		IsPinned: false,
-		IsNew:    false,
-		Type:     "warning",
-	},
-	{
-		ID:       6,
-		Title:    "Lomba Hackathon Nasional 2025",
-		Content:  "Pendaftaran Hackathon Nasional telah dibuka! Hadiah total 50 juta rupiah. Daftar sekarang di halaman Info Lomba.",
-		Date:     "05 Jan 2025",
-		Category: "Lomba",
-		IsPinned: false,
-		IsNew:    false,
-		Type:     "info",
-	},
-}
-
-// GetAnnouncements returns all announcements
-// GET /api/announcements
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Notify   *notify.Service
+}
+
+func NewAnnouncementsHandler(db *gorm.DB, validate *validator.Validate, notifier *notify.Service) *AnnouncementsHandler {
+	return &AnnouncementsHandler{DB: db, Validate: validate, Notify: notifier}
+}
+
+// AnnouncementRequest represents create/update announcement payload
+type AnnouncementRequest struct {
+	Title         string      `json:"title" validate:"required,max=200"`
+	Content       string      `json:"content" validate:"required,max=10000"`
+	Category      string      `json:"category" validate:"required,oneof=Akademik Keuangan Event Lomba Sistem"`
+	Priority      string      `json:"priority" validate:"omitempty,oneof=normal important"`
+	IsPinned      bool        `json:"is_pinned"`
+	TargetClasses []uuid.UUID `json:"target_classes"`                                                                 // empty = every class
+	TargetRoles   []string    `json:"target_roles" validate:"dive,oneof=admin_dev admin_kelas admin_dosen mahasiswa"` // empty = every role
+	PublishAt     *time.Time  `json:"publish_at"`                                                                     // schedule; empty = publish now
+	ExpiresAt     *time.Time  `json:"expires_at"`
+}
+
+// Helper: Classes a lecturer teaches this semester
+func (h *AnnouncementsHandler) taughtClasses(lecturerID uuid.UUID) map[uuid.UUID]bool {
+	var ids []uuid.UUID
+	h.DB.Model(&models.TeachingAssignment{}).Where("lecturer_id = ?", lecturerID).
+		Where(enrollment.CurrentAssignment("teaching_assignments")).Distinct().Pluck("class_id", &ids)
+	taught := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		taught[id] = true
+	}
+	return taught
+}
+
+// Helper: Check the audience a user may announce to. Admin kelas only reach their own class,
+// lecturers only the classes they teach. Returns the target class IDs or an error message.
+func (h *AnnouncementsHandler) resolveTargets(user middleware.UserContext, targets []uuid.UUID) (pq.StringArray, string) {
+	switch user.Role {
+	case models.RoleAdminKelas:
+		if user.ClassID == nil {
+			return nil, "Akun admin kelas belum terhubung ke kelas"
+		}
+		for _, id := range targets {
+			if id != *user.ClassID {
+				return nil, "Admin kelas hanya dapat mengumumkan ke kelasnya sendiri"
+			}
+		}
+		return pq.StringArray{user.ClassID.String()}, ""
+	case models.RoleAdminDosen:
+		if len(targets) == 0 {
+			return nil, "Pilih kelas yang Anda ajar sebagai target pengumuman"
+		}
+		taught := h.taughtClasses(user.UserID)
+		for _, id := range targets {
+			if !taught[id] {
+				return nil, "Anda hanya dapat mengumumkan ke kelas yang Anda ajar"
+			}
+		}
+	}
+
+	classes := make(pq.StringArray, 0, len(targets))
+	seen := make(map[uuid.UUID]bool, len(targets))
+	for _, id := range targets {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		classes = append(classes, id.String())
+	}
+	if len(classes) > 0 {
+		var count int64
+		h.DB.Model(&models.Class{}).Where("id IN ?", []string(classes)).Count(&count)
+		if int(count) != len(classes) {
+			return nil, "Kelas target tidak ditemukan"
+		}
+	}
+	return classes, ""
+}
+
+// Helper: Only admin dev or the author may change an announcement
+func canEdit(user middleware.UserContext, a *models.Announcement) bool {
+	return user.Role == models.RoleAdminDev || a.CreatedBy == user.UserID
+}
+
+// Helper: Fill an announcement from the request
+func applyRequest(a *models.Announcement, req *AnnouncementRequest, classes pq.StringArray) {
+	a.Title = strings.TrimSpace(req.Title)
+	a.Content = strings.TrimSpace(req.Content)
+	a.Category = req.Category
+	a.Priority = req.Priority
+	if a.Priority == "" {
+		a.Priority = models.AnnouncementNormal
+	}
+	a.IsPinned = &req.IsPinned
+	a.TargetClasses = classes
+	a.TargetRoles = pq.StringArray(req.TargetRoles)
+	if a.TargetRoles == nil {
+		a.TargetRoles = pq.StringArray{}
+	}
+	a.PublishAt = req.PublishAt
+	a.ExpiresAt = req.ExpiresAt
+}
+
+// Helper: Check publish/expiry times of a request
+func checkSchedule(req *AnnouncementRequest, now time.Time) string {
+	if req.ExpiresAt == nil {
+		return ""
+	}
+	if !req.ExpiresAt.After(now) {
+		return "expires_at harus di masa depan"
+	}
+	if req.PublishAt != nil && !req.ExpiresAt.After(*req.PublishAt) {
+		return "expires_at harus setelah publish_at"
+	}
+	return ""
+}
+
+// audienceCond matches the published announcements addressed to a user: untargeted ones plus
+// those aimed at their class or role (lecturers also reach the classes they teach this semester)
+func audienceCond(user middleware.UserContext, now time.Time) (string, []interface{}) {
+	classCond := "cardinality(coalesce(target_classes, '{}')) = 0"
+	args := []interface{}{}
+	if user.ClassID != nil {
+		classCond += " OR ? = ANY(target_classes)"
+		args = append(args, user.ClassID.String())
+	}
+	if user.Role == models.RoleAdminDosen {
+		classCond += " OR target_classes && (SELECT coalesce(array_agg(DISTINCT ta.class_id), '{}') FROM teaching_assignments ta WHERE ta.lecturer_id = ? AND " + enrollment.CurrentAssignment("ta") + ")"
+		args = append(args, user.UserID)
+	}
+	args = append(args, string(user.Role), now)
+
+	return "(" + classCond + ") AND (cardinality(coalesce(target_roles, '{}')) = 0 OR ? = ANY(target_roles)) AND (publish_at IS NULL OR publish_at <= ?)", args
+}
+
+// visibleTo scopes announcements to what a user may read: their audience plus, for authors,
+// their own scheduled ones. Admin dev sees everything.
+func visibleTo(db *gorm.DB, user middleware.UserContext, now time.Time) *gorm.DB {
+	query := db.Model(&models.Announcement{})
+	if user.Role == models.RoleAdminDev {
+		return query
+	}
+	cond, args := audienceCond(user, now)
+	return query.Where("("+cond+") OR created_by = ?", append(args, user.UserID)...)
+}
+
+// Helper: Unread, published and unexpired announcements addressed to a user
+func unreadQuery(db *gorm.DB, user middleware.UserContext, now time.Time) *gorm.DB {
+	cond, args := audienceCond(user, now)
+	return db.Model(&models.Announcement{}).
+		Where(cond, args...).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("created_by <> ?", user.UserID).
+		Where("NOT EXISTS (SELECT 1 FROM announcement_reads r WHERE r.announcement_id = announcements.id AND r.user_id = ?)", user.UserID)
+}
+
+// Helper: Flag announcements the user has not read yet (their own never count as new)
+func (h *AnnouncementsHandler) markNew(user middleware.UserContext, announcements []models.Announcement, now time.Time) {
+	if len(announcements) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, len(announcements))
+	for i, a := range announcements {
+		ids[i] = a.ID
+	}
+	var readIDs []uuid.UUID
+	h.DB.Model(&models.AnnouncementRead{}).Where("user_id = ? AND announcement_id IN ?", user.UserID, ids).Pluck("announcement_id", &readIDs)
+	read := make(map[uuid.UUID]bool, len(readIDs))
+	for _, id := range readIDs {
+		read[id] = true
+	}
+	for i := range announcements {
+		a := &announcements[i]
+		published := a.PublishAt == nil || !a.PublishAt.After(now)
+		a.IsNew = published && !read[a.ID] && a.CreatedBy != user.UserID
+	}
+}
+
+// GetAnnouncements lists the announcements for the viewer's class and role, pinned first,
+// hiding expired ones
+// GET /api/announcements?category=&q=&include_expired=
 func (h *AnnouncementsHandler) GetAnnouncements(c *fiber.Ctx) error {
-	// In the future, this will fetch from DB using h.DB
-	// For now, return mock data
+	user := c.Locals("user").(middleware.UserContext)
+
+	now := time.Now()
+	query := visibleTo(h.DB, user, now).Preload("Author", func(db *gorm.DB) *gorm.DB {
+		return db.Select("user_id", "full_name", "avatar_url")
+	})
+
+	// Expired announcements only on request: all of them for admin dev, own ones for other authors
+	switch {
+	case c.Query("include_expired") == "true" && user.Role == models.RoleAdminDev:
+	case c.Query("include_expired") == "true":
+		query = query.Where("expires_at IS NULL OR expires_at > ? OR created_by = ?", now, user.UserID)
+	default:
+		query = query.Where("expires_at IS NULL OR expires_at > ?", now)
+	}
+
+	if category := c.Query("category"); category != "" && category != "Semua" {
+		query = query.Where("category = ?", category)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		// Full-text match on title + content, with a substring fallback for partial words
+		query = query.Where("to_tsvector('simple', title || ' ' || content) @@ websearch_to_tsquery('simple', ?) OR title ILIKE ? OR content ILIKE ?",
+			q, "%"+q+"%", "%"+q+"%")
+	}
+
+	var announcements []models.Announcement
+	if err := query.Order("is_pinned DESC NULLS LAST").Order("created_at DESC").Find(&announcements).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch announcements"})
+	}
+	h.markNew(user, announcements, now)
+
+	var unread int64
+	unreadQuery(h.DB, user, now).Count(&unread)
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       announcements,
+		"unread":     unread,
+		"categories": models.AnnouncementCategories,
+	})
+}
+
+// GetAnnouncement returns a single announcement visible to the caller and marks it read
+// GET /api/announcements/:id
+func (h *AnnouncementsHandler) GetAnnouncement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	now := time.Now()
+	var announcement models.Announcement
+	err = visibleTo(h.DB, user, now).Preload("Author", func(db *gorm.DB) *gorm.DB {
+		return db.Select("user_id", "full_name", "avatar_url")
+	}).Where("id = ?", id).First(&announcement).Error
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+
+	list := []models.Announcement{announcement}
+	h.markNew(user, list, now)
+	announcement = list[0]
+	if announcement.IsNew {
+		h.recordRead(announcement.ID, user.UserID)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    announcement,
+	})
+}
+
+// CreateAnnouncement publishes an announcement
+// POST /api/announcements
+func (h *AnnouncementsHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req AnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+	if msg := checkSchedule(&req, time.Now()); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	classes, msg := h.resolveTargets(user, req.TargetClasses)
+	if msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	announcement := models.Announcement{CreatedBy: user.UserID}
+	applyRequest(&announcement, &req, classes)
+	if err := h.DB.Omit("Author").Create(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat pengumuman"})
+	}
+
+	// Scheduled announcements are announced by the notification dispatcher once publish_at passes
+	message := "Pengumuman berhasil dibuat"
+	if announcement.PublishAt != nil && announcement.PublishAt.After(time.Now()) {
+		message = "Pengumuman dijadwalkan terbit " + announcement.PublishAt.In(wib).Format("02/01/2006 15:04") + " WIB"
+	} else if h.Notify != nil {
+		now := time.Now()
+		h.DB.Model(&announcement).Update("notified_at", now)
+		a := announcement
+		go func() {
+			if err := h.Notify.PublishAnnouncement(&a); err != nil {
+				fmt.Printf("⚠️ Announcement notification failed: %v\n", err)
+			}
+		}()
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    announcement,
+		"message": message,
+	})
+}
+
+// UpdateAnnouncement edits an announcement (author or admin dev)
+// PUT /api/announcements/:id
+func (h *AnnouncementsHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	var announcement models.Announcement
+	if err := h.DB.Where("id = ?", id).First(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+	if !canEdit(user, &announcement) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda hanya dapat mengubah pengumuman Anda sendiri"})
+	}
+
+	var req AnnouncementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	if msg := checkSchedule(&req, time.Now()); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	classes, msg := h.resolveTargets(user, req.TargetClasses)
+	if msg != "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	applyRequest(&announcement, &req, classes)
+	if err := h.DB.Omit("Author").Save(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui pengumuman"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    announcement,
+		"message": "Pengumuman berhasil diperbarui",
+	})
+}
+
+// PinAnnouncement pins or unpins an announcement (author or admin dev)
+// PATCH /api/announcements/:id/pin
+func (h *AnnouncementsHandler) PinAnnouncement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	var req struct {
+		IsPinned bool `json:"is_pinned"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	var announcement models.Announcement
+	if err := h.DB.Where("id = ?", id).First(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+	if !canEdit(user, &announcement) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda hanya dapat mengubah pengumuman Anda sendiri"})
+	}
+
+	if err := h.DB.Model(&announcement).Update("is_pinned", req.IsPinned).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui pengumuman"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    announcement,
+	})
+}
+
+// DeleteAnnouncement removes an announcement (author or admin dev)
+// DELETE /api/announcements/:id
+func (h *AnnouncementsHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	var announcement models.Announcement
+	if err := h.DB.Where("id = ?", id).First(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+	if !canEdit(user, &announcement) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda hanya dapat menghapus pengumuman Anda sendiri"})
+	}
+
+	if err := h.DB.Delete(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus pengumuman"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pengumuman berhasil dihapus",
+	})
+}
+
+// ========================================
+// READ RECEIPTS
+// ========================================
+
+// Helper: Record a read, ignoring repeats
+func (h *AnnouncementsHandler) recordRead(announcementID, userID uuid.UUID) error {
+	return h.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.AnnouncementRead{AnnouncementID: announcementID, UserID: userID}).Error
+}
+
+// AudienceMember is a user an announcement is addressed to, with their read time
+type AudienceMember struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	NIM       string     `json:"nim"`
+	FullName  string     `json:"full_name"`
+	Role      string     `json:"role"`
+	ClassID   *uuid.UUID `json:"class_id,omitempty"`
+	ClassName string     `json:"class_name"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// ClassReadStat is the read percentage of one class
+type ClassReadStat struct {
+	ClassID    *uuid.UUID `json:"class_id,omitempty"`
+	ClassName  string     `json:"class_name"`
+	Total      int        `json:"total"`
+	Read       int        `json:"read"`
+	Percentage float64    `json:"percentage"`
+}
+
+// Helper: Everyone an announcement is addressed to, with their read time
+func (h *AnnouncementsHandler) audienceOf(a *models.Announcement) []AudienceMember {
+	var members []AudienceMember
+	notify.AnnouncementAudience(h.DB, a).
+		Select("p.user_id, p.nim, p.full_name, p.role, p.class_id, coalesce(c.name, '') AS class_name, r.read_at").
+		Joins("LEFT JOIN classes c ON c.id = p.class_id").
+		Joins("LEFT JOIN announcement_reads r ON r.announcement_id = ? AND r.user_id = p.user_id", a.ID).
+		Order("class_name ASC, p.nim ASC").
+		Scan(&members)
+	return members
+}
+
+// GetReadReceipts shows how much of the audience has read an announcement, per class. The
+// list of readers who have not read it yet is included for important announcements (or ?unread=true).
+// GET /api/announcements/:id/reads
+func (h *AnnouncementsHandler) GetReadReceipts(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	var announcement models.Announcement
+	if err := h.DB.Where("id = ?", id).First(&announcement).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+	if !canEdit(user, &announcement) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya penulis yang dapat melihat statistik baca"})
+	}
+
+	members := h.audienceOf(&announcement)
+	stats := []ClassReadStat{}
+	byClass := make(map[string]int)
+	unread := []AudienceMember{}
+	read := 0
+	for _, m := range members {
+		key := m.ClassName
+		if _, ok := byClass[key]; !ok {
+			name := m.ClassName
+			if name == "" {
+				name = "Tanpa Kelas"
+			}
+			byClass[key] = len(stats)
+			stats = append(stats, ClassReadStat{ClassID: m.ClassID, ClassName: name})
+		}
+		stat := &stats[byClass[key]]
+		stat.Total++
+		if m.ReadAt != nil {
+			stat.Read++
+			read++
+		} else {
+			unread = append(unread, m)
+		}
+	}
+	for i := range stats {
+		stats[i].Percentage = percentage(stats[i].Read, stats[i].Total)
+	}
+
+	data := fiber.Map{
+		"total":      len(members),
+		"read":       read,
+		"percentage": percentage(read, len(members)),
+		"classes":    stats,
+	}
+	if announcement.Priority == models.AnnouncementImportant || c.Query("unread") == "true" {
+		data["unread"] = unread
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// Helper: Share as a percentage with two decimals
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// MarkRead marks one announcement as read
+// POST /api/announcements/:id/read
+func (h *AnnouncementsHandler) MarkRead(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid announcement ID"})
+	}
+
+	var count int64
+	visibleTo(h.DB, user, time.Now()).Where("id = ?", id).Count(&count)
+	if count == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengumuman tidak ditemukan"})
+	}
+	if err := h.recordRead(id, user.UserID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menandai pengumuman"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pengumuman ditandai sudah dibaca",
+	})
+}
+
+// MarkAllRead marks every unread announcement addressed to the caller as read
+// POST /api/announcements/read-all
+func (h *AnnouncementsHandler) MarkAllRead(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var ids []uuid.UUID
+	unreadQuery(h.DB, user, time.Now()).Pluck("id", &ids)
+	if len(ids) > 0 {
+		reads := make([]models.AnnouncementRead, len(ids))
+		for i, id := range ids {
+			reads[i] = models.AnnouncementRead{AnnouncementID: id, UserID: user.UserID}
+		}
+		if err := h.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&reads).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menandai pengumuman"})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"marked":  len(ids),
+		"message": "Semua pengumuman ditandai sudah dibaca",
+	})
+}
+
+// GetUnreadCount returns how many announcements the caller has not read
+// GET /api/announcements/unread-count
+func (h *AnnouncementsHandler) GetUnreadCount(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var unread int64
+	unreadQuery(h.DB, user, time.Now()).Count(&unread)
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    mockAnnouncements,
+		"data":    fiber.Map{"unread": unread},
 	})
 }
@@ -3,11 +3,16 @@ package handlers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"math"
 	"time"
 
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers/auth"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/realtime"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -15,14 +20,20 @@ import (
 )
 
 type AttendanceHandler struct {
-	DB       *gorm.DB
-	Validate *validator.Validate
+	DB        *gorm.DB
+	Validate  *validator.Validate
+	Live      *realtime.Hub
+	Biometric *auth.WebAuthnHandler
+	Notify    *notify.Service
 }
 
-func NewAttendanceHandler(db *gorm.DB, validate *validator.Validate) *AttendanceHandler {
+func NewAttendanceHandler(db *gorm.DB, validate *validator.Validate, live *realtime.Hub, biometric *auth.WebAuthnHandler, notifier *notify.Service) *AttendanceHandler {
 	return &AttendanceHandler{
-		DB:       db,
-		Validate: validate,
+		DB:        db,
+		Validate:  validate,
+		Live:      live,
+		Biometric: biometric,
+		Notify:    notifier,
 	}
 }
 
@@ -38,6 +49,15 @@ type CreateSessionRequest struct {
 	ClassID   uuid.UUID `json:"class_id" validate:"required"`
 	MeetingID uuid.UUID `json:"meeting_id" validate:"required"`
 	Duration  int       `json:"duration"` // Duration in minutes, default 5
+
+	RequireBiometric bool `json:"require_biometric"` // Scans must carry a WebAuthn assertion
+	AllowOffline     bool `json:"allow_offline"`     // QR carries a signed claim students can sync later
+
+	// Optional lateness overrides; unset fields fall back to the subject's timing rule
+	ScheduledStart      *time.Time `json:"scheduled_start"`
+	GraceMinutes        *int       `json:"grace_minutes" validate:"omitempty,min=0,max=240"`
+	LateCutoffMinutes   *int       `json:"late_cutoff_minutes" validate:"omitempty,min=0,max=480"`
+	AbsentCutoffMinutes *int       `json:"absent_cutoff_minutes" validate:"omitempty,min=0,max=600"`
 }
 
 // ScanQRRequest represents the request when student scans QR
@@ -45,6 +65,12 @@ type ScanQRRequest struct {
 	QRToken   string  `json:"qr_token" validate:"required"`
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
+
+	// Assertion from /attendance/scan/biometric/begin, required when the session demands it
+	Assertion json.RawMessage `json:"webauthn_assertion,omitempty"`
+
+	// Stable browser/device fingerprint generated by the frontend (X-Device-ID also accepted)
+	DeviceID string `json:"device_id"`
 }
 
 // CreateSession creates a new attendance session (Dosen only)
@@ -94,10 +120,36 @@ func (h *AttendanceHandler) CreateSession(c *fiber.Ctx) error {
 		})
 	}
 
-	// Deactivate any existing active sessions for this class/meeting
-	h.DB.Model(&models.AttendanceSession{}).
-		Where("class_id = ? AND meeting_id = ? AND is_active = true", req.ClassID, req.MeetingID).
-		Update("is_active", false)
+	// Lecturers may only open sessions for subjects/classes they are assigned to
+	if !isAssigned(h.DB, user, meeting.SubjectID, req.ClassID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "You are not assigned to teach this subject for this class",
+		})
+	}
+
+	// Reject contradictory overrides before anything is written. Overrides are merged onto
+	// the subject's rule, the same way the scan will resolve them.
+	draft := models.AttendanceSession{
+		CreatedAt:           time.Now(),
+		GraceMinutes:        req.GraceMinutes,
+		LateCutoffMinutes:   req.LateCutoffMinutes,
+		AbsentCutoffMinutes: req.AbsentCutoffMinutes,
+	}
+	effective := h.resolveTiming(&draft, meeting.SubjectID)
+	if err := validateTimingMinutes(effective.GraceMinutes, effective.LateCutoffMinutes, effective.AbsentCutoffMinutes); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if req.AllowOffline && req.RequireBiometric {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Offline mode cannot be combined with biometric verification",
+		})
+	}
 
 	// Generate unique QR token
 	qrToken := generateQRToken()
@@ -110,22 +162,43 @@ func (h *AttendanceHandler) CreateSession(c *fiber.Ctx) error {
 
 	// Create session
 	isActive := true
+	requireBiometric := req.RequireBiometric
+	allowOffline := req.AllowOffline
 	session := models.AttendanceSession{
-		ClassID:    req.ClassID,
-		LecturerID: user.UserID,
-		MeetingID:  req.MeetingID,
-		QRCode:     qrToken,
-		IsActive:   &isActive,
-		ExpiresAt:  time.Now().Add(time.Duration(duration) * time.Minute),
+		ClassID:          req.ClassID,
+		LecturerID:       user.UserID,
+		MeetingID:        req.MeetingID,
+		QRCode:           qrToken,
+		IsActive:         &isActive,
+		ExpiresAt:        time.Now().Add(time.Duration(duration) * time.Minute),
+		RequireBiometric: &requireBiometric,
+		AllowOffline:     &allowOffline,
+		OfflineKey:       generateQRToken() + generateQRToken(),
+
+		ScheduledStart:      req.ScheduledStart,
+		GraceMinutes:        req.GraceMinutes,
+		LateCutoffMinutes:   req.LateCutoffMinutes,
+		AbsentCutoffMinutes: req.AbsentCutoffMinutes,
 	}
 
-	if err := h.DB.Create(&session).Error; err != nil {
+	// Replace any existing active session for this class/meeting; both or neither happen
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AttendanceSession{}).
+			Where("class_id = ? AND meeting_id = ? AND is_active = true", req.ClassID, req.MeetingID).
+			Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Failed to create session",
 		})
 	}
 
+	timing := h.resolveTiming(&session, meeting.SubjectID)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
@@ -136,6 +209,13 @@ func (h *AttendanceHandler) CreateSession(c *fiber.Ctx) error {
 			"meeting":    meeting.MeetingNumber,
 			"expires_at": session.ExpiresAt,
 			"duration":   duration,
+
+			"require_biometric": requireBiometric,
+			"allow_offline":     allowOffline,
+			"timing":            timing,
+			"qr_png_url":        "/api/attendance/session/" + session.ID.String() + "/qr.png",
+			"qr_svg_url":        "/api/attendance/session/" + session.ID.String() + "/qr.svg",
+			"poster_url":        "/api/attendance/session/" + session.ID.String() + "/poster.pdf",
 		},
 		"message": "Attendance session created. QR code will expire in " + string(rune(duration)) + " minutes.",
 	})
@@ -180,7 +260,7 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 	// Find active session
 	var session models.AttendanceSession
 	err := h.DB.Preload("Class").Preload("Meeting.Subject").
-		Where("qr_code = ? AND is_active = true", req.QRToken).
+		Where("qr_code = ? AND is_active = true AND archived_at IS NULL", req.QRToken).
 		First(&session).Error
 
 	if err != nil {
@@ -190,26 +270,30 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 		})
 	}
 
+	// Load student profile first so rejections can be attributed on the live feed
+	var studentProfile models.Profile
+	if err := h.DB.Where("user_id = ?", user.UserID).First(&studentProfile).Error; err != nil {
+		h.publishScanRejected(&session, nil, "profile_not_found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Student profile not found",
+		})
+	}
+
 	// Check if session is expired
 	if time.Now().After(session.ExpiresAt) {
 		// Deactivate expired session
 		h.DB.Model(&session).Update("is_active", false)
+		h.publishScanRejected(&session, &studentProfile, "expired")
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   "QR code has expired. Please ask the lecturer for a new code.",
 		})
 	}
 
-	// Validate student belongs to the correct class
-	var studentProfile models.Profile
-	if err := h.DB.Where("user_id = ?", user.UserID).First(&studentProfile).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "Student profile not found",
-		})
-	}
-
-	if studentProfile.ClassID == nil || *studentProfile.ClassID != session.ClassID {
+	// Validate student is on the roster of the session (approved KRS, or the class before KRS)
+	if !h.inSessionRoster(&session, &studentProfile) {
+		h.publishScanRejected(&session, &studentProfile, "wrong_class")
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"error":   "This attendance session is for a different class",
@@ -220,6 +304,8 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 	if req.Latitude != 0 && req.Longitude != 0 {
 		distance := haversineDistance(CampusLatitude, CampusLongitude, req.Latitude, req.Longitude)
 		if distance > MaxDistanceKm {
+			h.flagRejectedTravel(user.UserID, session.ID, req.Latitude, req.Longitude, time.Now())
+			h.publishScanRejected(&session, &studentProfile, "out_of_range")
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success":  false,
 				"error":    "You are too far from campus. Please scan from within campus premises.",
@@ -229,32 +315,105 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 		}
 	}
 
-	// Check for duplicate attendance
-	var existingRecord models.AttendanceRecord
-	if err := h.DB.Where("session_id = ? AND student_id = ?", session.ID, user.UserID).First(&existingRecord).Error; err == nil {
+	// Check for duplicate attendance (an earlier QR of the same meeting counts too)
+	if existingRecord, ok := h.meetingRecord(&session, user.UserID); ok {
+		h.publishScanRejected(&session, &studentProfile, "duplicate")
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"success":    false,
-			"error":      "You have already marked attendance for this session",
+			"error":      "You have already marked attendance for this meeting",
 			"scanned_at": existingRecord.ScannedAt,
 		})
 	}
 
+	// Classify against the session's timing rule; past the absent cutoff the scan is refused
+	var subjectID uuid.UUID
+	if session.Meeting != nil {
+		subjectID = session.Meeting.SubjectID
+	}
+	timing := h.resolveTiming(&session, subjectID)
+	scannedAt := time.Now()
+	status, minutesLate, closed := timing.Classify(scannedAt)
+	if closed {
+		h.publishScanRejected(&session, &studentProfile, "closed")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success":      false,
+			"error":        "Attendance for this session is closed. You are recorded as absent.",
+			"minutes_late": minutesLate,
+		})
+	}
+
+	// Biometric-bound session: the assertion must be signed over this QR's challenge
+	biometricVerified := false
+	if session.RequireBiometric != nil && *session.RequireBiometric {
+		if h.Biometric == nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"success": false,
+				"error":   "Biometric verification is not available on this server",
+			})
+		}
+		if len(req.Assertion) == 0 {
+			h.publishScanRejected(&session, &studentProfile, "biometric_required")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success":            false,
+				"error":              "This session requires biometric verification",
+				"biometric_required": true,
+			})
+		}
+		if err := h.Biometric.VerifyScanAssertion(c.Get("Origin"), studentProfile, session, req.Assertion); err != nil {
+			h.publishScanRejected(&session, &studentProfile, "biometric_failed")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "Biometric verification failed: " + err.Error(),
+			})
+		}
+		biometricVerified = true
+	}
+
 	// Create attendance record
 	record := models.AttendanceRecord{
 		SessionID: session.ID,
 		StudentID: user.UserID,
-		Status:    "present",
+		Status:    status,
 		Method:    "qr",
-		ScannedAt: time.Now(),
+		ScannedAt: scannedAt,
+
+		BiometricVerified: biometricVerified,
+		MinutesLate:       minutesLate,
+	}
+
+	// Scan context for the fraud detectors
+	deviceID := req.DeviceID
+	if deviceID == "" {
+		deviceID = c.Get("X-Device-ID")
+	}
+	if deviceID != "" {
+		record.DeviceID = &deviceID
+	}
+	if ip := c.IP(); ip != "" {
+		record.IPAddress = &ip
+	}
+	if ua := c.Get("User-Agent"); ua != "" {
+		record.UserAgent = &ua
+	}
+	if req.Latitude != 0 && req.Longitude != 0 {
+		lat, lng := req.Latitude, req.Longitude
+		record.Latitude = &lat
+		record.Longitude = &lng
 	}
 
 	if err := h.DB.Create(&record).Error; err != nil {
+		h.publishScanRejected(&session, &studentProfile, "save_failed")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Failed to record attendance",
 		})
 	}
 
+	h.dropGeneratedRecords(&session, user.UserID)
+
+	flags := h.detectSuspiciousScan(&session, &record)
+	h.publishScanAccepted(&session, &studentProfile, &record, flags)
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
@@ -264,8 +423,11 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 			"class":      session.Class.Name,
 			"subject":    session.Meeting.Subject.Name,
 			"meeting":    session.Meeting.MeetingNumber,
+
+			"biometric_verified": record.BiometricVerified,
+			"minutes_late":       record.MinutesLate,
 		},
-		"message": "Attendance recorded successfully!",
+		"message": scanMessage(record.Status, record.MinutesLate),
 	})
 }
 
@@ -277,11 +439,11 @@ func (h *AttendanceHandler) GetActiveSessions(c *fiber.Ctx) error {
 	query := h.DB.Model(&models.AttendanceSession{}).
 		Preload("Class").
 		Preload("Meeting.Subject").
-		Where("is_active = true AND expires_at > ?", time.Now())
+		Where("is_active = true AND archived_at IS NULL AND expires_at > ?", time.Now())
 
-	// Filter by lecturer for non-admin
+	// Lecturers see sessions of their teaching assignments (including co-teachers')
 	if user.Role != models.RoleAdminDev {
-		query = query.Where("lecturer_id = ?", user.UserID)
+		query = query.Scopes(assignedSessionScope(user))
 	}
 
 	var sessions []models.AttendanceSession
@@ -392,11 +554,19 @@ func (h *AttendanceHandler) RefreshSession(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check ownership
-	if user.Role != models.RoleAdminDev && session.LecturerID != user.UserID {
+	// Check teaching assignment
+	if !h.canManageSession(user, &session) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
-			"error":   "You can only refresh your own sessions",
+			"error":   "You can only refresh sessions of classes you teach",
+		})
+	}
+
+	// Sessions of a rolled-over term stay closed
+	if session.ArchivedAt != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Sesi ini sudah diarsipkan bersama semester sebelumnya",
 		})
 	}
 
@@ -407,6 +577,13 @@ func (h *AttendanceHandler) RefreshSession(c *fiber.Ctx) error {
 	session.ExpiresAt = time.Now().Add(5 * time.Minute)
 	session.IsActive = &isActive
 
+	// Reopening a finalized session: drop the auto-generated alpa/izin rows so the
+	// closer recomputes them when this code expires
+	if session.FinalizedAt != nil {
+		h.DB.Where("session_id = ? AND method = ?", session.ID, "auto").Delete(&models.AttendanceRecord{})
+		session.FinalizedAt = nil
+	}
+
 	h.DB.Save(&session)
 
 	return c.JSON(fiber.Map{
@@ -434,11 +611,11 @@ func (h *AttendanceHandler) DeactivateSession(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check ownership
-	if user.Role != models.RoleAdminDev && session.LecturerID != user.UserID {
+	// Check teaching assignment
+	if !h.canManageSession(user, &session) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
-			"error":   "You can only deactivate your own sessions",
+			"error":   "You can only deactivate sessions of classes you teach",
 		})
 	}
 
@@ -446,12 +623,42 @@ func (h *AttendanceHandler) DeactivateSession(c *fiber.Ctx) error {
 	session.IsActive = &isActive
 	h.DB.Save(&session)
 
+	h.publishSessionClosed(&session)
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Session deactivated successfully",
 	})
 }
 
+// Helper: Whether the student is on the roster of the session's subject and class
+func (h *AttendanceHandler) inSessionRoster(session *models.AttendanceSession, student *models.Profile) bool {
+	if session.Meeting == nil {
+		return student.ClassID != nil && *student.ClassID == session.ClassID
+	}
+	return enrollment.InRoster(h.DB, student, enrollment.SessionTermID(h.DB, session), session.Meeting.SubjectID, session.ClassID)
+}
+
+// Helper: The student's scanned or manual record in any session of the session's meeting and
+// class. Rows the closer generated (method auto) don't count, so a reopened meeting can
+// still be scanned.
+func (h *AttendanceHandler) meetingRecord(session *models.AttendanceSession, studentID uuid.UUID) (models.AttendanceRecord, bool) {
+	var record models.AttendanceRecord
+	err := h.DB.Joins("JOIN attendance_sessions ON attendance_sessions.id = attendance_records.session_id").
+		Where("attendance_sessions.meeting_id = ? AND attendance_sessions.class_id = ?", session.MeetingID, session.ClassID).
+		Where("attendance_records.student_id = ? AND attendance_records.method <> ?", studentID, "auto").
+		First(&record).Error
+	return record, err == nil
+}
+
+// Helper: Remove the closer's alpa/izin rows for a student who has now scanned the meeting
+func (h *AttendanceHandler) dropGeneratedRecords(session *models.AttendanceSession, studentID uuid.UUID) {
+	h.DB.Where("student_id = ? AND method = ? AND session_id <> ?", studentID, "auto", session.ID).
+		Where("session_id IN (?)", h.DB.Model(&models.AttendanceSession{}).Select("id").
+			Where("meeting_id = ? AND class_id = ?", session.MeetingID, session.ClassID)).
+		Delete(&models.AttendanceRecord{})
+}
+
 // Helper: Generate secure QR token
 func generateQRToken() string {
 	bytes := make([]byte, 16)
@@ -170,28 +170,3 @@ func (h *AttendanceHandler) ScanQR(c *fiber.Ctx) error {
 		"subject": session.Meeting.Subject.Name,
 	})
 }
-
-// GetStudentHistory: Mengambil riwayat lengkap untuk ditampilkan dalam folder
-// GET /api/attendance/history
-func (h *AttendanceHandler) GetStudentHistory(c *fiber.Ctx) error {
-	user := c.Locals("user").(middleware.UserContext)
-
-	var records []models.AttendanceRecord
-
-	// Preload Berjenjang: Record -> Session -> Meeting -> Subject (Dapetin Semester & Nama Matkul)
-	// Preload Session -> Class (Dapetin Nama Kelas)
-	err := h.DB.Preload("Session.Meeting.Subject").
-		Preload("Session.Class").
-		Where("student_id = ?", user.UserID).
-		Order("scanned_at DESC").
-		Find(&records).Error
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal memuat riwayat"})
-	}
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    records,
-	})
-}
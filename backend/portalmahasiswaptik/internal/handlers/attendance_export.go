@@ -3,8 +3,10 @@ package handlers
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
 	"github.com/gofiber/fiber/v2"
@@ -36,9 +38,16 @@ func (h *AttendanceHandler) ExportAttendanceExcel(c *fiber.Ctx) error {
 
 	user := c.Locals("user").(middleware.UserContext)
 
+	// Lecturers only export sessions of their teaching assignments
+	if user.Role == models.RoleAdminDosen && !h.canManageSession(user, &session) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Keamanan Dokumen: Anda tidak ditugaskan mengajar kelas ini.",
+		})
+	}
+
 	// --- TRANSPARENCY VS SECURITY LOGIC ---
 	if action == "download" && user.Role != models.RoleAdminDev {
-		if user.ClassID == nil || *user.ClassID != session.ClassID {
+		if user.Role != models.RoleAdminDosen && (user.ClassID == nil || *user.ClassID != session.ClassID) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": "Keamanan Dokumen: Anda hanya diperbolehkan mengunduh data kelas Anda sendiri.",
 			})
@@ -124,9 +133,16 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 
 	user := c.Locals("user").(middleware.UserContext)
 
+	// Lecturers only export subjects/classes of their teaching assignments
+	if user.Role == models.RoleAdminDosen && !isAssigned(h.DB, user, subjectID, classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Keamanan Dokumen: Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut.",
+		})
+	}
+
 	// --- TRANSPARENCY VS SECURITY LOGIC ---
 	if action == "download" && user.Role != models.RoleAdminDev {
-		if user.ClassID == nil || *user.ClassID != classID {
+		if user.Role != models.RoleAdminDosen && (user.ClassID == nil || *user.ClassID != classID) {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": "Keamanan Dokumen: Anda hanya diperbolehkan mengunduh file Master kelas Anda sendiri.",
 			})
@@ -180,7 +196,8 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 	h.DB.Where("subject_id = ?", subjectID).Order("meeting_number ASC").Find(&meetings)
 
 	var students []models.Profile
-	h.DB.Where("class_id = ?", classID).Order("nim ASC").Find(&students)
+	termID, _ := enrollment.ActiveTermID(h.DB)
+	enrollment.Roster(h.DB, termID, subjectID, classID).Order("nim ASC").Find(&students)
 
 	// 2. Create Excel
 	f := excelize.NewFile()
@@ -193,7 +210,9 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 		f.SetSheetName("Sheet1", "Summary")
 	}
 
-	// 3. Loop Meetings and Generate Sheets
+	// One record per student and meeting, across every QR the meeting had
+	recordsByMeeting := meetingRecords(h.DB, subjectID, classID)
+
 	// 3. Loop Meetings and Generate Sheets
 	for i, meeting := range meetings {
 		sheetName := fmt.Sprintf("Pertemuan %d", meeting.MeetingNumber)
@@ -204,10 +223,13 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 		// --- UPDATE DI SINI: TRICK ANTI LOG MERAH ---
 		var sessions []models.AttendanceSession
 		// Kita pake Find + Limit(1) alih-alih First agar GORM tidak teriak "Record Not Found" di terminal
-		h.DB.Where("meeting_id = ?", meeting.ID).Order("created_at DESC").Limit(1).Find(&sessions)
+		h.DB.Where("meeting_id = ? AND class_id = ?", meeting.ID, classID).Order("created_at DESC").Limit(1).Find(&sessions)
 
 		// Prepare Data
-		recordMap := make(map[uuid.UUID]models.AttendanceRecord)
+		recordMap := recordsByMeeting[meeting.ID]
+		if recordMap == nil {
+			recordMap = make(map[uuid.UUID]models.AttendanceRecord)
+		}
 		var session models.AttendanceSession
 
 		// Cek apakah sessions ketemu (panjang slice > 0)
@@ -218,13 +240,6 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 			session.Meeting = &meetings[i]
 			session.Meeting.Subject = &subject
 			session.Class = &class
-
-			var records []models.AttendanceRecord
-			h.DB.Where("session_id = ?", session.ID).Find(&records)
-
-			for _, r := range records {
-				recordMap[r.StudentID] = r
-			}
 		} else {
 			// LOGIC JIKA TIDAK ADA SESI (Sama kayak kode lama lo tapi lebih bersih)
 			fmt.Printf("ℹ️ Pertemuan %d belum ada sesi absen. Membuat sheet kosong.\n", meeting.MeetingNumber)
@@ -239,6 +254,16 @@ func (h *AttendanceHandler) ExportMasterAttendanceExcel(c *fiber.Ctx) error {
 
 		generateAttendanceSheet(f, sheetName, &session, students, recordMap)
 	}
+
+	// Recap sheet: hadir and terlambat are reported separately
+	summaries, meetingCount := h.buildLatenessSummary(subjectID, classID)
+	if len(meetings) > 0 {
+		f.NewSheet("Rekap")
+		generateRecapSheet(f, "Rekap", &subject, &class, meetingCount, summaries)
+	} else {
+		generateRecapSheet(f, "Summary", &subject, &class, meetingCount, summaries)
+	}
+
 	// Finalize
 	filename := fmt.Sprintf("Master_Absensi_%s_%s.xlsx", subject.Name, class.Name)
 	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
@@ -273,6 +298,13 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
 	})
 
+	lateStyle, _ := f.NewStyle(&excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"FFEB9C"}, Pattern: 1},
+		Font:      &excelize.Font{Color: "9C5700"},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
+	})
+
 	normalStyle, _ := f.NewStyle(&excelize.Style{
 		Alignment: &excelize.Alignment{Horizontal: "center"},
 		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
@@ -280,8 +312,8 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 
 	// --- HEADERS ---
 	f.SetCellValue(sheet, "A1", "LAPORAN PRESENSI MAHASISWA")
-	f.MergeCell(sheet, "A1", "F1")
-	f.SetCellStyle(sheet, "A1", "F1", headerStyle)
+	f.MergeCell(sheet, "A1", "G1")
+	f.SetCellStyle(sheet, "A1", "G1", headerStyle)
 
 	// Safe checks for nil pointers in case of partial session data
 	subjectName := "-"
@@ -316,7 +348,8 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 	f.SetCellValue(sheet, "D7", "Status")
 	f.SetCellValue(sheet, "E7", "Metode")
 	f.SetCellValue(sheet, "F7", "Waktu Scan")
-	f.SetCellStyle(sheet, "A7", "F7", headerStyle)
+	f.SetCellValue(sheet, "G7", "Terlambat (menit)")
+	f.SetCellStyle(sheet, "A7", "G7", headerStyle)
 
 	// --- DATA ---
 	for i, s := range students {
@@ -330,6 +363,7 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 		style := normalStyle
 		method := "-"
 		scanTime := "-"
+		minutesLate := "-"
 
 		if ok {
 			status = record.Status
@@ -337,22 +371,35 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 			if method == "" {
 				method = "manual"
 			}
+			if record.BiometricVerified {
+				method += " + biometrik"
+			}
 			// Force treat as UTC then shift to WIB (UTC+7)
 			loc := time.FixedZone("WIB", 7*3600)
 			scanTime = record.ScannedAt.UTC().In(loc).Format("03:04 PM")
 
-			switch status {
-			case "present", "hadir":
+			switch models.NormalizeAttendanceStatus(status) {
+			case models.AttendanceHadir:
 				style = presentStyle
 				status = "HADIR"
-			case "absent", "alpha":
+			case models.AttendanceTerlambat:
+				style = lateStyle
+				status = "TERLAMBAT"
+				minutesLate = strconv.Itoa(record.MinutesLate)
+			case models.AttendanceAlpa:
 				style = absentStyle
 				status = "ALPHA"
-			case "permit", "izin":
+				if record.MinutesLate > 0 {
+					minutesLate = strconv.Itoa(record.MinutesLate)
+				}
+			case models.AttendanceIzin:
 				style = normalStyle // Or yellow if defined
 				status = "IZIN"
-			case "pending":
-				status = "PENDING"
+			case models.AttendanceSakit:
+				style = normalStyle
+				status = "SAKIT"
+			default: // pending and anything unrecognised
+				status = strings.ToUpper(status)
 				style = normalStyle
 			}
 		}
@@ -360,13 +407,67 @@ func generateAttendanceSheet(f *excelize.File, sheet string, session *models.Att
 		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), status)
 		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), method)
 		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), scanTime)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), minutesLate)
 
 		f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("C%d", row), normalStyle)
 		f.SetCellStyle(sheet, fmt.Sprintf("D%d", row), fmt.Sprintf("D%d", row), style)
-		f.SetCellStyle(sheet, fmt.Sprintf("E%d", row), fmt.Sprintf("F%d", row), normalStyle)
+		f.SetCellStyle(sheet, fmt.Sprintf("E%d", row), fmt.Sprintf("G%d", row), normalStyle)
 	}
 
 	f.SetColWidth(sheet, "C", "C", 35)
 	f.SetColWidth(sheet, "B", "B", 15)
 	f.SetColWidth(sheet, "F", "F", 15)
+	f.SetColWidth(sheet, "G", "G", 18)
+}
+
+// Helper to generate the per-student recap sheet (lateness counted separately from hadir)
+func generateRecapSheet(f *excelize.File, sheet string, subject *models.Subject, class *models.Class, meetingCount int, summaries []LatenessSummary) {
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"1E293B"}, Pattern: 1},
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF", Size: 12},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
+	})
+	metaLabelStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	normalStyle, _ := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
+	})
+
+	f.SetCellValue(sheet, "A1", "REKAP PRESENSI MAHASISWA")
+	f.MergeCell(sheet, "A1", "K1")
+	f.SetCellStyle(sheet, "A1", "K1", headerStyle)
+
+	f.SetCellValue(sheet, "A3", "Mata Kuliah:")
+	f.SetCellValue(sheet, "B3", subject.Name)
+	f.SetCellValue(sheet, "A4", "Kelas:")
+	f.SetCellValue(sheet, "B4", class.Name)
+	f.SetCellValue(sheet, "A5", "Jumlah Pertemuan:")
+	f.SetCellValue(sheet, "B5", meetingCount)
+	f.SetCellStyle(sheet, "A3", "A5", metaLabelStyle)
+
+	headers := []string{"No", "NIM", "Nama Mahasiswa", "Hadir", "Terlambat", "Total Menit Terlambat", "Izin", "Sakit", "Alpha", "Kehadiran (%)", "Tepat Waktu (%)"}
+	for i, title := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 7)
+		f.SetCellValue(sheet, cell, title)
+	}
+	f.SetCellStyle(sheet, "A7", "K7", headerStyle)
+
+	for i, s := range summaries {
+		row := i + 8
+		values := []interface{}{
+			i + 1, s.NIM, s.FullName, s.Hadir, s.Terlambat, s.TotalMinutesLate, s.Izin, s.Sakit, s.Alpa,
+			fmt.Sprintf("%.1f", s.AttendanceRate*100), fmt.Sprintf("%.1f", s.OnTimeRate*100),
+		}
+		for col, v := range values {
+			cell, _ := excelize.CoordinatesToCellName(col+1, row)
+			f.SetCellValue(sheet, cell, v)
+		}
+		f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("K%d", row), normalStyle)
+	}
+
+	f.SetColWidth(sheet, "A", "A", 18)
+	f.SetColWidth(sheet, "B", "B", 15)
+	f.SetColWidth(sheet, "C", "C", 35)
+	f.SetColWidth(sheet, "D", "K", 14)
 }
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// Detector thresholds
+const (
+	IPBurstWindow       = 60 * time.Second
+	IPBurstMinScans     = 6 // scans from one IP inside the window before flagging
+	TravelLookback      = 6 * time.Hour
+	TravelMaxSpeedKmh   = 120.0 // faster than this between two scans is not plausible
+	TravelMinDistanceKm = 0.05  // GPS jitter below this is ignored; must stay under MaxDistanceKm
+	CoordsPrecision     = 6     // decimals compared for identical coordinates
+)
+
+// Helper: Run every detector against a freshly saved record and persist the flags.
+// Pair detectors flag the counterpart records too so both show up in the review list.
+func (h *AttendanceHandler) detectSuspiciousScan(session *models.AttendanceSession, record *models.AttendanceRecord) []string {
+	var flags []models.ScanFlag
+	add := func(r models.AttendanceRecord, detector, detail string) {
+		flags = append(flags, models.ScanFlag{
+			SessionID: session.ID,
+			RecordID:  r.ID,
+			StudentID: r.StudentID,
+			Detector:  detector,
+			Detail:    detail,
+		})
+	}
+
+	// 1. One device scanning for several students
+	if record.DeviceID != nil && *record.DeviceID != "" {
+		var others []models.AttendanceRecord
+		h.DB.Where("session_id = ? AND device_id = ? AND student_id <> ?", session.ID, *record.DeviceID, record.StudentID).
+			Find(&others)
+		if len(others) > 0 {
+			add(*record, models.FlagSharedDevice, fmt.Sprintf("Device yang sama dipakai %d mahasiswa lain di sesi ini", len(others)))
+			for _, o := range others {
+				add(o, models.FlagSharedDevice, "Device yang sama dipakai mahasiswa lain di sesi ini")
+			}
+		}
+	}
+
+	// 2. Burst of scans from one IP
+	if record.IPAddress != nil && *record.IPAddress != "" {
+		var burst int64
+		h.DB.Model(&models.AttendanceRecord{}).
+			Where("session_id = ? AND ip_address = ? AND scanned_at > ?", session.ID, *record.IPAddress, record.ScannedAt.Add(-IPBurstWindow)).
+			Count(&burst)
+		if burst >= IPBurstMinScans {
+			add(*record, models.FlagIPBurst, fmt.Sprintf("%d scan dari IP %s dalam %d detik", burst, *record.IPAddress, int(IPBurstWindow.Seconds())))
+		}
+	}
+
+	if record.Latitude != nil && record.Longitude != nil {
+		// 3. Impossible travel since the student's previous located scan
+		if _, detail, ok := h.impossibleTravel(record.StudentID, session.ID, *record.Latitude, *record.Longitude, record.ScannedAt); ok {
+			add(*record, models.FlagImpossibleTravel, detail)
+		}
+
+		// 4. Coordinates identical to several decimals across students (shared screenshot / spoofing)
+		var same []models.AttendanceRecord
+		h.DB.Where("session_id = ? AND student_id <> ? AND ROUND(latitude::numeric, ?) = ROUND(?::numeric, ?) AND ROUND(longitude::numeric, ?) = ROUND(?::numeric, ?)",
+			session.ID, record.StudentID,
+			CoordsPrecision, *record.Latitude, CoordsPrecision,
+			CoordsPrecision, *record.Longitude, CoordsPrecision).
+			Find(&same)
+		if len(same) > 0 {
+			add(*record, models.FlagIdenticalCoords, fmt.Sprintf("Koordinat identik dengan %d mahasiswa lain", len(same)))
+			for _, o := range same {
+				add(o, models.FlagIdenticalCoords, "Koordinat identik dengan mahasiswa lain")
+			}
+		}
+	}
+
+	if len(flags) == 0 {
+		return nil
+	}
+
+	// Counterparts may already carry the same flag from an earlier scan
+	if err := h.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&flags).Error; err != nil {
+		fmt.Printf("⚠️ Failed to save scan flags: %v\n", err)
+	}
+
+	var detectors []string
+	for _, f := range flags {
+		if f.RecordID == record.ID {
+			detectors = append(detectors, f.Detector)
+		}
+	}
+	return detectors
+}
+
+// Helper: The student's previous located scan (outside the given session) when reaching
+// lat/lng by the given time would have been faster than TravelMaxSpeedKmh
+func (h *AttendanceHandler) impossibleTravel(studentID, sessionID uuid.UUID, lat, lng float64, at time.Time) (models.AttendanceRecord, string, bool) {
+	var previous models.AttendanceRecord
+	h.DB.Where("student_id = ? AND session_id <> ? AND latitude IS NOT NULL AND longitude IS NOT NULL AND scanned_at > ?",
+		studentID, sessionID, at.Add(-TravelLookback)).
+		Order("scanned_at DESC").Limit(1).Find(&previous)
+	if previous.ID == uuid.Nil {
+		return previous, "", false
+	}
+	distance := haversineDistance(*previous.Latitude, *previous.Longitude, lat, lng)
+	hours := at.Sub(previous.ScannedAt).Hours()
+	if distance <= TravelMinDistanceKm || (hours > 0 && distance/hours <= TravelMaxSpeedKmh) {
+		return previous, "", false
+	}
+	return previous, fmt.Sprintf("%.2f km dari scan sebelumnya dalam %.0f menit", distance, hours*60), true
+}
+
+// Helper: Called with the raw coordinates of a scan the geofence is about to reject. Accepted
+// scans all lie within MaxDistanceKm of campus, so a far-away attempt shortly after one of them
+// is the only place a large jump shows up; the earlier accepted record gets the flag.
+func (h *AttendanceHandler) flagRejectedTravel(studentID, sessionID uuid.UUID, lat, lng float64, at time.Time) {
+	previous, detail, ok := h.impossibleTravel(studentID, sessionID, lat, lng, at)
+	if !ok {
+		return
+	}
+	flag := models.ScanFlag{
+		SessionID: previous.SessionID,
+		RecordID:  previous.ID,
+		StudentID: studentID,
+		Detector:  models.FlagImpossibleTravel,
+		Detail:    "Scan berikutnya ditolak (di luar kampus): " + detail,
+	}
+	if err := h.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&flag).Error; err != nil {
+		fmt.Printf("⚠️ Failed to save scan flag: %v\n", err)
+	}
+}
+
+// Helper: Load a session and enforce that the caller teaches it
+func (h *AttendanceHandler) ownedSession(c *fiber.Ctx, sessionID uuid.UUID) (*models.AttendanceSession, error) {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var session models.AttendanceSession
+	if err := h.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+	if !h.canManageSession(user, &session) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "You can only review sessions of classes you teach",
+		})
+	}
+	return &session, nil
+}
+
+// SuspiciousRecord groups the open flags of one attendance record
+type SuspiciousRecord struct {
+	Record   models.AttendanceRecord `json:"record"`
+	NIM      string                  `json:"nim"`
+	FullName string                  `json:"full_name"`
+	Flags    []models.ScanFlag       `json:"flags"`
+}
+
+// GetSuspiciousScans lists flagged records of a session for lecturer review
+// GET /api/attendance/session/:id/suspicious
+func (h *AttendanceHandler) GetSuspiciousScans(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid session ID",
+		})
+	}
+	session, errResp := h.ownedSession(c, sessionID)
+	if session == nil {
+		return errResp
+	}
+
+	query := h.DB.Where("session_id = ?", session.ID)
+	if c.Query("include_dismissed") != "true" {
+		query = query.Where("dismissed_at IS NULL")
+	}
+	var flags []models.ScanFlag
+	query.Order("created_at ASC").Find(&flags)
+
+	byRecord := make(map[uuid.UUID][]models.ScanFlag)
+	var recordIDs []uuid.UUID
+	for _, f := range flags {
+		if _, ok := byRecord[f.RecordID]; !ok {
+			recordIDs = append(recordIDs, f.RecordID)
+		}
+		byRecord[f.RecordID] = append(byRecord[f.RecordID], f)
+	}
+
+	result := make([]SuspiciousRecord, 0, len(recordIDs))
+	if len(recordIDs) > 0 {
+		var records []models.AttendanceRecord
+		h.DB.Where("id IN ?", recordIDs).Order("scanned_at ASC").Find(&records)
+
+		studentIDs := make([]uuid.UUID, len(records))
+		for i, r := range records {
+			studentIDs[i] = r.StudentID
+		}
+		var profiles []models.Profile
+		h.DB.Where("user_id IN ?", studentIDs).Find(&profiles)
+		profileMap := make(map[uuid.UUID]models.Profile, len(profiles))
+		for _, p := range profiles {
+			profileMap[p.UserID] = p
+		}
+
+		for _, r := range records {
+			p := profileMap[r.StudentID]
+			result = append(result, SuspiciousRecord{
+				Record:   r,
+				NIM:      p.NIM,
+				FullName: p.FullName,
+				Flags:    byRecord[r.ID],
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// InvalidateRecordRequest carries the lecturer's reason
+type InvalidateRecordRequest struct {
+	Reason string `json:"reason" validate:"required,min=3"`
+}
+
+// InvalidateRecord voids a scan after review: the student is counted as alpa
+// POST /api/attendance/records/:id/invalidate
+func (h *AttendanceHandler) InvalidateRecord(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	recordID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid record ID",
+		})
+	}
+
+	var req InvalidateRecordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+
+	var record models.AttendanceRecord
+	if err := h.DB.Where("id = ?", recordID).First(&record).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Record not found",
+		})
+	}
+
+	session, errResp := h.ownedSession(c, record.SessionID)
+	if session == nil {
+		return errResp
+	}
+
+	if record.InvalidatedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Record has already been invalidated",
+		})
+	}
+
+	now := time.Now()
+	reason := strings.TrimSpace(req.Reason)
+	reviewer := user.UserID
+	if err := h.DB.Model(&record).Updates(models.AttendanceRecord{
+		Status:        models.AttendanceAlpa,
+		InvalidatedAt: &now,
+		InvalidatedBy: &reviewer,
+		InvalidReason: &reason,
+	}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to invalidate record",
+		})
+	}
+
+	// The flags are resolved by the decision
+	h.DB.Model(&models.ScanFlag{}).
+		Where("record_id = ? AND dismissed_at IS NULL", record.ID).
+		Updates(map[string]interface{}{"dismissed_at": now, "dismissed_by": reviewer})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    record,
+		"message": "Attendance record invalidated",
+	})
+}
+
+// DismissFlag marks a flag as reviewed without touching the record
+// POST /api/attendance/flags/:id/dismiss
+func (h *AttendanceHandler) DismissFlag(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	flagID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid flag ID",
+		})
+	}
+
+	var flag models.ScanFlag
+	if err := h.DB.Where("id = ?", flagID).First(&flag).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Flag not found",
+		})
+	}
+
+	session, errResp := h.ownedSession(c, flag.SessionID)
+	if session == nil {
+		return errResp
+	}
+
+	now := time.Now()
+	reviewer := user.UserID
+	h.DB.Model(&flag).Updates(models.ScanFlag{DismissedAt: &now, DismissedBy: &reviewer})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Flag dismissed",
+	})
+}
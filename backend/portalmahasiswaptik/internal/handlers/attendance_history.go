@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// MeetingHistory is one meeting of a subject from the student's point of view.
+// Meetings without a record are still listed: Status is "alpa" once the class's
+// session has closed, and empty while no session has been held yet.
+type MeetingHistory struct {
+	MeetingID     uuid.UUID  `json:"meeting_id"`
+	MeetingNumber int        `json:"meeting_number"`
+	Topic         *string    `json:"topic,omitempty"`
+	Date          *time.Time `json:"date,omitempty"`
+	SessionHeld   bool       `json:"session_held"`
+	SessionActive bool       `json:"session_active"`
+	Status        string     `json:"status"`
+	Recorded      bool       `json:"recorded"`
+	Method        string     `json:"method,omitempty"`
+	ScannedAt     *time.Time `json:"scanned_at,omitempty"`
+	MinutesLate   int        `json:"minutes_late"`
+	RecordID      *uuid.UUID `json:"record_id,omitempty"`
+}
+
+// StatusCounts tallies meetings per attendance status
+type StatusCounts struct {
+	Hadir     int `json:"hadir"`
+	Terlambat int `json:"terlambat"`
+	Izin      int `json:"izin"`
+	Sakit     int `json:"sakit"`
+	Alpa      int `json:"alpa"`
+}
+
+// Helper: Count one status (legacy values included)
+func (s *StatusCounts) add(status string) {
+	switch models.NormalizeAttendanceStatus(status) {
+	case models.AttendanceHadir:
+		s.Hadir++
+	case models.AttendanceTerlambat:
+		s.Terlambat++
+	case models.AttendanceIzin:
+		s.Izin++
+	case models.AttendanceSakit:
+		s.Sakit++
+	case models.AttendanceAlpa:
+		s.Alpa++
+	}
+}
+
+// SubjectHistory groups a subject's meetings with its rates
+type SubjectHistory struct {
+	SubjectID      uuid.UUID        `json:"subject_id"`
+	Code           string           `json:"code"`
+	Name           string           `json:"name"`
+	SKS            int              `json:"sks"`
+	TotalMeetings  int              `json:"total_meetings"`
+	HeldMeetings   int              `json:"held_meetings"`
+	Counts         StatusCounts     `json:"counts"`
+	AttendanceRate float64          `json:"attendance_rate"` // (hadir + terlambat) / held meetings
+	OnTimeRate     float64          `json:"on_time_rate"`    // hadir / held meetings
+	Meetings       []MeetingHistory `json:"meetings"`
+}
+
+// SemesterHistory groups subjects by semester
+type SemesterHistory struct {
+	Semester       int              `json:"semester"`
+	Counts         StatusCounts     `json:"counts"`
+	HeldMeetings   int              `json:"held_meetings"`
+	AttendanceRate float64          `json:"attendance_rate"`
+	Subjects       []SubjectHistory `json:"subjects"`
+}
+
+// Helper: (hadir + terlambat) / held and hadir / held, 0 when nothing was held
+func attendanceRates(counts StatusCounts, held int) (float64, float64) {
+	if held == 0 {
+		return 0, 0
+	}
+	return float64(counts.Hadir+counts.Terlambat) / float64(held), float64(counts.Hadir) / float64(held)
+}
+
+// GetStudentHistory returns a student's attendance grouped semester → subject → meeting.
+// Students see their own history; lecturers (subjects they teach in the student's class),
+// class admins (students of their class) and admin dev may pass a student ID.
+// GET /api/attendance/history
+// GET /api/attendance/history/:studentId?semester=
+func (h *AttendanceHandler) GetStudentHistory(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	studentID := user.UserID
+	if param := c.Params("studentId"); param != "" {
+		parsed, err := uuid.Parse(param)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid student ID"})
+		}
+		studentID = parsed
+	}
+
+	var student models.Profile
+	if err := h.DB.Preload("Class").Where("user_id = ?", studentID).First(&student).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Student not found"})
+	}
+
+	// Access: own history, or a role that oversees the student
+	if studentID != user.UserID {
+		switch user.Role {
+		case models.RoleAdminDev:
+		case models.RoleAdminKelas:
+			if user.ClassID == nil || student.ClassID == nil || *user.ClassID != *student.ClassID {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "You can only view students of your own class"})
+			}
+		case models.RoleAdminDosen:
+		default:
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Access denied"})
+		}
+	}
+
+	// 1. Subjects of the student: assigned to or held in their class, enrolled through KRS,
+	// or with a record of theirs
+	classID := uuid.Nil
+	if student.ClassID != nil {
+		classID = *student.ClassID
+	}
+	var subjectIDs []uuid.UUID
+	var rows []struct{ SubjectID uuid.UUID }
+	if user.Role == models.RoleAdminDosen && studentID != user.UserID {
+		h.DB.Raw(`
+			SELECT subject_id FROM teaching_assignments WHERE lecturer_id = ? AND class_id = ?
+			UNION
+			SELECT e.subject_id FROM enrollments e
+			JOIN teaching_assignments ta ON ta.subject_id = e.subject_id AND ta.class_id = e.class_id
+			WHERE e.student_id = ? AND ta.lecturer_id = ?`, user.UserID, classID, studentID, user.UserID).Scan(&rows)
+		if len(rows) == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "You do not teach this student's class"})
+		}
+	} else {
+		h.DB.Raw(`
+			SELECT subject_id FROM teaching_assignments WHERE class_id = ?
+			UNION
+			SELECT m.subject_id FROM attendance_sessions s JOIN meetings m ON m.id = s.meeting_id WHERE s.class_id = ?
+			UNION
+			SELECT subject_id FROM enrollments WHERE student_id = ?
+			UNION
+			SELECT m.subject_id FROM attendance_records r
+			JOIN attendance_sessions s ON s.id = r.session_id
+			JOIN meetings m ON m.id = s.meeting_id
+			WHERE r.student_id = ?`, classID, classID, studentID, studentID).Scan(&rows)
+	}
+	for _, r := range rows {
+		subjectIDs = append(subjectIDs, r.SubjectID)
+	}
+
+	query := h.DB.Where("id IN ?", subjectIDs)
+	if semester := c.QueryInt("semester", 0); semester > 0 {
+		query = query.Where("semester = ?", semester)
+	}
+	var subjects []models.Subject
+	if len(subjectIDs) > 0 {
+		query.Order("semester ASC, name ASC").Find(&subjects)
+	}
+
+	ids := make([]uuid.UUID, len(subjects))
+	for i, s := range subjects {
+		ids[i] = s.ID
+	}
+
+	// 2. Meetings, the section's latest session per meeting and the student's records. The
+	// section is the class of the student's latest enrollment in the subject, else their class.
+	var meetings []models.Meeting
+	sessionByMeeting := make(map[uuid.UUID]models.AttendanceSession)
+	recordByMeeting := make(map[uuid.UUID]models.AttendanceRecord)
+	if len(ids) > 0 {
+		h.DB.Where("subject_id IN ?", ids).Order("meeting_number ASC").Find(&meetings)
+		subjectOfMeeting := make(map[uuid.UUID]uuid.UUID, len(meetings))
+		for _, m := range meetings {
+			subjectOfMeeting[m.ID] = m.SubjectID
+		}
+
+		var enrollments []models.Enrollment
+		h.DB.Where("student_id = ? AND subject_id IN ?", studentID, ids).Order("created_at ASC").Find(&enrollments)
+		sectionOf := make(map[uuid.UUID]uuid.UUID, len(enrollments))
+		sectionClasses := []uuid.UUID{classID}
+		for _, e := range enrollments {
+			sectionOf[e.SubjectID] = e.ClassID // latest wins
+			sectionClasses = append(sectionClasses, e.ClassID)
+		}
+
+		var sessions []models.AttendanceSession
+		h.DB.Joins("JOIN meetings ON meetings.id = attendance_sessions.meeting_id").
+			Where("meetings.subject_id IN ? AND attendance_sessions.class_id IN ?", ids, sectionClasses).
+			Order("attendance_sessions.created_at ASC").
+			Find(&sessions)
+		for _, s := range sessions {
+			section, enrolled := sectionOf[subjectOfMeeting[s.MeetingID]]
+			if !enrolled {
+				section = classID
+			}
+			if s.ClassID != section {
+				continue
+			}
+			sessionByMeeting[s.MeetingID] = s // latest wins
+		}
+
+		var records []models.AttendanceRecord
+		h.DB.Preload("Session").
+			Joins("JOIN attendance_sessions ON attendance_sessions.id = attendance_records.session_id").
+			Joins("JOIN meetings ON meetings.id = attendance_sessions.meeting_id").
+			Where("attendance_records.student_id = ? AND meetings.subject_id IN ?", studentID, ids).
+			Order("attendance_records.scanned_at ASC").
+			Find(&records)
+		for _, r := range records {
+			if r.Session == nil {
+				continue
+			}
+			// Several sessions per meeting: an attended record beats a later absence
+			if prev, ok := recordByMeeting[r.Session.MeetingID]; ok && models.IsAttendedStatus(prev.Status) && !models.IsAttendedStatus(r.Status) {
+				continue
+			}
+			recordByMeeting[r.Session.MeetingID] = r
+		}
+	}
+
+	meetingsBySubject := make(map[uuid.UUID][]models.Meeting)
+	for _, m := range meetings {
+		meetingsBySubject[m.SubjectID] = append(meetingsBySubject[m.SubjectID], m)
+	}
+
+	// 3. Assemble semester → subject → meeting
+	now := time.Now()
+	bySemester := make(map[int]*SemesterHistory)
+	var overall StatusCounts
+	overallHeld := 0
+
+	for _, subject := range subjects {
+		sh := SubjectHistory{
+			SubjectID: subject.ID,
+			Code:      subject.Code,
+			Name:      subject.Name,
+			SKS:       subject.SKS,
+			Meetings:  []MeetingHistory{},
+		}
+
+		for _, m := range meetingsBySubject[subject.ID] {
+			mh := MeetingHistory{
+				MeetingID:     m.ID,
+				MeetingNumber: m.MeetingNumber,
+				Topic:         m.Topic,
+				Date:          m.Date,
+			}
+
+			session, held := sessionByMeeting[m.ID]
+			if held {
+				mh.SessionHeld = true
+				mh.SessionActive = session.IsActive != nil && *session.IsActive && session.ExpiresAt.After(now)
+			}
+
+			if r, ok := recordByMeeting[m.ID]; ok {
+				scannedAt := r.ScannedAt
+				recordID := r.ID
+				mh.SessionHeld = true
+				mh.Recorded = true
+				mh.Status = r.Status
+				if mh.Status == models.AttendancePresent {
+					mh.Status = models.AttendanceHadir
+				}
+				mh.Method = r.Method
+				mh.ScannedAt = &scannedAt
+				mh.MinutesLate = r.MinutesLate
+				mh.RecordID = &recordID
+			} else if held && !mh.SessionActive {
+				// Closed without a scan (the closer may not have written the alpa record yet)
+				mh.Status = models.AttendanceAlpa
+			}
+
+			if (mh.SessionHeld && !mh.SessionActive) || mh.Recorded {
+				sh.HeldMeetings++
+				sh.Counts.add(mh.Status)
+			}
+			sh.Meetings = append(sh.Meetings, mh)
+		}
+
+		sh.TotalMeetings = len(sh.Meetings)
+		sh.AttendanceRate, sh.OnTimeRate = attendanceRates(sh.Counts, sh.HeldMeetings)
+
+		sem, ok := bySemester[subject.Semester]
+		if !ok {
+			sem = &SemesterHistory{Semester: subject.Semester, Subjects: []SubjectHistory{}}
+			bySemester[subject.Semester] = sem
+		}
+		sem.Subjects = append(sem.Subjects, sh)
+		sem.HeldMeetings += sh.HeldMeetings
+		sem.Counts.Hadir += sh.Counts.Hadir
+		sem.Counts.Terlambat += sh.Counts.Terlambat
+		sem.Counts.Izin += sh.Counts.Izin
+		sem.Counts.Sakit += sh.Counts.Sakit
+		sem.Counts.Alpa += sh.Counts.Alpa
+	}
+
+	semesters := make([]SemesterHistory, 0, len(bySemester))
+	for _, sem := range bySemester {
+		sem.AttendanceRate, _ = attendanceRates(sem.Counts, sem.HeldMeetings)
+		overallHeld += sem.HeldMeetings
+		overall.Hadir += sem.Counts.Hadir
+		overall.Terlambat += sem.Counts.Terlambat
+		overall.Izin += sem.Counts.Izin
+		overall.Sakit += sem.Counts.Sakit
+		overall.Alpa += sem.Counts.Alpa
+		semesters = append(semesters, *sem)
+	}
+	sort.Slice(semesters, func(i, j int) bool { return semesters[i].Semester < semesters[j].Semester })
+
+	overallRate, _ := attendanceRates(overall, overallHeld)
+
+	className := ""
+	if student.Class != nil {
+		className = student.Class.Name
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"student": fiber.Map{
+				"user_id":    student.UserID,
+				"nim":        student.NIM,
+				"full_name":  student.FullName,
+				"class_id":   student.ClassID,
+				"class_name": className,
+			},
+			"semesters": semesters,
+			"summary": fiber.Map{
+				"counts":          overall,
+				"held_meetings":   overallHeld,
+				"attendance_rate": overallRate,
+			},
+		},
+	})
+}
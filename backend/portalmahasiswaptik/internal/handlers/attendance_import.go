@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ImportMaxRows caps the data rows read per sheet
+const ImportMaxRows = 500
+
+// Import diff actions
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportUnchanged = "unchanged"
+)
+
+// ImportChange is one student × meeting line of the import diff
+type ImportChange struct {
+	Sheet          string    `json:"sheet"`
+	Row            int       `json:"row"`
+	MeetingNumber  int       `json:"meeting_number"`
+	StudentID      uuid.UUID `json:"student_id"`
+	NIM            string    `json:"nim"`
+	Name           string    `json:"name"`
+	CurrentStatus  string    `json:"current_status,omitempty"`
+	NewStatus      string    `json:"new_status"`
+	MinutesLate    int       `json:"minutes_late"`
+	Action         string    `json:"action"`
+	SessionMissing bool      `json:"session_missing,omitempty"` // a closed session is created on apply
+
+	meetingID uuid.UUID
+}
+
+// ImportIssue is a row or sheet the import could not use
+type ImportIssue struct {
+	Sheet   string `json:"sheet"`
+	Row     int    `json:"row,omitempty"`
+	NIM     string `json:"nim,omitempty"`
+	Message string `json:"message"`
+}
+
+// importRow is a parsed data row of an attendance sheet
+type importRow struct {
+	Row         int
+	NIM         string
+	Status      string
+	MinutesLate int
+}
+
+// Helper: Map the statuses written by generateAttendanceSheet (and common variants) back to record statuses.
+// Returns "" for rows that carry no attendance yet (PENDING / empty).
+func parseImportStatus(raw string) (string, bool) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "", "-", "PENDING":
+		return "", true
+	case "HADIR", "PRESENT", "H":
+		return models.AttendanceHadir, true
+	case "TERLAMBAT", "LATE", "T":
+		return models.AttendanceTerlambat, true
+	case "ALPHA", "ALPA", "ABSENT", "A":
+		return models.AttendanceAlpa, true
+	case "IZIN", "PERMIT", "I":
+		return models.AttendanceIzin, true
+	case "SAKIT", "SICK", "S":
+		return models.AttendanceSakit, true
+	}
+	return "", false
+}
+
+// Helper: Read one sheet laid out like generateAttendanceSheet.
+// The meeting number comes from the "Pertemuan:" label and the table starts at the row whose cell reads "NIM".
+// Sheets without the label (the Rekap/Summary sheet, notes) are not meeting sheets and are skipped silently.
+func parseAttendanceGrid(sheet string, rows [][]string) (int, []importRow, []ImportIssue) {
+	meetingNum := 0
+	labelled := false
+	headerRow := -1
+	nimCol, statusCol, lateCol := -1, -1, -1
+
+	for i, row := range rows {
+		for j, cell := range row {
+			label := strings.ToLower(strings.TrimSpace(cell))
+			if (label == "pertemuan:" || label == "pertemuan") && !labelled {
+				labelled = true
+				if j+1 < len(row) {
+					meetingNum, _ = strconv.Atoi(strings.TrimSpace(row[j+1]))
+				}
+			}
+			if label == "nim" && headerRow < 0 {
+				headerRow = i
+			}
+		}
+		if headerRow >= 0 {
+			break
+		}
+	}
+	if !labelled {
+		return 0, nil, nil
+	}
+	if headerRow < 0 {
+		return 0, nil, []ImportIssue{{Sheet: sheet, Message: "Kolom NIM tidak ditemukan, sheet dilewati"}}
+	}
+
+	for j, cell := range rows[headerRow] {
+		switch label := strings.ToLower(strings.TrimSpace(cell)); {
+		case label == "nim":
+			nimCol = j
+		case label == "status":
+			statusCol = j
+		case strings.HasPrefix(label, "terlambat"):
+			lateCol = j
+		}
+	}
+	if statusCol < 0 {
+		return 0, nil, []ImportIssue{{Sheet: sheet, Row: headerRow + 1, Message: "Kolom Status tidak ditemukan"}}
+	}
+	if meetingNum <= 0 {
+		return 0, nil, []ImportIssue{{Sheet: sheet, Message: "Nomor pertemuan tidak ditemukan (isi sel di samping label \"Pertemuan:\")"}}
+	}
+
+	var parsed []importRow
+	var issues []ImportIssue
+	for i := headerRow + 1; i < len(rows); i++ {
+		row := rows[i]
+		cell := func(col int) string {
+			if col < 0 || col >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[col])
+		}
+
+		nim := cell(nimCol)
+		if nim == "" {
+			continue
+		}
+		if len(parsed) >= ImportMaxRows {
+			issues = append(issues, ImportIssue{Sheet: sheet, Row: i + 1, Message: fmt.Sprintf("Maksimal %d baris per sheet", ImportMaxRows)})
+			break
+		}
+
+		status, ok := parseImportStatus(cell(statusCol))
+		if !ok {
+			issues = append(issues, ImportIssue{Sheet: sheet, Row: i + 1, NIM: nim, Message: "Status tidak dikenal: " + cell(statusCol)})
+			continue
+		}
+		if status == "" {
+			continue
+		}
+
+		minutesLate := 0
+		if status == models.AttendanceTerlambat {
+			if v, err := strconv.Atoi(cell(lateCol)); err == nil && v > 0 {
+				minutesLate = v
+			}
+		}
+
+		parsed = append(parsed, importRow{Row: i + 1, NIM: nim, Status: status, MinutesLate: minutesLate})
+	}
+
+	return meetingNum, parsed, issues
+}
+
+// Helper: Read every sheet of the upload as rows of cells. CSV files are treated as a single sheet.
+func readImportFile(filename string, content []byte) (map[string][][]string, []string, error) {
+	sheets := make(map[string][][]string)
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		f, err := excelize.OpenReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, nil, fmt.Errorf("file Excel tidak dapat dibaca")
+		}
+		defer f.Close()
+
+		order := f.GetSheetList()
+		for _, name := range order {
+			rows, err := f.GetRows(name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("sheet %s tidak dapat dibaca", name)
+			}
+			sheets[name] = rows
+		}
+		return sheets, order, nil
+
+	case ".csv":
+		content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		// Excel with an Indonesian locale saves CSV with semicolons
+		if firstLine, _, _ := bytes.Cut(content, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("file CSV tidak valid: %v", err)
+		}
+		name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+		sheets[name] = rows
+		return sheets, []string{name}, nil
+	}
+
+	return nil, nil, fmt.Errorf("format file harus .xlsx atau .csv")
+}
+
+// ImportAttendance reads a sheet in the export layout (NIM, name, status per meeting) and
+// diffs it against the stored records. Nothing is written unless dry_run=false and every
+// row is valid; applied rows become method "manual" records with updated_by set.
+// POST /api/attendance/import (multipart: file, subject_id, class_id, dry_run)
+func (h *AttendanceHandler) ImportAttendance(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	subjectID, err := uuid.Parse(c.FormValue("subject_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "subject_id tidak valid"})
+	}
+	classID, err := uuid.Parse(c.FormValue("class_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "class_id tidak valid"})
+	}
+	dryRun := c.FormValue("dry_run") != "false"
+
+	if !isAssigned(h.DB, user, subjectID, classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "file is required"})
+	}
+	fileContent, err := file.Open()
+	if err != nil {
+		fmt.Printf("❌ Error: Failed to open import file: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "failed to open file"})
+	}
+	defer fileContent.Close()
+
+	content, err := io.ReadAll(fileContent)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "failed to read file"})
+	}
+
+	sheets, order, err := readImportFile(file.Filename, content)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	// Context: meetings of the subject and the class roster keyed by NIM
+	var meetings []models.Meeting
+	h.DB.Where("subject_id = ?", subjectID).Find(&meetings)
+	meetingByNum := make(map[int]models.Meeting)
+	for _, m := range meetings {
+		meetingByNum[m.MeetingNumber] = m
+	}
+
+	var students []models.Profile
+	termID, _ := enrollment.ActiveTermID(h.DB)
+	enrollment.Roster(h.DB, termID, subjectID, classID).Find(&students)
+	roster := make(map[string]models.Profile)
+	for _, s := range students {
+		roster[strings.TrimSpace(s.NIM)] = s
+	}
+
+	var changes []ImportChange
+	var issues []ImportIssue
+	seen := make(map[string]string) // "meeting|nim" -> sheet that set it
+	meetingSheets := 0
+
+	for _, sheet := range order {
+		meetingNum, rows, sheetIssues := parseAttendanceGrid(sheet, sheets[sheet])
+		issues = append(issues, sheetIssues...)
+		if meetingNum == 0 {
+			continue
+		}
+		meetingSheets++
+
+		meeting, ok := meetingByNum[meetingNum]
+		if !ok {
+			issues = append(issues, ImportIssue{Sheet: sheet, Message: fmt.Sprintf("Pertemuan %d tidak ada di mata kuliah ini", meetingNum)})
+			continue
+		}
+
+		// Latest session of the meeting for this class, as the export reads it
+		var sessions []models.AttendanceSession
+		h.DB.Where("meeting_id = ? AND class_id = ?", meeting.ID, classID).Order("created_at DESC").Limit(1).Find(&sessions)
+
+		recordMap := make(map[uuid.UUID]models.AttendanceRecord)
+		if len(sessions) > 0 {
+			var records []models.AttendanceRecord
+			h.DB.Where("session_id = ?", sessions[0].ID).Find(&records)
+			for _, r := range records {
+				recordMap[r.StudentID] = r
+			}
+		}
+
+		for _, row := range rows {
+			student, ok := roster[row.NIM]
+			if !ok {
+				issues = append(issues, ImportIssue{Sheet: sheet, Row: row.Row, NIM: row.NIM, Message: "NIM tidak terdaftar di kelas ini"})
+				continue
+			}
+			key := fmt.Sprintf("%d|%s", meetingNum, row.NIM)
+			if prev, dup := seen[key]; dup {
+				issues = append(issues, ImportIssue{Sheet: sheet, Row: row.Row, NIM: row.NIM, Message: "NIM muncul dua kali untuk pertemuan yang sama (lihat sheet " + prev + ")"})
+				continue
+			}
+			seen[key] = sheet
+
+			change := ImportChange{
+				Sheet:          sheet,
+				Row:            row.Row,
+				MeetingNumber:  meetingNum,
+				StudentID:      student.UserID,
+				NIM:            row.NIM,
+				Name:           student.FullName,
+				NewStatus:      row.Status,
+				MinutesLate:    row.MinutesLate,
+				Action:         ImportCreate,
+				SessionMissing: len(sessions) == 0,
+				meetingID:      meeting.ID,
+			}
+			if record, exists := recordMap[student.UserID]; exists {
+				current := record.Status
+				if current == models.AttendancePresent {
+					current = models.AttendanceHadir
+				}
+				change.CurrentStatus = current
+				change.Action = ImportUpdate
+				if current == row.Status && record.MinutesLate == row.MinutesLate && record.InvalidatedAt == nil {
+					change.Action = ImportUnchanged
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	if meetingSheets == 0 && len(issues) == 0 {
+		issues = append(issues, ImportIssue{Message: "Tidak ada sheet pertemuan (sel \"Pertemuan:\" berisi nomor pertemuan) di file ini"})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].MeetingNumber != changes[j].MeetingNumber {
+			return changes[i].MeetingNumber < changes[j].MeetingNumber
+		}
+		return changes[i].NIM < changes[j].NIM
+	})
+
+	summary := fiber.Map{ImportCreate: 0, ImportUpdate: 0, ImportUnchanged: 0, "errors": len(issues)}
+	for _, ch := range changes {
+		summary[ch.Action] = summary[ch.Action].(int) + 1
+	}
+	result := fiber.Map{
+		"dry_run": dryRun,
+		"summary": summary,
+		"changes": changes,
+		"errors":  issues,
+	}
+
+	if dryRun {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    result,
+			"message": "Pratinjau impor, belum ada data yang disimpan",
+		})
+	}
+	if len(issues) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"data":    result,
+			"error":   "Perbaiki baris yang bermasalah sebelum menerapkan impor",
+		})
+	}
+
+	applied := 0
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		sessionByMeeting := make(map[uuid.UUID]uuid.UUID)
+		for _, ch := range changes {
+			if ch.Action == ImportUnchanged {
+				continue
+			}
+
+			sessionID, ok := sessionByMeeting[ch.meetingID]
+			if !ok {
+				var err error
+				if sessionID, err = importSession(tx, ch.meetingID, classID, user.UserID); err != nil {
+					return err
+				}
+				sessionByMeeting[ch.meetingID] = sessionID
+			}
+
+			var record models.AttendanceRecord
+			tx.Where("session_id = ? AND student_id = ?", sessionID, ch.StudentID).Limit(1).Find(&record)
+
+			updatedBy := user.UserID
+			record.SessionID = sessionID
+			record.StudentID = ch.StudentID
+			record.Status = ch.NewStatus
+			record.Method = "manual"
+			record.MinutesLate = ch.MinutesLate
+			record.UpdatedBy = &updatedBy
+			record.InvalidatedAt = nil
+			record.InvalidatedBy = nil
+			record.InvalidReason = nil
+			if record.ScannedAt.IsZero() {
+				record.ScannedAt = time.Now()
+			}
+
+			var saveErr error
+			if record.ID == uuid.Nil {
+				saveErr = tx.Create(&record).Error
+			} else {
+				saveErr = tx.Save(&record).Error
+			}
+			if saveErr != nil {
+				return saveErr
+			}
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("❌ Attendance Import Error: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal menyimpan hasil impor, tidak ada data yang diubah",
+		})
+	}
+
+	fmt.Printf("✅ Attendance import by %s: %d records written\n", user.Email, applied)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+		"message": fmt.Sprintf("%d data presensi berhasil diimpor", applied),
+	})
+}
+
+// Helper: Latest session of the meeting for the class, or a closed one created for the import
+func importSession(tx *gorm.DB, meetingID, classID, lecturerID uuid.UUID) (uuid.UUID, error) {
+	var sessions []models.AttendanceSession
+	tx.Where("meeting_id = ? AND class_id = ?", meetingID, classID).Order("created_at DESC").Limit(1).Find(&sessions)
+	if len(sessions) > 0 {
+		return sessions[0].ID, nil
+	}
+
+	// Paper attendance: the session never ran, so it is created already closed and finalized
+	now := time.Now()
+	isActive := false
+	session := models.AttendanceSession{
+		ClassID:     classID,
+		LecturerID:  lecturerID,
+		MeetingID:   meetingID,
+		QRCode:      generateQRToken(),
+		IsActive:    &isActive,
+		ExpiresAt:   now,
+		FinalizedAt: &now,
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return session.ID, nil
+}
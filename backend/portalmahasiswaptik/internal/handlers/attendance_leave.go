@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LeaveRequestBody represents a student's izin/sakit submission
+type LeaveRequestBody struct {
+	Type          string  `json:"type" validate:"required,oneof=izin sakit"`
+	StartDate     string  `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate       string  `json:"end_date" validate:"required"`   // YYYY-MM-DD
+	Reason        string  `json:"reason" validate:"required,min=5"`
+	AttachmentURL *string `json:"attachment_url"`
+}
+
+// ReviewLeaveRequest represents the approve/reject decision
+type ReviewLeaveRequest struct {
+	Approve bool    `json:"approve"`
+	Note    *string `json:"note"`
+}
+
+// SubmitLeave creates a pending leave request for the current student
+// POST /api/attendance/leave
+func (h *AttendanceHandler) SubmitLeave(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req LeaveRequestBody
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+
+	start, err1 := time.ParseInLocation("2006-01-02", req.StartDate, WIB)
+	end, err2 := time.ParseInLocation("2006-01-02", req.EndDate, WIB)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid date range (use YYYY-MM-DD, end >= start)",
+		})
+	}
+	if end.Sub(start) > 31*24*time.Hour {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Leave requests cannot exceed 31 days",
+		})
+	}
+
+	leave := models.LeaveRequest{
+		StudentID:     user.UserID,
+		ClassID:       user.ClassID,
+		Type:          req.Type,
+		StartDate:     start,
+		EndDate:       end,
+		Reason:        req.Reason,
+		AttachmentURL: req.AttachmentURL,
+		Status:        models.LeavePending,
+	}
+
+	if err := h.DB.Create(&leave).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to submit leave request",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    leave,
+		"message": "Leave request submitted and waiting for approval",
+	})
+}
+
+// GetLeaveRequests lists leave requests visible to the caller
+// GET /api/attendance/leave?status=pending
+func (h *AttendanceHandler) GetLeaveRequests(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := h.DB.Model(&models.LeaveRequest{})
+	switch user.Role {
+	case models.RoleMahasiswa:
+		query = query.Where("student_id = ?", user.UserID)
+	case models.RoleAdminKelas:
+		if user.ClassID == nil {
+			query = query.Where("student_id = ?", user.UserID)
+		} else {
+			query = query.Where("class_id = ?", *user.ClassID)
+		}
+	}
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var leaves []models.LeaveRequest
+	if err := query.Order("created_at DESC").Find(&leaves).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to fetch leave requests",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    leaves,
+	})
+}
+
+// ReviewLeave approves or rejects a pending leave request. Approving also converts
+// auto-generated alpa records inside the range into izin/sakit.
+// POST /api/attendance/leave/:id/review
+func (h *AttendanceHandler) ReviewLeave(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	leaveID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid leave request ID",
+		})
+	}
+
+	var req ReviewLeaveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	var leave models.LeaveRequest
+	if err := h.DB.Where("id = ?", leaveID).First(&leave).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Leave request not found",
+		})
+	}
+
+	// Class admins only review their own class, never their own request
+	if user.Role == models.RoleAdminKelas {
+		if user.ClassID == nil || leave.ClassID == nil || *leave.ClassID != *user.ClassID || leave.StudentID == user.UserID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "You can only review leave requests of your own class",
+			})
+		}
+	}
+
+	// Lecturers only review students they advise or teach
+	if user.Role == models.RoleAdminDosen {
+		var student models.Profile
+		if err := h.DB.Where("user_id = ?", leave.StudentID).First(&student).Error; err != nil || !canViewStudent(h.DB, user, &student) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "You can only review leave requests of students you teach",
+			})
+		}
+	}
+
+	status := models.LeaveRejected
+	if req.Approve {
+		status = models.LeaveApproved
+	}
+	now := time.Now()
+	reviewer := user.UserID
+
+	// Only a still-pending request is decided, so concurrent reviews cannot overwrite each other
+	res := h.DB.Model(&leave).Where("status = ?", models.LeavePending).Updates(models.LeaveRequest{
+		Status:     status,
+		ReviewedBy: &reviewer,
+		ReviewedAt: &now,
+		ReviewNote: req.Note,
+	})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to review leave request",
+		})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "Leave request has already been reviewed",
+		})
+	}
+
+	// Sessions already finalized as alpa are corrected retroactively
+	var converted int64
+	if req.Approve {
+		res := h.DB.Exec(`
+			UPDATE attendance_records r SET status = ?
+			FROM attendance_sessions s
+			WHERE r.session_id = s.id
+			  AND r.student_id = ?
+			  AND r.status = ? AND r.method = 'auto'
+			  AND (COALESCE(s.scheduled_start, s.created_at) AT TIME ZONE 'Asia/Jakarta')::date BETWEEN ? AND ?`,
+			leave.Type, leave.StudentID, models.AttendanceAlpa,
+			leave.StartDate.Format("2006-01-02"), leave.EndDate.Format("2006-01-02"))
+		converted = res.RowsAffected
+	}
+
+	var reviewerProfile models.Profile
+	h.DB.Select("full_name").Where("user_id = ?", user.UserID).First(&reviewerProfile)
+	note := ""
+	if req.Note != nil {
+		note = *req.Note
+	}
+	h.Notify.PublishAsync(notify.Event{
+		Type:    notify.EventLeaveReviewed,
+		UserIDs: []uuid.UUID{leave.StudentID},
+		Link:    "/dashboard/attendance-history",
+		Data: map[string]interface{}{
+			"type":     leave.Type,
+			"approved": req.Approve,
+			"start":    leave.StartDate.Format("02/01/2006"),
+			"end":      leave.EndDate.Format("02/01/2006"),
+			"reviewer": reviewerProfile.FullName,
+			"note":     note,
+		},
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"leave":             leave,
+			"records_converted": converted,
+		},
+		"message": "Leave request " + status,
+	})
+}
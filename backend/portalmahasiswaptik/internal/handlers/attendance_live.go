@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/realtime"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// StreamSession pushes live scan results for a session as Server-Sent Events
+// GET /api/attendance/session/:id/live
+func (h *AttendanceHandler) StreamSession(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid session ID",
+		})
+	}
+
+	var session models.AttendanceSession
+	if err := h.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+
+	// Check teaching assignment
+	if !h.canManageSession(user, &session) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "You can only watch sessions of classes you teach",
+		})
+	}
+
+	if h.Live == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"error":   "Live feed is not available",
+		})
+	}
+
+	events, unsubscribe := h.Live.Subscribe(session.ID)
+	snapshot := realtime.AttendanceEvent{
+		Type:      realtime.EventCounts,
+		SessionID: session.ID,
+		Counts:    h.sessionCounts(&session),
+		At:        time.Now(),
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		if err := writeSSE(w, snapshot); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeSSE(w, ev); err != nil {
+					return
+				}
+				if ev.Type == realtime.EventSessionClosed {
+					return
+				}
+			case <-keepAlive.C:
+				// Comment line keeps proxies from closing the idle stream and detects disconnects
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// Helper: Write one SSE frame and flush it to the client
+func writeSSE(w *bufio.Writer, ev realtime.AttendanceEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Helper: Count present vs. enrolled students for a session
+func (h *AttendanceHandler) sessionCounts(session *models.AttendanceSession) *realtime.AttendanceCounts {
+	var present, total int64
+	h.DB.Model(&models.AttendanceRecord{}).
+		Where("session_id = ? AND status IN ?", session.ID, []string{models.AttendanceHadir, models.AttendanceTerlambat, models.AttendancePresent, models.AttendanceLate}).
+		Count(&present)
+	var meeting models.Meeting
+	h.DB.Select("subject_id").Where("id = ?", session.MeetingID).First(&meeting)
+	enrollment.Roster(h.DB, enrollment.SessionTermID(h.DB, session), meeting.SubjectID, session.ClassID).Count(&total)
+
+	absent := total - present
+	if absent < 0 {
+		absent = 0
+	}
+	return &realtime.AttendanceCounts{Present: present, Absent: absent, Total: total}
+}
+
+// Helper: Broadcast a successful scan together with the updated tally
+func (h *AttendanceHandler) publishScanAccepted(session *models.AttendanceSession, profile *models.Profile, record *models.AttendanceRecord, flags []string) {
+	if h.Live == nil {
+		return
+	}
+	studentID := record.StudentID
+	if err := h.Live.Publish(realtime.AttendanceEvent{
+		Type:        realtime.EventScanAccepted,
+		SessionID:   session.ID,
+		StudentID:   &studentID,
+		StudentName: profile.FullName,
+		NIM:         profile.NIM,
+		Status:      record.Status,
+		Biometric:   record.BiometricVerified,
+		Flags:       flags,
+		Counts:      h.sessionCounts(session),
+		At:          record.ScannedAt,
+	}); err != nil {
+		fmt.Printf("⚠️ Live feed publish failed: %v\n", err)
+	}
+}
+
+// Helper: Broadcast a rejected scan with the reason shown to the student
+func (h *AttendanceHandler) publishScanRejected(session *models.AttendanceSession, profile *models.Profile, reason string) {
+	if h.Live == nil {
+		return
+	}
+	ev := realtime.AttendanceEvent{
+		Type:      realtime.EventScanRejected,
+		SessionID: session.ID,
+		Reason:    reason,
+	}
+	if profile != nil {
+		studentID := profile.UserID
+		ev.StudentID = &studentID
+		ev.StudentName = profile.FullName
+		ev.NIM = profile.NIM
+	}
+	if err := h.Live.Publish(ev); err != nil {
+		fmt.Printf("⚠️ Live feed publish failed: %v\n", err)
+	}
+}
+
+// Helper: Tell every viewer that the session is over
+func (h *AttendanceHandler) publishSessionClosed(session *models.AttendanceSession) {
+	if h.Live == nil {
+		return
+	}
+	if err := h.Live.Publish(realtime.AttendanceEvent{
+		Type:      realtime.EventSessionClosed,
+		SessionID: session.ID,
+		Counts:    h.sessionCounts(session),
+	}); err != nil {
+		fmt.Printf("⚠️ Live feed publish failed: %v\n", err)
+	}
+}
@@ -0,0 +1,474 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Offline scan windows
+const (
+	OfflineClaimTTL   = 2 * time.Minute  // each claim shown on the projector is valid this long
+	OfflineClockSkew  = 30 * time.Second // tolerated drift between lecturer and student devices
+	OfflineSyncWindow = 6 * time.Hour    // how long after the claim a student may still upload it
+)
+
+// OfflineClaim is the payload encoded in an offline-capable QR
+type OfflineClaim struct {
+	SessionID uuid.UUID `json:"sid"`
+	NotBefore int64     `json:"nbf"`
+	ExpiresAt int64     `json:"exp"`
+	Nonce     string    `json:"n"`
+}
+
+// Helper: Encode and sign a claim as base64url(payload).base64url(hmac)
+func signOfflineClaim(key string, claim OfflineClaim) (string, error) {
+	payload, err := json.Marshal(claim)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Helper: Sign a fresh claim for the session, valid for OfflineClaimTTL (never past the
+// session's expiry)
+func issueOfflineClaim(session *models.AttendanceSession) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(OfflineClaimTTL)
+	if exp.After(session.ExpiresAt) {
+		exp = session.ExpiresAt
+	}
+	token, err := signOfflineClaim(session.OfflineKey, OfflineClaim{
+		SessionID: session.ID,
+		NotBefore: now.Unix(),
+		ExpiresAt: exp.Unix(),
+		Nonce:     generateQRToken()[:12],
+	})
+	return token, exp, err
+}
+
+// Helper: Decode a claim without verifying it (the key is looked up by session ID)
+func decodeOfflineClaim(token string) (OfflineClaim, []byte, []byte, bool) {
+	var claim OfflineClaim
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return claim, nil, nil, false
+	}
+	payload, err1 := base64.RawURLEncoding.DecodeString(parts[0])
+	sig, err2 := base64.RawURLEncoding.DecodeString(parts[1])
+	if err1 != nil || err2 != nil || json.Unmarshal(payload, &claim) != nil {
+		return claim, nil, nil, false
+	}
+	return claim, payload, sig, true
+}
+
+// Helper: Parse a base64 PKIX (SPKI) DER public key and require ECDSA P-256
+func parseOfflineDeviceKey(raw string) ([]byte, bool) {
+	der, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		if der, err = base64.RawURLEncoding.DecodeString(raw); err != nil {
+			return nil, false
+		}
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, false
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, false
+	}
+	return der, true
+}
+
+// Helper: Verify an ECDSA P-256 / SHA-256 signature from a registered device. WebCrypto
+// returns the raw r||s form (64 bytes); ASN.1 DER is accepted as well.
+func verifyOfflineDeviceSignature(der, message, sig []byte) bool {
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return false
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256(message)
+	if len(sig) == 64 {
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return ecdsa.VerifyASN1(key, digest[:], sig)
+}
+
+// Helper: Message the student device signs, binding claim, student and scan time
+func offlineCountersignMessage(claim string, userID uuid.UUID, scannedAt string) []byte {
+	return []byte(claim + "|" + userID.String() + "|" + scannedAt)
+}
+
+// GetOfflineClaim issues a fresh signed claim for the lecturer's QR display
+// GET /api/attendance/session/:id/offline-claim
+func (h *AttendanceHandler) GetOfflineClaim(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid session ID",
+		})
+	}
+	session, errResp := h.ownedSession(c, sessionID)
+	if session == nil {
+		return errResp
+	}
+
+	if session.AllowOffline == nil || !*session.AllowOffline || session.OfflineKey == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Offline mode is not enabled for this session",
+		})
+	}
+	if session.IsActive == nil || !*session.IsActive || time.Now().After(session.ExpiresAt) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session is no longer active",
+		})
+	}
+
+	token, exp, err := issueOfflineClaim(session)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to sign claim",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"claim":      token,
+			"expires_at": exp,
+		},
+	})
+}
+
+// RegisterOfflineDeviceRequest registers the public half of a device key pair
+type RegisterOfflineDeviceRequest struct {
+	DeviceID  string `json:"device_id" validate:"required,min=8,max=128"`
+	PublicKey string `json:"public_key" validate:"required"` // base64 SPKI DER, ECDSA P-256
+}
+
+// RegisterOfflineDevice stores the public key a student's device signs offline scans with
+// (register while online). The private key never leaves the device and the server holds no
+// secret the student could use to sign for someone else.
+// POST /api/attendance/offline/devices
+func (h *AttendanceHandler) RegisterOfflineDevice(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req RegisterOfflineDeviceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+
+	der, ok := parseOfflineDeviceKey(req.PublicKey)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "public_key must be a base64 SPKI ECDSA P-256 key",
+		})
+	}
+
+	// A device belongs to one student; re-registering it rotates the key
+	var device models.OfflineDevice
+	h.DB.Where("device_id = ?", req.DeviceID).Limit(1).Find(&device)
+	if device.ID != uuid.Nil && device.UserID != user.UserID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "This device is already registered to another student",
+		})
+	}
+
+	device.UserID = user.UserID
+	device.DeviceID = req.DeviceID
+	device.PublicKey = der
+	device.UpdatedAt = time.Now()
+	var err error
+	if device.ID != uuid.Nil {
+		err = h.DB.Save(&device).Error
+	} else {
+		err = h.DB.Create(&device).Error
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to register device",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"device_id": device.DeviceID,
+			"algorithm": "ECDSA-P256-SHA256",
+			"message":   "claim|user_id|scanned_at",
+		},
+	})
+}
+
+// OfflineScanRequest is a claim captured offline and uploaded later
+type OfflineScanRequest struct {
+	Claim     string  `json:"claim" validate:"required"`
+	DeviceID  string  `json:"device_id" validate:"required"`
+	ScannedAt string  `json:"scanned_at" validate:"required"` // RFC3339, as signed by the device
+	Signature string  `json:"signature" validate:"required"`  // base64url ECDSA signature of the registered device
+	Latitude  float64 `json:"latitude" validate:"required"`
+	Longitude float64 `json:"longitude" validate:"required"`
+}
+
+// SyncOfflineScan verifies an uploaded offline claim and records it with method "offline"
+// POST /api/attendance/scan/offline
+func (h *AttendanceHandler) SyncOfflineScan(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req OfflineScanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+
+	claim, payload, sig, ok := decodeOfflineClaim(req.Claim)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Malformed offline claim",
+		})
+	}
+
+	var session models.AttendanceSession
+	if err := h.DB.Preload("Class").Preload("Meeting.Subject").Where("id = ? AND archived_at IS NULL", claim.SessionID).First(&session).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+
+	// 1. Lecturer's signature over the claim
+	mac := hmac.New(sha256.New, []byte(session.OfflineKey))
+	mac.Write(payload)
+	if session.AllowOffline == nil || !*session.AllowOffline || session.OfflineKey == "" || !hmac.Equal(sig, mac.Sum(nil)) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid offline claim signature",
+		})
+	}
+
+	var studentProfile models.Profile
+	if err := h.DB.Where("user_id = ?", user.UserID).First(&studentProfile).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Student profile not found",
+		})
+	}
+
+	// 2. Signature of the student's registered device over claim + scan time
+	var device models.OfflineDevice
+	h.DB.Where("device_id = ? AND user_id = ?", req.DeviceID, user.UserID).Limit(1).Find(&device)
+	if device.ID == uuid.Nil {
+		h.publishScanRejected(&session, &studentProfile, "offline_unknown_device")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Device is not registered for offline scans",
+		})
+	}
+	deviceSig, err := base64.RawURLEncoding.DecodeString(req.Signature)
+	if err != nil || !verifyOfflineDeviceSignature(device.PublicKey, offlineCountersignMessage(req.Claim, user.UserID, req.ScannedAt), deviceSig) {
+		h.publishScanRejected(&session, &studentProfile, "offline_bad_signature")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid device signature",
+		})
+	}
+
+	// 3. Scan time must fall inside the claim window and the session, and the upload inside
+	// the sync window. The claim is valid for minutes only, so a device clock cannot move
+	// the scan far from when the QR was actually shown.
+	scannedAt, err := time.Parse(time.RFC3339, req.ScannedAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "scanned_at must be RFC3339",
+		})
+	}
+	nbf := time.Unix(claim.NotBefore, 0)
+	exp := time.Unix(claim.ExpiresAt, 0)
+	if scannedAt.Before(nbf.Add(-OfflineClockSkew)) || scannedAt.After(exp.Add(OfflineClockSkew)) || scannedAt.After(time.Now().Add(OfflineClockSkew)) ||
+		scannedAt.Before(session.CreatedAt.Add(-OfflineClockSkew)) || scannedAt.After(session.ExpiresAt.Add(OfflineClockSkew)) {
+		h.publishScanRejected(&session, &studentProfile, "offline_outside_window")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Scan time is outside the claim window",
+		})
+	}
+	if time.Now().After(exp.Add(OfflineSyncWindow)) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"success": false,
+			"error":   "Offline claim is too old to sync",
+		})
+	}
+
+	if !h.inSessionRoster(&session, &studentProfile) {
+		h.publishScanRejected(&session, &studentProfile, "wrong_class")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "This attendance session is for a different class",
+		})
+	}
+
+	// Offline scans cannot be checked against a live session, so the location is mandatory
+	if distance := haversineDistance(CampusLatitude, CampusLongitude, req.Latitude, req.Longitude); distance > MaxDistanceKm {
+		h.flagRejectedTravel(user.UserID, session.ID, req.Latitude, req.Longitude, scannedAt)
+		h.publishScanRejected(&session, &studentProfile, "out_of_range")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success":  false,
+			"error":    "You were too far from campus when scanning.",
+			"distance": distance,
+			"max":      MaxDistanceKm,
+		})
+	}
+
+	// An earlier scan of the same meeting counts; the closer's alpa rows are replaced
+	if existingRecord, ok := h.meetingRecord(&session, user.UserID); ok {
+		h.publishScanRejected(&session, &studentProfile, "duplicate")
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":    false,
+			"error":      "You have already marked attendance for this meeting",
+			"scanned_at": existingRecord.ScannedAt,
+		})
+	}
+	var existing models.AttendanceRecord
+	h.DB.Where("session_id = ? AND student_id = ?", session.ID, user.UserID).Limit(1).Find(&existing)
+
+	var subjectID uuid.UUID
+	if session.Meeting != nil {
+		subjectID = session.Meeting.SubjectID
+	}
+	status, minutesLate, closed := h.resolveTiming(&session, subjectID).Classify(scannedAt)
+	if closed {
+		h.publishScanRejected(&session, &studentProfile, "closed")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success":      false,
+			"error":        "Attendance for this session was already closed at scan time.",
+			"minutes_late": minutesLate,
+		})
+	}
+
+	record := models.AttendanceRecord{
+		ID:          existing.ID,
+		SessionID:   session.ID,
+		StudentID:   user.UserID,
+		Status:      status,
+		Method:      "offline",
+		ScannedAt:   scannedAt,
+		MinutesLate: minutesLate,
+		DeviceID:    &req.DeviceID,
+	}
+	if ip := c.IP(); ip != "" {
+		record.IPAddress = &ip
+	}
+	if ua := c.Get("User-Agent"); ua != "" {
+		record.UserAgent = &ua
+	}
+	record.Latitude = &req.Latitude
+	record.Longitude = &req.Longitude
+
+	// The claim nonce is single-use per student: replaying the same upload fails here
+	var replayed bool
+	saveErr := h.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.OfflineClaimUse{
+			SessionID: session.ID,
+			Nonce:     claim.Nonce,
+			StudentID: user.UserID,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			replayed = true
+			return nil
+		}
+		if existing.ID != uuid.Nil {
+			return tx.Save(&record).Error
+		}
+		return tx.Create(&record).Error
+	})
+	if replayed {
+		h.publishScanRejected(&session, &studentProfile, "offline_replay")
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error":   "This offline claim has already been used",
+		})
+	}
+	if saveErr != nil {
+		h.publishScanRejected(&session, &studentProfile, "save_failed")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to record attendance",
+		})
+	}
+
+	h.dropGeneratedRecords(&session, user.UserID)
+
+	flags := h.detectSuspiciousScan(&session, &record)
+	h.publishScanAccepted(&session, &studentProfile, &record, flags)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"record_id":    record.ID,
+			"status":       record.Status,
+			"scanned_at":   record.ScannedAt,
+			"method":       record.Method,
+			"minutes_late": record.MinutesLate,
+			"class":        session.Class.Name,
+			"subject":      session.Meeting.Subject.Name,
+			"meeting":      session.Meeting.MeetingNumber,
+		},
+		"message": scanMessage(record.Status, record.MinutesLate),
+	})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OverrideRecordRequest sets a student's status for a session by hand
+type OverrideRecordRequest struct {
+	StudentID   uuid.UUID `json:"student_id" validate:"required"`
+	Status      string    `json:"status" validate:"required,oneof=hadir terlambat alpa izin sakit"`
+	MinutesLate int       `json:"minutes_late" validate:"min=0"`
+	Note        string    `json:"note" validate:"required,min=3"`
+}
+
+// OverrideRecord creates or replaces a student's record with method "manual"
+// PUT /api/attendance/session/:id/override
+func (h *AttendanceHandler) OverrideRecord(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid session ID",
+		})
+	}
+
+	var req OverrideRecordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+
+	session, errResp := h.ownedSession(c, sessionID)
+	if session == nil {
+		return errResp
+	}
+
+	var student models.Profile
+	if err := h.DB.Where("user_id = ?", req.StudentID).First(&student).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Student not found",
+		})
+	}
+	if student.ClassID == nil || *student.ClassID != session.ClassID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Student is not in this session's class",
+		})
+	}
+
+	minutesLate := 0
+	if req.Status == models.AttendanceTerlambat {
+		minutesLate = req.MinutesLate
+	}
+	note := strings.TrimSpace(req.Note)
+	overriddenBy := user.UserID
+
+	var record models.AttendanceRecord
+	h.DB.Where("session_id = ? AND student_id = ?", session.ID, req.StudentID).Limit(1).Find(&record)
+
+	record.SessionID = session.ID
+	record.StudentID = req.StudentID
+	record.Status = req.Status
+	record.Method = "manual"
+	record.MinutesLate = minutesLate
+	record.OverriddenBy = &overriddenBy
+	record.UpdatedBy = &overriddenBy
+	record.Note = &note
+	record.InvalidatedAt = nil
+	record.InvalidatedBy = nil
+	record.InvalidReason = nil
+	if record.ScannedAt.IsZero() {
+		record.ScannedAt = time.Now()
+	}
+
+	var saveErr error
+	if record.ID == uuid.Nil {
+		saveErr = h.DB.Create(&record).Error
+	} else {
+		saveErr = h.DB.Save(&record).Error
+	}
+	if saveErr != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to save attendance override",
+		})
+	}
+
+	h.publishScanAccepted(session, &student, &record, nil)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    record,
+		"message": "Attendance for " + student.FullName + " set to " + record.Status,
+	})
+}
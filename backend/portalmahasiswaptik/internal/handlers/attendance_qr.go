@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QR image size limits (pixels)
+const (
+	QRDefaultSize = 512
+	QRMinSize     = 128
+	QRMaxSize     = 2048
+)
+
+// Helper: Load the session for rendering, enforcing the teaching assignment
+func (h *AttendanceHandler) sessionForRender(c *fiber.Ctx) (*models.AttendanceSession, error) {
+	user := c.Locals("user").(middleware.UserContext)
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid session ID",
+		})
+	}
+
+	var session models.AttendanceSession
+	if err := h.DB.Preload("Class").Preload("Meeting.Subject").Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Session not found",
+		})
+	}
+
+	if !h.canManageSession(user, &session) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "You can only render sessions of classes you teach",
+		})
+	}
+
+	return &session, nil
+}
+
+// Helper: Content encoded in the QR. ?offline=true embeds a signed offline claim that,
+// like the one from GetOfflineClaim, expires after OfflineClaimTTL (see attendance_offline.go)
+// instead of the raw token, so the display has to refresh it.
+func (h *AttendanceHandler) qrPayload(c *fiber.Ctx, session *models.AttendanceSession) (string, error) {
+	if c.Query("offline") != "true" {
+		return session.QRCode, nil
+	}
+	if session.AllowOffline == nil || !*session.AllowOffline || session.OfflineKey == "" {
+		return "", fmt.Errorf("offline mode is not enabled for this session")
+	}
+	token, _, err := issueOfflineClaim(session)
+	return token, err
+}
+
+// Helper: Clamp the ?size= query parameter
+func qrSize(c *fiber.Ctx) int {
+	size := c.QueryInt("size", QRDefaultSize)
+	if size < QRMinSize {
+		return QRMinSize
+	}
+	if size > QRMaxSize {
+		return QRMaxSize
+	}
+	return size
+}
+
+// GetSessionQRPNG renders the session's current code as PNG
+// GET /api/attendance/session/:id/qr.png?size=512
+func (h *AttendanceHandler) GetSessionQRPNG(c *fiber.Ctx) error {
+	session, errResp := h.sessionForRender(c)
+	if session == nil {
+		return errResp
+	}
+
+	content, err := h.qrPayload(c, session)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	png, err := qrcode.Encode(content, qrcode.Medium, qrSize(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to render QR code"})
+	}
+
+	// The code rotates on refresh, never let a proxy serve a stale one
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "image/png")
+	return c.Send(png)
+}
+
+// GetSessionQRSVG renders the session's current code as SVG
+// GET /api/attendance/session/:id/qr.svg?size=512
+func (h *AttendanceHandler) GetSessionQRSVG(c *fiber.Ctx) error {
+	session, errResp := h.sessionForRender(c)
+	if session == nil {
+		return errResp
+	}
+
+	content, err := h.qrPayload(c, session)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to render QR code"})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "image/svg+xml")
+	return c.SendString(qrToSVG(qr.Bitmap(), qrSize(c)))
+}
+
+// Helper: Draw a QR bitmap (quiet zone included) as one SVG path
+func qrToSVG(bitmap [][]bool, size int) string {
+	modules := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#FFFFFF"/><path fill="#000000" d="%s"/></svg>`,
+		size, size, modules, modules, path.String())
+}
+
+// GetSessionPoster renders a printable A4 poster with the QR and session details
+// GET /api/attendance/session/:id/poster.pdf
+func (h *AttendanceHandler) GetSessionPoster(c *fiber.Ctx) error {
+	session, errResp := h.sessionForRender(c)
+	if session == nil {
+		return errResp
+	}
+
+	content, err := h.qrPayload(c, session)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	png, err := qrcode.Encode(content, qrcode.High, 1200)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to render QR code"})
+	}
+
+	subjectName, meetingNum := "-", 0
+	if session.Meeting != nil {
+		meetingNum = session.Meeting.MeetingNumber
+		if session.Meeting.Subject != nil {
+			subjectName = session.Meeting.Subject.Name
+		}
+	}
+	className := "-"
+	if session.Class != nil {
+		className = session.Class.Name
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Presensi "+subjectName, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Header band
+	pdf.SetFillColor(30, 41, 59)
+	pdf.Rect(0, 0, 210, 38, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 24)
+	pdf.SetXY(20, 11)
+	pdf.CellFormat(170, 10, "PRESENSI KULIAH", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.CellFormat(170, 8, "Portal Mahasiswa PTIK", "", 1, "C", false, 0, "")
+
+	// Session details
+	pdf.SetTextColor(15, 23, 42)
+	pdf.SetXY(20, 48)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.MultiCell(170, 9, tr(subjectName), "", "C", false)
+	pdf.SetFont("Helvetica", "", 14)
+	pdf.CellFormat(170, 8, tr(fmt.Sprintf("Kelas %s  -  Pertemuan %d", className, meetingNum)), "", 1, "C", false, 0, "")
+
+	// QR code
+	pdf.RegisterImageOptionsReader("qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions("qr", 35, pdf.GetY()+6, 140, 140, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetY(pdf.GetY() + 152)
+
+	// Expiry & instructions
+	expires := session.ExpiresAt.In(WIB).Format("02 Jan 2006, 15:04 WIB")
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.SetTextColor(156, 0, 6)
+	pdf.CellFormat(170, 8, "Berlaku sampai: "+expires, "", 1, "C", false, 0, "")
+
+	pdf.SetTextColor(71, 85, 105)
+	pdf.SetFont("Helvetica", "", 11)
+	instructions := "Buka menu Absensi di Portal Mahasiswa lalu scan kode di atas."
+	if c.Query("offline") == "true" {
+		instructions += " Kode ini tetap bisa dipindai tanpa sinyal dan akan disinkronkan otomatis saat online."
+	}
+	pdf.MultiCell(170, 6, tr(instructions), "", "C", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		fmt.Printf("❌ Poster PDF Error: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to render poster"})
+	}
+
+	filename := fmt.Sprintf("Poster_Absensi_%s_%s_P%d.pdf", strings.ReplaceAll(subjectName, " ", "_"), className, meetingNum)
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", filename))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WIB is the campus timezone; schedule times ("08:00") are always interpreted in it
+var WIB = time.FixedZone("WIB", 7*3600)
+
+// Default lateness rule when neither the subject nor the session configures one
+const (
+	DefaultGraceMinutes      = 15
+	DefaultLateCutoffMinutes = 60
+)
+
+var clockPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// TimingRule is the effective lateness rule for one session
+type TimingRule struct {
+	ScheduledStart      time.Time `json:"scheduled_start"`
+	GraceMinutes        int       `json:"grace_minutes"`
+	LateCutoffMinutes   int       `json:"late_cutoff_minutes"`
+	AbsentCutoffMinutes int       `json:"absent_cutoff_minutes"`
+}
+
+// Classify maps a scan time to hadir/terlambat/alpa. closed is true once the absent
+// cutoff has passed and the scan should be rejected entirely.
+func (r TimingRule) Classify(at time.Time) (status string, minutesLate int, closed bool) {
+	minutes := int(at.Sub(r.ScheduledStart).Minutes())
+	if minutes < 0 {
+		minutes = 0
+	}
+
+	if r.AbsentCutoffMinutes > 0 && minutes > r.AbsentCutoffMinutes {
+		return models.AttendanceAlpa, minutes, true
+	}
+	if minutes <= r.GraceMinutes {
+		return models.AttendanceHadir, 0, false
+	}
+	if minutes <= r.LateCutoffMinutes {
+		return models.AttendanceTerlambat, minutes, false
+	}
+	// Past the late cutoff: the scan is kept for the record but counts as absent
+	return models.AttendanceAlpa, minutes, false
+}
+
+// Helper: Parse "HH:MM" (WIB) onto the calendar date of ref
+func clockOnDate(clock string, ref time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation("15:04", clock, WIB)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day := ref.In(WIB)
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, WIB), nil
+}
+
+// resolveTiming merges defaults, the subject rule and the session overrides
+func (h *AttendanceHandler) resolveTiming(session *models.AttendanceSession, subjectID uuid.UUID) TimingRule {
+	rule := TimingRule{
+		ScheduledStart:    session.CreatedAt,
+		GraceMinutes:      DefaultGraceMinutes,
+		LateCutoffMinutes: DefaultLateCutoffMinutes,
+	}
+
+	var subjectRule models.SubjectTimingRule
+	if err := h.DB.Where("subject_id = ?", subjectID).Limit(1).Find(&subjectRule).Error; err == nil && subjectRule.ID != uuid.Nil {
+		rule.GraceMinutes = subjectRule.GraceMinutes
+		rule.LateCutoffMinutes = subjectRule.LateCutoffMinutes
+		rule.AbsentCutoffMinutes = subjectRule.AbsentCutoffMinutes
+		if subjectRule.StartTime != nil {
+			if start, err := clockOnDate(*subjectRule.StartTime, session.CreatedAt); err == nil {
+				rule.ScheduledStart = start
+			}
+		}
+	}
+
+	if session.ScheduledStart != nil {
+		rule.ScheduledStart = *session.ScheduledStart
+	}
+	if session.GraceMinutes != nil {
+		rule.GraceMinutes = *session.GraceMinutes
+	}
+	if session.LateCutoffMinutes != nil {
+		rule.LateCutoffMinutes = *session.LateCutoffMinutes
+	}
+	if session.AbsentCutoffMinutes != nil {
+		rule.AbsentCutoffMinutes = *session.AbsentCutoffMinutes
+	}
+
+	return rule
+}
+
+// Helper: Shared sanity check for grace/late/absent minutes
+func validateTimingMinutes(grace, late, absent int) error {
+	if grace < 0 || late < 0 || absent < 0 {
+		return fmt.Errorf("timing values cannot be negative")
+	}
+	if late < grace {
+		return fmt.Errorf("late cutoff must be at least the grace period")
+	}
+	if absent != 0 && absent < late {
+		return fmt.Errorf("absent cutoff must be at least the late cutoff")
+	}
+	return nil
+}
+
+// TimingRuleRequest represents the body for updating a subject's timing rule
+type TimingRuleRequest struct {
+	StartTime           *string `json:"start_time"` // "HH:MM" WIB, null = time the session is opened
+	GraceMinutes        int     `json:"grace_minutes" validate:"min=0,max=240"`
+	LateCutoffMinutes   int     `json:"late_cutoff_minutes" validate:"min=0,max=480"`
+	AbsentCutoffMinutes int     `json:"absent_cutoff_minutes" validate:"min=0,max=600"`
+}
+
+// GetSubjectTiming returns the timing rule of a subject (defaults if none saved)
+// GET /api/attendance/subjects/:subjectId/timing
+func (h *AttendanceHandler) GetSubjectTiming(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("subjectId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid subject ID",
+		})
+	}
+
+	rule := models.SubjectTimingRule{
+		SubjectID:         subjectID,
+		GraceMinutes:      DefaultGraceMinutes,
+		LateCutoffMinutes: DefaultLateCutoffMinutes,
+	}
+	h.DB.Where("subject_id = ?", subjectID).Limit(1).Find(&rule)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// UpdateSubjectTiming creates or replaces the timing rule of a subject
+// PUT /api/attendance/subjects/:subjectId/timing
+func (h *AttendanceHandler) UpdateSubjectTiming(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	subjectID, err := uuid.Parse(c.Params("subjectId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid subject ID",
+		})
+	}
+
+	var req TimingRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Validasi Gagal: " + err.Error(),
+		})
+	}
+	if req.StartTime != nil && !clockPattern.MatchString(*req.StartTime) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "start_time must use HH:MM format",
+		})
+	}
+	if err := validateTimingMinutes(req.GraceMinutes, req.LateCutoffMinutes, req.AbsentCutoffMinutes); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Subject not found",
+		})
+	}
+
+	if !teachesSubject(h.DB, user, subjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "You are not assigned to teach this subject",
+		})
+	}
+
+	rule := models.SubjectTimingRule{SubjectID: subjectID}
+	updatedBy := user.UserID
+	if err := h.DB.Where("subject_id = ?", subjectID).
+		Assign(models.SubjectTimingRule{
+			StartTime:           req.StartTime,
+			GraceMinutes:        req.GraceMinutes,
+			LateCutoffMinutes:   req.LateCutoffMinutes,
+			AbsentCutoffMinutes: req.AbsentCutoffMinutes,
+			UpdatedBy:           &updatedBy,
+		}).
+		FirstOrCreate(&rule).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Failed to save timing rule",
+		})
+	}
+
+	// Assign skips zero values, so write them explicitly
+	h.DB.Model(&rule).Updates(map[string]interface{}{
+		"start_time":            req.StartTime,
+		"grace_minutes":         req.GraceMinutes,
+		"late_cutoff_minutes":   req.LateCutoffMinutes,
+		"absent_cutoff_minutes": req.AbsentCutoffMinutes,
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+		"message": "Timing rule saved for " + subject.Name,
+	})
+}
+
+// LatenessSummary is the per-student breakdown used by stats and exports
+type LatenessSummary struct {
+	StudentID        uuid.UUID `json:"student_id"`
+	NIM              string    `json:"nim"`
+	FullName         string    `json:"full_name"`
+	Hadir            int       `json:"hadir"`
+	Terlambat        int       `json:"terlambat"`
+	Alpa             int       `json:"alpa"`
+	Izin             int       `json:"izin"`
+	Sakit            int       `json:"sakit"`
+	TotalMinutesLate int       `json:"total_minutes_late"`
+	AttendanceRate   float64   `json:"attendance_rate"` // (hadir + terlambat) / meetings
+	OnTimeRate       float64   `json:"on_time_rate"`    // hadir / meetings
+}
+
+// Helper: Rank of a status when a meeting has several records for one student
+func recordWeight(status string) int {
+	switch models.NormalizeAttendanceStatus(status) {
+	case models.AttendanceHadir, models.AttendanceTerlambat:
+		return 3
+	case models.AttendanceIzin, models.AttendanceSakit:
+		return 2
+	case models.AttendanceAlpa:
+		return 1
+	}
+	return 0
+}
+
+// Helper: One record per student and meeting of a section, keyed by meeting ID. A meeting
+// whose QR was regenerated can hold records in several sessions; attendance beats
+// izin/sakit beats alpa, and the later record wins a tie.
+func meetingRecords(db *gorm.DB, subjectID, classID uuid.UUID) map[uuid.UUID]map[uuid.UUID]models.AttendanceRecord {
+	var rows []struct {
+		models.AttendanceRecord
+		MeetingID uuid.UUID
+	}
+	db.Table("attendance_records").
+		Select("attendance_records.*, attendance_sessions.meeting_id").
+		Joins("JOIN attendance_sessions ON attendance_sessions.id = attendance_records.session_id").
+		Joins("JOIN meetings ON meetings.id = attendance_sessions.meeting_id").
+		Where("meetings.subject_id = ? AND attendance_sessions.class_id = ?", subjectID, classID).
+		Scan(&rows)
+
+	byMeeting := make(map[uuid.UUID]map[uuid.UUID]models.AttendanceRecord)
+	for _, r := range rows {
+		best, ok := byMeeting[r.MeetingID]
+		if !ok {
+			best = make(map[uuid.UUID]models.AttendanceRecord)
+			byMeeting[r.MeetingID] = best
+		}
+		prev, ok := best[r.StudentID]
+		if !ok || recordWeight(r.Status) > recordWeight(prev.Status) ||
+			(recordWeight(r.Status) == recordWeight(prev.Status) && r.ScannedAt.After(prev.ScannedAt)) {
+			best[r.StudentID] = r.AttendanceRecord
+		}
+	}
+	return byMeeting
+}
+
+// Helper: Build lateness summaries for every student on a section's roster across a subject's meetings
+func (h *AttendanceHandler) buildLatenessSummary(subjectID, classID uuid.UUID) ([]LatenessSummary, int) {
+	var students []models.Profile
+	termID, _ := enrollment.ActiveTermID(h.DB)
+	enrollment.Roster(h.DB, termID, subjectID, classID).Order("nim ASC").Find(&students)
+
+	var meetingCount int64
+	h.DB.Model(&models.Meeting{}).Where("subject_id = ?", subjectID).Count(&meetingCount)
+
+	var records []models.AttendanceRecord
+	for _, byStudent := range meetingRecords(h.DB, subjectID, classID) {
+		for _, r := range byStudent {
+			records = append(records, r)
+		}
+	}
+
+	byStudent := make(map[uuid.UUID]*LatenessSummary, len(students))
+	summaries := make([]LatenessSummary, len(students))
+	for i, s := range students {
+		summaries[i] = LatenessSummary{StudentID: s.UserID, NIM: s.NIM, FullName: s.FullName}
+		byStudent[s.UserID] = &summaries[i]
+	}
+
+	for _, r := range records {
+		sum, ok := byStudent[r.StudentID]
+		if !ok {
+			continue
+		}
+		switch models.NormalizeAttendanceStatus(r.Status) {
+		case models.AttendanceHadir:
+			sum.Hadir++
+		case models.AttendanceTerlambat:
+			sum.Terlambat++
+			sum.TotalMinutesLate += r.MinutesLate
+		case models.AttendanceIzin:
+			sum.Izin++
+		case models.AttendanceSakit:
+			sum.Sakit++
+		case models.AttendanceAlpa:
+			sum.Alpa++
+		}
+	}
+
+	if meetingCount > 0 {
+		for i := range summaries {
+			summaries[i].AttendanceRate = float64(summaries[i].Hadir+summaries[i].Terlambat) / float64(meetingCount)
+			summaries[i].OnTimeRate = float64(summaries[i].Hadir) / float64(meetingCount)
+		}
+	}
+
+	return summaries, int(meetingCount)
+}
+
+// GetAttendanceStats returns per-student attendance with lateness reported separately
+// GET /api/attendance/stats?subject_id=...&class_id=...
+func (h *AttendanceHandler) GetAttendanceStats(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	subjectID, err := uuid.Parse(c.Query("subject_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid subject_id required"})
+	}
+	classID, err := uuid.Parse(c.Query("class_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid class_id required"})
+	}
+
+	if user.Role == models.RoleAdminDosen && !isAssigned(h.DB, user, subjectID, classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "You are not assigned to teach this subject for this class"})
+	}
+
+	// Students and class admins only see their own class
+	if (user.Role == models.RoleMahasiswa || user.Role == models.RoleAdminKelas) &&
+		(user.ClassID == nil || *user.ClassID != classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "You can only view your own class"})
+	}
+
+	summaries, meetingCount := h.buildLatenessSummary(subjectID, classID)
+
+	// Mahasiswa only get their own row
+	if user.Role == models.RoleMahasiswa {
+		own := make([]LatenessSummary, 0, 1)
+		for _, s := range summaries {
+			if s.StudentID == user.UserID {
+				own = append(own, s)
+			}
+		}
+		summaries = own
+	}
+
+	totals := fiber.Map{"hadir": 0, "terlambat": 0, "alpa": 0, "izin": 0, "sakit": 0, "total_minutes_late": 0}
+	for _, s := range summaries {
+		totals["hadir"] = totals["hadir"].(int) + s.Hadir
+		totals["terlambat"] = totals["terlambat"].(int) + s.Terlambat
+		totals["alpa"] = totals["alpa"].(int) + s.Alpa
+		totals["izin"] = totals["izin"].(int) + s.Izin
+		totals["sakit"] = totals["sakit"].(int) + s.Sakit
+		totals["total_minutes_late"] = totals["total_minutes_late"].(int) + s.TotalMinutesLate
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    summaries,
+		"meta": fiber.Map{
+			"meetings": meetingCount,
+			"totals":   totals,
+		},
+	})
+}
+
+// Helper: Student-facing confirmation for each scan outcome
+func scanMessage(status string, minutesLate int) string {
+	switch status {
+	case models.AttendanceTerlambat:
+		return fmt.Sprintf("Attendance recorded, but you are %d minutes late.", minutesLate)
+	case models.AttendanceAlpa:
+		return fmt.Sprintf("Scan received %d minutes late, past the late cutoff. You are recorded as absent.", minutesLate)
+	}
+	return "Attendance recorded successfully!"
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ScanChallenge derives the WebAuthn challenge for one student and one QR code.
+// It is deterministic so any server instance can verify the assertion without shared
+// session storage, and it changes whenever the lecturer refreshes the QR.
+func ScanChallenge(sessionID, userID uuid.UUID, qrCode string) []byte {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("SUPABASE_JWT_SECRET")))
+	mac.Write([]byte("attendance-scan|" + sessionID.String() + "|" + userID.String() + "|" + qrCode))
+	return mac.Sum(nil)
+}
+
+// resolveRPID derives the RPID from the browser Origin, same rules as login/register
+func (h *WebAuthnHandler) resolveRPID(origin string) string {
+	rpID := h.WebAuthn.Config.RPID
+	if origin == "" {
+		return rpID
+	}
+	if strings.Contains(origin, "localhost") || strings.Contains(origin, "127.0.0.1") {
+		return "localhost"
+	}
+	for _, p := range []string{"https://", "http://"} {
+		if strings.HasPrefix(origin, p) {
+			rpID = origin[len(p):]
+			break
+		}
+	}
+	if idx := strings.Index(rpID, ":"); idx != -1 {
+		rpID = rpID[:idx]
+	}
+	return strings.TrimSuffix(rpID, "/")
+}
+
+// scanWebAuthn builds a WebAuthn instance pinned to the caller's origin
+func (h *WebAuthnHandler) scanWebAuthn(origin string) (*webauthn.WebAuthn, error) {
+	waConfig := *h.WebAuthn.Config
+	waConfig.RPID = h.resolveRPID(origin)
+	if origin != "" {
+		waConfig.RPOrigins = []string{origin}
+	}
+	waConfig.AuthenticatorSelection.UserVerification = protocol.VerificationRequired
+	return webauthn.New(&waConfig)
+}
+
+// BeginScan returns assertion options whose challenge is bound to the scanned QR code
+// POST /api/attendance/scan/biometric/begin
+func (h *WebAuthnHandler) BeginScan(c *fiber.Ctx) error {
+	userCtx, ok := c.Locals("user").(middleware.UserContext)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User context missing"})
+	}
+
+	var body struct {
+		QRToken string `json:"qr_token"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.QRToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "QR token wajib diisi"})
+	}
+
+	var session models.AttendanceSession
+	if err := h.DB.Where("qr_code = ? AND is_active = true AND expires_at > ?", body.QRToken, time.Now()).
+		First(&session).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "QR Code expired atau tidak ditemukan"})
+	}
+
+	var profile models.Profile
+	if err := h.DB.Where("user_id = ?", userCtx.UserID).First(&profile).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Profil pengguna tidak ditemukan"})
+	}
+
+	var credentials []models.WebAuthnCredential
+	h.DB.Where("user_id = ?", userCtx.UserID).Find(&credentials)
+	if len(credentials) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Sesi ini wajib verifikasi biometrik. Daftarkan sidik jari/wajah dulu di Profile.",
+		})
+	}
+
+	origin := c.Get("Origin")
+	tempWebAuthn, err := h.scanWebAuthn(origin)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal inisialisasi WebAuthn: " + err.Error()})
+	}
+
+	waUser := models.WebAuthnUser{Profile: profile, Credentials: credentials}
+	options, _, err := tempWebAuthn.BeginLogin(waUser,
+		webauthn.WithChallenge(ScanChallenge(session.ID, profile.UserID, session.QRCode)),
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		fmt.Printf("❌ WebAuthn BeginScan Error: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal menyiapkan verifikasi biometrik: " + err.Error()})
+	}
+
+	return c.JSON(options)
+}
+
+// VerifyScanAssertion checks that the assertion was signed by the student's registered
+// credential over the challenge of the session's current QR code
+func (h *WebAuthnHandler) VerifyScanAssertion(origin string, profile models.Profile, session models.AttendanceSession, assertion []byte) error {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(assertion)
+	if err != nil {
+		return fmt.Errorf("format assertion tidak valid: %v", err)
+	}
+
+	var credentials []models.WebAuthnCredential
+	h.DB.Where("user_id = ?", profile.UserID).Find(&credentials)
+	if len(credentials) == 0 {
+		return fmt.Errorf("belum ada biometrik terdaftar")
+	}
+
+	tempWebAuthn, err := h.scanWebAuthn(origin)
+	if err != nil {
+		return err
+	}
+
+	waUser := models.WebAuthnUser{Profile: profile, Credentials: credentials}
+	allowed := make([][]byte, len(credentials))
+	for i, cred := range credentials {
+		allowed[i] = cred.CredentialID
+	}
+
+	sessionData := webauthn.SessionData{
+		Challenge:            protocol.URLEncodedBase64(ScanChallenge(session.ID, profile.UserID, session.QRCode)).String(),
+		RelyingPartyID:       tempWebAuthn.Config.RPID,
+		UserID:               waUser.WebAuthnID(),
+		AllowedCredentialIDs: allowed,
+		UserVerification:     protocol.VerificationRequired,
+	}
+
+	credential, err := tempWebAuthn.ValidateLogin(waUser, sessionData, parsed)
+	if err != nil {
+		return err
+	}
+
+	// Update sign count in DB (clone detection on the next assertion)
+	h.DB.Model(&models.WebAuthnCredential{}).
+		Where("credential_id = ?", credential.ID).
+		Update("sign_count", credential.Authenticator.SignCount)
+
+	return nil
+}
@@ -0,0 +1,508 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/dues"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarHandler serves per-user iCalendar subscription feeds
+type CalendarHandler struct {
+	DB *gorm.DB
+}
+
+// NewCalendarHandler creates a new calendar handler
+func NewCalendarHandler(db *gorm.DB) *CalendarHandler {
+	return &CalendarHandler{DB: db}
+}
+
+// icsUIDDomain suffixes every UID so events stay stable across feed refreshes
+const icsUIDDomain = "@portalmahasiswaptik"
+
+// icsEvent is one VEVENT. Timed events are written in Asia/Jakarta; AllDay events use DATE values.
+type icsEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time // overrides one occurrence of the recurring event with the same UID
+}
+
+var icsDays = map[int]string{1: "MO", 2: "TU", 3: "WE", 4: "TH", 5: "FR", 6: "SA", 7: "SU"}
+
+// Helper: Escape TEXT values (RFC 5545 §3.3.11)
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// Helper: Fold content lines longer than 75 octets without splitting UTF-8 sequences;
+// continuation lines carry a leading space, so they hold at most 74 octets of content
+func icsFold(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// Helper: DTSTART/DTEND style property for a local (WIB) time or a date
+func icsTime(name string, t time.Time, allDay bool) string {
+	if allDay {
+		return name + ";VALUE=DATE:" + t.Format("20060102")
+	}
+	return name + ";TZID=Asia/Jakarta:" + t.In(WIB).Format("20060102T150405")
+}
+
+// writeICS renders a complete VCALENDAR
+func writeICS(name string, events []icsEvent) string {
+	var b strings.Builder
+	line := func(s string) { icsFold(&b, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Portal Mahasiswa PTIK//Kalender Akademik//ID")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsEscape(name))
+	line("X-WR-TIMEZONE:Asia/Jakarta")
+	line("REFRESH-INTERVAL;VALUE=DURATION:PT6H")
+	line("X-PUBLISHED-TTL:PT6H")
+
+	// WIB has no daylight saving, one STANDARD block is enough
+	line("BEGIN:VTIMEZONE")
+	line("TZID:Asia/Jakarta")
+	line("BEGIN:STANDARD")
+	line("DTSTART:19700101T000000")
+	line("TZOFFSETFROM:+0700")
+	line("TZOFFSETTO:+0700")
+	line("TZNAME:WIB")
+	line("END:STANDARD")
+	line("END:VTIMEZONE")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID + icsUIDDomain)
+		line("DTSTAMP:" + stamp)
+		if e.RecurrenceID != nil {
+			line(icsTime("RECURRENCE-ID", *e.RecurrenceID, e.AllDay))
+		}
+		line(icsTime("DTSTART", e.Start, e.AllDay))
+		line(icsTime("DTEND", e.End, e.AllDay))
+		if e.RRule != "" {
+			line("RRULE:" + e.RRule)
+		}
+		for _, ex := range e.ExDates {
+			line(icsTime("EXDATE", ex, e.AllDay))
+		}
+		line("SUMMARY:" + icsEscape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + icsEscape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + icsEscape(e.Location))
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// calendarEvents collects every source for the user's feed
+func (h *CalendarHandler) calendarEvents(profile models.Profile) []icsEvent {
+	events := h.timetableEvents(profile)
+	events = append(events, h.duesEvents(profile)...)
+	events = append(events, h.examEvents(profile)...)
+	events = append(events, h.competitionEvents(profile)...)
+	return events
+}
+
+// Helper: Holidays keyed by date ("2006-01-02")
+func (h *CalendarHandler) holidaysByDate() map[string]models.Holiday {
+	var holidays []models.Holiday
+	h.DB.Find(&holidays)
+	byDate := make(map[string]models.Holiday, len(holidays))
+	for _, hol := range holidays {
+		byDate[hol.Date.Format("2006-01-02")] = hol
+	}
+	return byDate
+}
+
+// calendarFallbackWeeks bounds slots that have neither an end date nor a term to end with
+// (14 meetings plus the UTS and UAS weeks)
+const calendarFallbackWeeks = 16
+
+// timetableEvents turns each schedule slot into a weekly recurring event bounded by its
+// validity window, or by its term's end date when it has none. Holidays become EXDATEs and
+// dated meetings rename their occurrence.
+func (h *CalendarHandler) timetableEvents(profile models.Profile) []icsEvent {
+	query := h.DB.Preload("Subject").Preload("Class")
+	switch {
+	case profile.Role == models.RoleAdminDosen:
+		query = query.Where("lecturer_id = ?", profile.UserID)
+	case profile.ClassID != nil:
+		query = query.Where("class_id = ?", *profile.ClassID)
+	default:
+		return nil
+	}
+	var slots []models.ScheduleSlot
+	query.Order("day ASC, start_time ASC").Find(&slots)
+	if len(slots) == 0 {
+		return nil
+	}
+
+	holidays := h.holidaysByDate()
+
+	var termList []models.AcademicTerm
+	h.DB.Find(&termList)
+	terms := make(map[uuid.UUID]models.AcademicTerm, len(termList))
+	var active *models.AcademicTerm
+	for i, t := range termList {
+		terms[t.ID] = t
+		if t.IsActive {
+			active = &termList[i]
+		}
+	}
+
+	subjectIDs := make([]uuid.UUID, 0, len(slots))
+	for _, s := range slots {
+		subjectIDs = append(subjectIDs, s.SubjectID)
+	}
+	var meetings []models.Meeting
+	h.DB.Where("subject_id IN ? AND date IS NOT NULL", subjectIDs).Order("meeting_number ASC").Find(&meetings)
+
+	var events []icsEvent
+	for _, slot := range slots {
+		// First occurrence: the slot's weekday on or after valid_from (or the week it was created)
+		anchor := slot.CreatedAt.In(WIB)
+		if slot.ValidFrom != nil {
+			anchor = *slot.ValidFrom
+		}
+		anchor = time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, WIB)
+		if slot.ValidFrom == nil {
+			anchor = anchor.AddDate(0, 0, -(isoWeekday(anchor) - 1)) // Monday of that week
+		}
+		for isoWeekday(anchor) != slot.Day {
+			anchor = anchor.AddDate(0, 0, 1)
+		}
+		start, errStart := clockOnDate(slot.StartTime, anchor)
+		end, errEnd := clockOnDate(slot.EndTime, anchor)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+
+		subjectName, className := "-", "-"
+		if slot.Subject != nil {
+			subjectName = slot.Subject.Name
+		}
+		if slot.Class != nil {
+			className = slot.Class.Name
+		}
+
+		event := icsEvent{
+			UID:         "slot-" + slot.ID.String(),
+			Summary:     subjectName,
+			Description: fmt.Sprintf("Kelas %s, semester %d", className, slot.Semester),
+			Location:    slot.Room,
+			Start:       start,
+			End:         end,
+			RRule:       "FREQ=WEEKLY;BYDAY=" + icsDays[slot.Day],
+		}
+		// Open-ended slots stop with their term (or the active one), never run forever
+		last := anchor.AddDate(0, 0, 7*calendarFallbackWeeks-1)
+		switch {
+		case slot.ValidUntil != nil:
+			last = *slot.ValidUntil
+		case slot.TermID != nil && terms[*slot.TermID].ID != uuid.Nil:
+			last = terms[*slot.TermID].EndDate
+		case active != nil:
+			last = active.EndDate
+		}
+		until := time.Date(last.Year(), last.Month(), last.Day(), 23, 59, 59, 0, WIB)
+		event.RRule += ";UNTIL=" + until.UTC().Format("20060102T150405Z")
+		inRange := func(day time.Time) bool {
+			d := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, WIB)
+			return isoWeekday(d) == slot.Day && !d.Before(anchor) && !d.After(until)
+		}
+
+		for _, hol := range holidays {
+			if inRange(hol.Date) {
+				exdate, _ := clockOnDate(slot.StartTime, time.Date(hol.Date.Year(), hol.Date.Month(), hol.Date.Day(), 12, 0, 0, 0, WIB))
+				event.ExDates = append(event.ExDates, exdate)
+			}
+		}
+		sort.Slice(event.ExDates, func(i, j int) bool { return event.ExDates[i].Before(event.ExDates[j]) })
+		events = append(events, event)
+
+		// Dated meetings on this slot's weekday rename that occurrence
+		for _, m := range meetings {
+			if m.SubjectID != slot.SubjectID || m.Date == nil || !inRange(*m.Date) {
+				continue
+			}
+			if _, holiday := holidays[m.Date.Format("2006-01-02")]; holiday {
+				continue
+			}
+			day := time.Date(m.Date.Year(), m.Date.Month(), m.Date.Day(), 12, 0, 0, 0, WIB)
+			occStart, _ := clockOnDate(slot.StartTime, day)
+			occEnd, _ := clockOnDate(slot.EndTime, day)
+
+			summary := fmt.Sprintf("%s - Pertemuan %d", subjectName, m.MeetingNumber)
+			if m.Topic != nil && *m.Topic != "" {
+				summary += ": " + *m.Topic
+			}
+			events = append(events, icsEvent{
+				UID:          event.UID,
+				Summary:      summary,
+				Description:  event.Description,
+				Location:     slot.Room,
+				Start:        occStart,
+				End:          occEnd,
+				RecurrenceID: &occStart,
+			})
+		}
+	}
+	return events
+}
+
+// duesEvents lists the deadlines of the student's billing weeks this year (the configured
+// billing range) that are not paid yet. Amounts and statuses come from the student's
+// weekly_dues rows; weeks without a row are unpaid at dues.WeeklyAmount.
+func (h *CalendarHandler) duesEvents(profile models.Profile) []icsEvent {
+	if profile.ClassID == nil || profile.Role == models.RoleAdminDosen || profile.Role == models.RoleAdminDev {
+		return nil
+	}
+
+	weeks := dues.BillingWeeks(h.DB, time.Now())
+	if len(weeks) == 0 {
+		return nil
+	}
+
+	var rows []models.WeeklyDue
+	first, last := weeks[0], weeks[len(weeks)-1]
+	h.DB.Where("student_id = ? AND year * 100 + month BETWEEN ? AND ?",
+		profile.UserID, first.Year*100+first.Month, last.Year*100+last.Month).
+		Find(&rows)
+	byWeek := make(map[string]models.WeeklyDue, len(rows))
+	for _, d := range rows {
+		byWeek[fmt.Sprintf("%d-%d-%d", d.Year, d.Month, d.WeekNumber)] = d
+	}
+
+	var events []icsEvent
+	for _, w := range weeks {
+		amount, status := float64(dues.WeeklyAmount), "unpaid"
+		if row, ok := byWeek[fmt.Sprintf("%d-%d-%d", w.Year, w.Month, w.Number)]; ok {
+			amount, status = row.Amount, row.Status
+		}
+		if status == "paid" || status == "lunas" {
+			continue
+		}
+
+		events = append(events, icsEvent{
+			UID:         fmt.Sprintf("dues-%s-%d-%02d-w%d", profile.UserID, w.Year, w.Month, w.Number),
+			Summary:     fmt.Sprintf("Batas Iuran Kas Minggu %d (%02d/%d)", w.Number, w.Month, w.Year),
+			Description: fmt.Sprintf("Iuran %s, status: %s", dues.Rupiah(amount), status),
+			Start:       w.DueDate,
+			End:         w.DueDate.AddDate(0, 0, 1),
+			AllDay:      true,
+		})
+	}
+	return events
+}
+
+// examEvents lists the UTS/UAS exams of the current terms. Students get their room and seat
+// once seating is generated; lecturers get the subjects they teach and rooms they invigilate.
+func (h *CalendarHandler) examEvents(profile models.Profile) []icsEvent {
+	if profile.Role == models.RoleAdminDev {
+		return nil
+	}
+
+	var exams []models.Exam
+	examsQuery(h.DB, profile.UserID, profile.Role, profile.ClassID).
+		Preload("Subject").Preload("Rooms").
+		Where("term_id IS NULL OR term_id IN (?)", h.DB.Model(&models.AcademicTerm{}).Select("id").Where("archived_at IS NULL")).
+		Find(&exams)
+	if len(exams) == 0 {
+		return nil
+	}
+
+	var seats []models.ExamSeat
+	h.DB.Preload("Room").Where("student_id = ?", profile.UserID).Find(&seats)
+	seatByExam := make(map[uuid.UUID]models.ExamSeat, len(seats))
+	for _, s := range seats {
+		seatByExam[s.ExamID] = s
+	}
+
+	events := make([]icsEvent, 0, len(exams))
+	for _, e := range exams {
+		subject := "Ujian"
+		if e.Subject != nil {
+			subject = e.Subject.Name
+		}
+		ev := icsEvent{
+			UID:         fmt.Sprintf("exam-%s", e.ID),
+			Summary:     fmt.Sprintf("%s %s", strings.ToUpper(e.Type), subject),
+			Description: e.Notes,
+			Start:       e.StartsAt.In(WIB),
+			End:         e.EndsAt.In(WIB),
+		}
+		rooms := make([]string, 0, len(e.Rooms))
+		for _, r := range e.Rooms {
+			if profile.Role == models.RoleAdminDosen && r.InvigilatorID != nil && *r.InvigilatorID == profile.UserID {
+				rooms = []string{r.Room}
+				ev.Description = strings.TrimSpace("Pengawas ruangan " + r.Room + ". " + e.Notes)
+				break
+			}
+			rooms = append(rooms, r.Room)
+		}
+		ev.Location = strings.Join(rooms, ", ")
+		if seat, ok := seatByExam[e.ID]; ok && seat.Room != nil {
+			ev.Location = seat.Room.Room
+			ev.Description = strings.TrimSpace(fmt.Sprintf("Kursi %d. %s", seat.SeatNumber, e.Notes))
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// competitionEvents lists registration deadlines of competitions the user bookmarked or
+// joined a team for, from last month on
+func (h *CalendarHandler) competitionEvents(profile models.Profile) []icsEvent {
+	var comps []models.Competition
+	h.DB.Where("deadline >= ?", time.Now().In(WIB).AddDate(0, -1, 0).Format("2006-01-02")).
+		Where("id IN (?) OR id IN (?)",
+			h.DB.Model(&models.CompetitionBookmark{}).Select("competition_id").Where("user_id = ?", profile.UserID),
+			h.DB.Model(&models.CompetitionTeamMember{}).Select("competition_id").Where("student_id = ?", profile.UserID)).
+		Find(&comps)
+
+	events := make([]icsEvent, 0, len(comps))
+	for _, comp := range comps {
+		description := comp.Organizer
+		if comp.LinkURL != nil {
+			description += "\n" + *comp.LinkURL
+		}
+		events = append(events, icsEvent{
+			UID:         fmt.Sprintf("competition-%s", comp.ID),
+			Summary:     "Batas Pendaftaran: " + comp.Title,
+			Description: description,
+			Start:       comp.Deadline,
+			End:         comp.Deadline.AddDate(0, 0, 1),
+			AllDay:      true,
+		})
+	}
+	return events
+}
+
+// Helper: Subscription URLs for a token
+func calendarURLs(c *fiber.Ctx, token string) fiber.Map {
+	url := c.BaseURL() + "/api/calendar/feed/" + token + ".ics"
+	return fiber.Map{
+		"url":        url,
+		"webcal_url": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"),
+	}
+}
+
+// GetSubscription returns the caller's ICS subscription URL, creating the token on first use
+// GET /api/calendar/subscription
+func (h *CalendarHandler) GetSubscription(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var token models.CalendarToken
+	if err := h.DB.Where("user_id = ?", user.UserID).First(&token).Error; err != nil {
+		token = models.CalendarToken{UserID: user.UserID, Token: generateQRToken() + generateQRToken()}
+		if err := h.DB.Create(&token).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat tautan kalender"})
+		}
+	}
+
+	data := calendarURLs(c, token.Token)
+	data["created_at"] = token.CreatedAt
+	data["last_used_at"] = token.LastUsedAt
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// RotateSubscription replaces the token; calendars using the old URL stop updating
+// POST /api/calendar/subscription/rotate
+func (h *CalendarHandler) RotateSubscription(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	token := models.CalendarToken{UserID: user.UserID, Token: generateQRToken() + generateQRToken()}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.UserID).Delete(&models.CalendarToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui tautan kalender"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    calendarURLs(c, token.Token),
+		"message": "Tautan kalender lama sudah tidak berlaku",
+	})
+}
+
+// RevokeSubscription deletes the token without issuing a new one
+// DELETE /api/calendar/subscription
+func (h *CalendarHandler) RevokeSubscription(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	if err := h.DB.Where("user_id = ?", user.UserID).Delete(&models.CalendarToken{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mencabut tautan kalender"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Langganan kalender dicabut",
+	})
+}
+
+// GetFeed serves the ICS feed. Public: the token in the URL is the credential.
+// GET /api/calendar/feed/:token.ics
+func (h *CalendarHandler) GetFeed(c *fiber.Ctx) error {
+	secret := strings.TrimSuffix(c.Params("token"), ".ics")
+	if secret == "" {
+		return c.Status(fiber.StatusNotFound).SendString("Not found")
+	}
+
+	var token models.CalendarToken
+	if err := h.DB.Where("token = ?", secret).First(&token).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Calendar not found")
+	}
+
+	var profile models.Profile
+	if err := h.DB.Where("user_id = ?", token.UserID).First(&profile).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Calendar not found")
+	}
+
+	h.DB.Model(&token).Update("last_used_at", time.Now())
+
+	c.Set("Content-Type", "text/calendar; charset=utf-8")
+	c.Set("Content-Disposition", "inline; filename=portal-ptik.ics")
+	c.Set("Cache-Control", "private, max-age=900")
+	return c.SendString(writeICS("Portal PTIK - "+profile.FullName, h.calendarEvents(profile)))
+}
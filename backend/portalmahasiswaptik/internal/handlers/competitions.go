@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CompetitionHandler manages the competitions board, bookmarks and team registration
+type CompetitionHandler struct {
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Notify   *notify.Service
+}
+
+// NewCompetitionHandler creates a new competition handler
+func NewCompetitionHandler(db *gorm.DB, validate *validator.Validate, notifier *notify.Service) *CompetitionHandler {
+	return &CompetitionHandler{DB: db, Validate: validate, Notify: notifier}
+}
+
+// CompetitionRequest represents create/update competition payload
+type CompetitionRequest struct {
+	Title       string  `json:"title" validate:"required,max=200"`
+	Organizer   string  `json:"organizer" validate:"required,max=200"`
+	Deadline    string  `json:"deadline" validate:"required"` // YYYY-MM-DD, last registration day
+	EventDates  *string `json:"event_dates"`
+	TeamSize    *string `json:"team_size" validate:"omitempty,max=50"`
+	Prize       *string `json:"prize"`
+	LinkURL     *string `json:"link_url" validate:"omitempty,url"`
+	Category    string  `json:"category" validate:"required"`
+	Badge       *string `json:"badge"`
+	Description *string `json:"description" validate:"omitempty,max=5000"`
+	Location    *string `json:"location"`
+}
+
+// TeamRequest registers a team; the caller is the leader and is added automatically
+type TeamRequest struct {
+	Name       string   `json:"name" validate:"required,max=100"`
+	MemberNIMs []string `json:"member_nims" validate:"max=20,dive,required"`
+}
+
+// Helper: Today's date in WIB as a date-only value, comparable with Competition.Deadline
+func competitionToday() time.Time {
+	now := time.Now().In(WIB)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Helper: Whether registration is still open (the deadline day itself included)
+func registrationOpen(c models.Competition) bool {
+	return !c.Deadline.Before(competitionToday())
+}
+
+// Helper: Optional text fields are stored as NULL when blank
+func blankToNil(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
+}
+
+// Helper: Validate the payload and copy it onto the competition
+func (h *CompetitionHandler) applyRequest(c *fiber.Ctx, comp *models.Competition) error {
+	var req CompetitionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.New("Invalid request body")
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return errors.New("Validasi Gagal: " + err.Error())
+	}
+
+	valid := false
+	for _, category := range models.CompetitionCategories {
+		if req.Category == category {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("Kategori harus salah satu dari: %s", strings.Join(models.CompetitionCategories, ", "))
+	}
+	deadline, err := time.Parse("2006-01-02", req.Deadline)
+	if err != nil {
+		return errors.New("deadline harus berformat YYYY-MM-DD")
+	}
+
+	comp.Title = strings.TrimSpace(req.Title)
+	comp.Organizer = strings.TrimSpace(req.Organizer)
+	comp.Deadline = deadline
+	comp.EventDates = blankToNil(req.EventDates)
+	comp.TeamSize = blankToNil(req.TeamSize)
+	comp.Prize = blankToNil(req.Prize)
+	comp.LinkURL = blankToNil(req.LinkURL)
+	comp.Category = req.Category
+	comp.Badge = blankToNil(req.Badge)
+	if comp.Badge != nil && *comp.Badge == "None" {
+		comp.Badge = nil
+	}
+	comp.Description = blankToNil(req.Description)
+	comp.Location = blankToNil(req.Location)
+	return nil
+}
+
+// Helper: Mark the competitions the user bookmarked or registered a team for
+func (h *CompetitionHandler) markViewer(userID uuid.UUID, comps []models.Competition) {
+	if len(comps) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, len(comps))
+	for i, comp := range comps {
+		ids[i] = comp.ID
+	}
+
+	var bookmarked, registered []uuid.UUID
+	h.DB.Model(&models.CompetitionBookmark{}).Where("user_id = ? AND competition_id IN ?", userID, ids).Pluck("competition_id", &bookmarked)
+	h.DB.Model(&models.CompetitionTeamMember{}).Where("student_id = ? AND competition_id IN ?", userID, ids).Pluck("competition_id", &registered)
+	isBookmarked := make(map[uuid.UUID]bool, len(bookmarked))
+	for _, id := range bookmarked {
+		isBookmarked[id] = true
+	}
+	isRegistered := make(map[uuid.UUID]bool, len(registered))
+	for _, id := range registered {
+		isRegistered[id] = true
+	}
+	for i := range comps {
+		comps[i].Bookmarked = isBookmarked[comps[i].ID]
+		comps[i].Registered = isRegistered[comps[i].ID]
+	}
+}
+
+// Helper: Load a competition by the :id param
+func (h *CompetitionHandler) findCompetition(c *fiber.Ctx) (*models.Competition, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var comp models.Competition
+	if err := h.DB.Where("id = ?", id).First(&comp).Error; err != nil {
+		return nil, err
+	}
+	return &comp, nil
+}
+
+// Helper: Admins manage every competition; admin kelas and lecturers only the ones they posted
+func canManageCompetition(user middleware.UserContext, comp *models.Competition) bool {
+	return user.Role == models.RoleAdminDev || (comp.CreatedBy != nil && *comp.CreatedBy == user.UserID)
+}
+
+// GetCompetitions lists competitions, soonest open deadline first.
+// Filters: ?category=, ?q=, ?upcoming=true (registration still open), ?within=<days>
+// (deadline in the next N days) and ?bookmarked=true.
+// GET /api/competitions
+func (h *CompetitionHandler) GetCompetitions(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	today := competitionToday()
+
+	query := h.DB.Model(&models.Competition{})
+	if category := c.Query("category"); category != "" && category != "Semua" {
+		query = query.Where("category = ?", category)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("title ILIKE ? OR organizer ILIKE ?", like, like)
+	}
+	if c.Query("upcoming") == "true" {
+		query = query.Where("deadline >= ?", today)
+	}
+	if raw := c.Query("within"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "within must be a number of days"})
+		}
+		query = query.Where("deadline BETWEEN ? AND ?", today, today.AddDate(0, 0, days))
+	}
+	if c.Query("bookmarked") == "true" {
+		query = query.Where("id IN (?)", h.DB.Model(&models.CompetitionBookmark{}).Select("competition_id").Where("user_id = ?", user.UserID))
+	}
+
+	var comps []models.Competition
+	if err := query.Order(clause.Expr{SQL: "deadline < ?, deadline", Vars: []interface{}{today}}).Find(&comps).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch competitions"})
+	}
+	h.markViewer(user.UserID, comps)
+
+	return c.JSON(fiber.Map{"success": true, "data": comps})
+}
+
+// GetCompetition returns one competition with the caller's team and the team size limits
+// GET /api/competitions/:id
+func (h *CompetitionHandler) GetCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+	comps := []models.Competition{*comp}
+	h.markViewer(user.UserID, comps)
+
+	var teamCount int64
+	h.DB.Model(&models.CompetitionTeam{}).Where("competition_id = ?", comp.ID).Count(&teamCount)
+
+	result := fiber.Map{
+		"competition":       comps[0],
+		"team_count":        teamCount,
+		"registration_open": registrationOpen(*comp),
+	}
+	if min, max, ok := comp.TeamLimits(); ok {
+		result["team_min"], result["team_max"] = min, max
+	}
+	var team models.CompetitionTeam
+	err = h.DB.Preload("Members.Student").
+		Where("id = (?)", h.DB.Model(&models.CompetitionTeamMember{}).Select("team_id").Where("competition_id = ? AND student_id = ?", comp.ID, user.UserID)).
+		First(&team).Error
+	if err == nil {
+		result["my_team"] = team
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": result})
+}
+
+// CreateCompetition posts a competition to the board
+// POST /api/competitions
+func (h *CompetitionHandler) CreateCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp := models.Competition{CreatedBy: &user.UserID}
+	if err := h.applyRequest(c, &comp); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+	if err := h.DB.Create(&comp).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to create competition"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": comp, "message": "Lomba berhasil ditambahkan"})
+}
+
+// UpdateCompetition edits a competition. Moving the deadline re-arms the bookmark reminders.
+// PUT /api/competitions/:id
+func (h *CompetitionHandler) UpdateCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+	if !canManageCompetition(user, comp) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya pembuat lomba atau admin dev yang dapat mengubahnya"})
+	}
+
+	oldDeadline := comp.Deadline
+	if err := h.applyRequest(c, comp); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(comp).Error; err != nil {
+			return err
+		}
+		if !comp.Deadline.Equal(oldDeadline) {
+			return tx.Model(&models.CompetitionBookmark{}).Where("competition_id = ?", comp.ID).Update("reminded_at", nil).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to update competition"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "data": comp, "message": "Lomba berhasil diperbarui"})
+}
+
+// DeleteCompetition removes a competition with its bookmarks and teams
+// DELETE /api/competitions/:id
+func (h *CompetitionHandler) DeleteCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+	if !canManageCompetition(user, comp) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya pembuat lomba atau admin dev yang dapat menghapusnya"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("competition_id = ?", comp.ID).Delete(&models.CompetitionTeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("competition_id = ?", comp.ID).Delete(&models.CompetitionTeam{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("competition_id = ?", comp.ID).Delete(&models.CompetitionBookmark{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(comp).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to delete competition"})
+	}
+
+	return c.JSON(fiber.Map{"success": true, "message": "Lomba berhasil dihapus"})
+}
+
+// BookmarkCompetition saves a competition; bookmarked deadlines get a reminder and appear
+// in the calendar feed
+// POST /api/competitions/:id/bookmark
+func (h *CompetitionHandler) BookmarkCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+
+	bookmark := models.CompetitionBookmark{CompetitionID: comp.ID, UserID: user.UserID}
+	if err := h.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&bookmark).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to bookmark competition"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Lomba disimpan"})
+}
+
+// UnbookmarkCompetition removes a saved competition
+// DELETE /api/competitions/:id/bookmark
+func (h *CompetitionHandler) UnbookmarkCompetition(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	if err := h.DB.Where("competition_id = ? AND user_id = ?", c.Params("id"), user.UserID).Delete(&models.CompetitionBookmark{}).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to remove bookmark"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Lomba dihapus dari simpanan"})
+}
+
+// GetTeams lists the registered teams. Mahasiswa only see their own team.
+// GET /api/competitions/:id/teams
+func (h *CompetitionHandler) GetTeams(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+
+	query := h.DB.Preload("Members.Student").Where("competition_id = ?", comp.ID).Order("created_at")
+	if user.Role == models.RoleMahasiswa {
+		query = query.Where("id IN (?)", h.DB.Model(&models.CompetitionTeamMember{}).Select("team_id").Where("student_id = ?", user.UserID))
+	}
+	var teams []models.CompetitionTeam
+	if err := query.Find(&teams).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch teams"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": teams})
+}
+
+// RegisterTeam registers the caller's team. Members are given by NIM; the team size must
+// fit the competition's team_size and nobody may already be in another team for it.
+// POST /api/competitions/:id/teams
+func (h *CompetitionHandler) RegisterTeam(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	if user.Role != models.RoleMahasiswa && user.Role != models.RoleAdminKelas {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya mahasiswa yang dapat mendaftarkan tim"})
+	}
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+	if !registrationOpen(*comp) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Pendaftaran lomba sudah ditutup"})
+	}
+
+	var req TeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var leader models.Profile
+	if err := h.DB.Where("user_id = ?", user.UserID).First(&leader).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Profile not found"})
+	}
+
+	// Resolve NIMs; the leader is always a member
+	nims := []string{leader.NIM}
+	seen := map[string]bool{leader.NIM: true}
+	for _, nim := range req.MemberNIMs {
+		nim = strings.TrimSpace(nim)
+		if nim != "" && !seen[nim] {
+			seen[nim] = true
+			nims = append(nims, nim)
+		}
+	}
+	var members []models.Profile
+	h.DB.Where("nim IN ? AND role IN ?", nims, []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas}).Find(&members)
+	found := make(map[string]bool, len(members))
+	for _, m := range members {
+		found[m.NIM] = true
+	}
+	var unknown []string
+	for _, nim := range nims {
+		if !found[nim] {
+			unknown = append(unknown, nim)
+		}
+	}
+	if len(unknown) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "NIM tidak ditemukan: " + strings.Join(unknown, ", ")})
+	}
+
+	if min, max, ok := comp.TeamLimits(); ok && (len(members) < min || len(members) > max) {
+		limit := fmt.Sprintf("%d-%d", min, max)
+		if min == max {
+			limit = strconv.Itoa(min)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Jumlah anggota tim harus %s orang (termasuk ketua), saat ini %d", limit, len(members)),
+		})
+	}
+
+	memberIDs := make([]uuid.UUID, len(members))
+	for i, m := range members {
+		memberIDs[i] = m.UserID
+	}
+	var taken []string
+	h.DB.Model(&models.Profile{}).
+		Where("user_id IN (?)", h.DB.Model(&models.CompetitionTeamMember{}).Select("student_id").Where("competition_id = ? AND student_id IN ?", comp.ID, memberIDs)).
+		Pluck("full_name", &taken)
+	if len(taken) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Sudah terdaftar di tim lain: " + strings.Join(taken, ", ")})
+	}
+
+	team := models.CompetitionTeam{CompetitionID: comp.ID, Name: strings.TrimSpace(req.Name), LeaderID: user.UserID}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&team).Error; err != nil {
+			return err
+		}
+		rows := make([]models.CompetitionTeamMember, len(members))
+		for i, m := range members {
+			rows[i] = models.CompetitionTeamMember{TeamID: team.ID, CompetitionID: comp.ID, StudentID: m.UserID}
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		// The unique index catches a member registered concurrently
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Gagal mendaftarkan tim, salah satu anggota sudah terdaftar"})
+	}
+	h.DB.Preload("Members.Student").First(&team, "id = ?", team.ID)
+
+	names := make([]string, len(members))
+	var others []uuid.UUID
+	for i, m := range members {
+		names[i] = m.FullName
+		if m.UserID != user.UserID {
+			others = append(others, m.UserID)
+		}
+	}
+	h.Notify.PublishAsync(notify.Event{
+		Type:    notify.EventCompetitionTeam,
+		UserIDs: others,
+		Link:    "/dashboard/competitions",
+		Data: map[string]interface{}{
+			"team":    team.Name,
+			"title":   comp.Title,
+			"leader":  leader.FullName,
+			"members": strings.Join(names, ", "),
+		},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"success": true, "data": team, "message": "Tim " + team.Name + " berhasil didaftarkan"})
+}
+
+// DeleteTeam withdraws a team. The leader can withdraw while registration is open; admins
+// any time.
+// DELETE /api/competitions/:id/teams/:teamId
+func (h *CompetitionHandler) DeleteTeam(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	comp, err := h.findCompetition(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Lomba tidak ditemukan"})
+	}
+	var team models.CompetitionTeam
+	if err := h.DB.Where("id = ? AND competition_id = ?", c.Params("teamId"), comp.ID).First(&team).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Tim tidak ditemukan"})
+	}
+
+	isAdmin := user.Role == models.RoleAdminDev || canManageCompetition(user, comp)
+	if !isAdmin && team.LeaderID != user.UserID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya ketua tim yang dapat membatalkan pendaftaran"})
+	}
+	if !isAdmin && !registrationOpen(*comp) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Pendaftaran lomba sudah ditutup"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", team.ID).Delete(&models.CompetitionTeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&team).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to withdraw team"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Pendaftaran tim " + team.Name + " dibatalkan"})
+}
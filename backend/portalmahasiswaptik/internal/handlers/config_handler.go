@@ -117,9 +117,7 @@ func (h *ConfigHandler) SaveBillingRange(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Selected month must be between 0 and 12"})
 	}
 
-	if req.StartMonth > req.EndMonth {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Start month cannot be greater than end month"})
-	}
+	// A start month after the end month wraps around the new year (e.g. August–January)
 
 	// TRANSACTION START
 	err := h.DB.Transaction(func(tx *gorm.DB) error {
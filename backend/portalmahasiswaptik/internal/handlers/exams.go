@@ -0,0 +1,984 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ========================================
+// EXAMS (UTS / UAS)
+// ========================================
+
+// ExamRoomInput is one room of an exam
+type ExamRoomInput struct {
+	Room          string     `json:"room" validate:"required,max=50"`
+	Capacity      int        `json:"capacity" validate:"required,min=1,max=500"`
+	InvigilatorID *uuid.UUID `json:"invigilator_id"`
+}
+
+// ExamRequest represents create/update exam payload
+type ExamRequest struct {
+	SubjectID     uuid.UUID       `json:"subject_id" validate:"required"`
+	Type          string          `json:"type" validate:"required,oneof=uts uas"`
+	Date          string          `json:"date" validate:"required"`       // YYYY-MM-DD
+	StartTime     string          `json:"start_time" validate:"required"` // "HH:MM" WIB
+	EndTime       string          `json:"end_time" validate:"required"`   // "HH:MM" WIB
+	Rooms         []ExamRoomInput `json:"rooms" validate:"required,min=1,dive"`
+	MinAttendance *float64        `json:"min_attendance" validate:"omitempty,min=0,max=100"` // percent, overrides exam_min_attendance
+	Notes         string          `json:"notes" validate:"max=500"`
+}
+
+// ExamCandidate is a student taking the subject, measured against the attendance threshold
+type ExamCandidate struct {
+	StudentID      uuid.UUID `json:"student_id"`
+	NIM            string    `json:"nim"`
+	FullName       string    `json:"full_name"`
+	ClassID        uuid.UUID `json:"class_id"`
+	ClassName      string    `json:"class_name"`
+	HeldMeetings   int       `json:"held_meetings"`
+	Attended       int       `json:"attended_meetings"`
+	AttendanceRate float64   `json:"attendance_rate"` // percent; 100 when nothing was held
+	Eligible       bool      `json:"eligible"`
+}
+
+// ExamBrief identifies an exam in clash reports
+type ExamBrief struct {
+	ID       uuid.UUID `json:"id"`
+	Subject  string    `json:"subject"`
+	Type     string    `json:"type"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// ExamClash is a student expected at two exams that overlap in time
+type ExamClash struct {
+	StudentID uuid.UUID    `json:"student_id"`
+	NIM       string       `json:"nim"`
+	FullName  string       `json:"full_name"`
+	Exams     [2]ExamBrief `json:"exams"`
+}
+
+// Helper: Attendance percentage an exam requires
+func (h *AcademicHandler) examThreshold(exam *models.Exam) float64 {
+	if exam.MinAttendance != nil {
+		return *exam.MinAttendance
+	}
+	var cfg models.GlobalConfig
+	if err := h.DB.Where("key = ?", "exam_min_attendance").First(&cfg).Error; err == nil {
+		if v, err := strconv.ParseFloat(cfg.Value, 64); err == nil {
+			return v
+		}
+	}
+	return models.DefaultExamMinAttendance
+}
+
+// Helper: Exams that overlap [start, end), excluding excludeID
+func (h *AcademicHandler) overlappingExams(start, end time.Time, excludeID uuid.UUID) []models.Exam {
+	var exams []models.Exam
+	h.DB.Preload("Subject").Preload("Rooms").
+		Where("starts_at < ? AND ends_at > ? AND id <> ?", end, start, excludeID).
+		Find(&exams)
+	return exams
+}
+
+// Helper: Validate the payload into an exam and its rooms. Rooms and invigilators already
+// used by an overlapping exam are refused. Returns status and message on failure.
+func (h *AcademicHandler) buildExam(req *ExamRequest, exam *models.Exam) ([]models.ExamRoom, int, string) {
+	if !clockPattern.MatchString(req.StartTime) || !clockPattern.MatchString(req.EndTime) {
+		return nil, fiber.StatusBadRequest, "start_time dan end_time harus berformat HH:MM"
+	}
+	if req.StartTime >= req.EndTime {
+		return nil, fiber.StatusBadRequest, "end_time harus setelah start_time"
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, WIB)
+	if err != nil {
+		return nil, fiber.StatusBadRequest, "date harus berformat YYYY-MM-DD"
+	}
+	startsAt, _ := clockOnDate(req.StartTime, date)
+	endsAt, _ := clockOnDate(req.EndTime, date)
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", req.SubjectID).First(&subject).Error; err != nil {
+		return nil, fiber.StatusNotFound, "Mata kuliah tidak ditemukan"
+	}
+
+	rooms := make([]models.ExamRoom, 0, len(req.Rooms))
+	seenRoom := make(map[string]bool, len(req.Rooms))
+	for i, in := range req.Rooms {
+		room := strings.TrimSpace(in.Room)
+		if seenRoom[strings.ToLower(room)] {
+			return nil, fiber.StatusBadRequest, "Ruangan " + room + " tercantum dua kali"
+		}
+		seenRoom[strings.ToLower(room)] = true
+		if in.InvigilatorID != nil {
+			var count int64
+			h.DB.Model(&models.Profile{}).Where("user_id = ? AND role = ?", *in.InvigilatorID, models.RoleAdminDosen).Count(&count)
+			if count == 0 {
+				return nil, fiber.StatusBadRequest, "Pengawas ruangan " + room + " harus dosen"
+			}
+		}
+		rooms = append(rooms, models.ExamRoom{Room: room, Capacity: in.Capacity, InvigilatorID: in.InvigilatorID, Position: i + 1})
+	}
+
+	for _, other := range h.overlappingExams(startsAt, endsAt, exam.ID) {
+		name := "ujian lain"
+		if other.Subject != nil {
+			name = strings.ToUpper(other.Type) + " " + other.Subject.Name
+		}
+		for _, r := range other.Rooms {
+			for _, mine := range rooms {
+				if strings.EqualFold(r.Room, mine.Room) {
+					return nil, fiber.StatusConflict, "Ruangan " + mine.Room + " sudah dipakai " + name + " pada jam yang sama"
+				}
+				if r.InvigilatorID != nil && mine.InvigilatorID != nil && *r.InvigilatorID == *mine.InvigilatorID {
+					return nil, fiber.StatusConflict, "Pengawas ruangan " + mine.Room + " sudah mengawasi " + name + " pada jam yang sama"
+				}
+			}
+		}
+	}
+
+	exam.SubjectID = req.SubjectID
+	exam.Type = req.Type
+	exam.StartsAt, exam.EndsAt = startsAt, endsAt
+	exam.MinAttendance = req.MinAttendance
+	exam.Notes = strings.TrimSpace(req.Notes)
+	exam.Subject = &subject
+	return rooms, 0, ""
+}
+
+// Helper: Classes taking the subject now (assigned to it for their current semester)
+func (h *AcademicHandler) subjectClasses(subjectID uuid.UUID) []models.Class {
+	var classes []models.Class
+	assigned := h.DB.Model(&models.TeachingAssignment{}).Select("class_id").
+		Where("subject_id = ?", subjectID).Where(enrollment.CurrentAssignment("teaching_assignments"))
+	h.DB.Where("id IN (?)", assigned).Order("name ASC").Find(&classes)
+	return classes
+}
+
+// examCandidates lists every student on the rosters of the subject's classes with their
+// attendance over the meetings held so far, sorted by NIM
+func (h *AcademicHandler) examCandidates(exam *models.Exam) []ExamCandidate {
+	threshold := h.examThreshold(exam)
+	candidates := []ExamCandidate{}
+	seen := make(map[uuid.UUID]bool)
+
+	termID := enrollment.ExamTermID(h.DB, exam)
+	for _, class := range h.subjectClasses(exam.SubjectID) {
+		var students []models.Profile
+		enrollment.Roster(h.DB, termID, exam.SubjectID, class.ID).
+			Where("role IN ?", []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas}).
+			Find(&students)
+		if len(students) == 0 {
+			continue
+		}
+
+		// Held meetings: a session of the class that is closed or finalized
+		var held []uuid.UUID
+		h.DB.Model(&models.AttendanceSession{}).
+			Joins("JOIN meetings ON meetings.id = attendance_sessions.meeting_id").
+			Where("meetings.subject_id = ? AND attendance_sessions.class_id = ? AND attendance_sessions.archived_at IS NULL", exam.SubjectID, class.ID).
+			Where("attendance_sessions.finalized_at IS NOT NULL OR attendance_sessions.is_active = false OR attendance_sessions.expires_at < ?", time.Now()).
+			Distinct().Pluck("attendance_sessions.meeting_id", &held)
+
+		var records []struct {
+			StudentID uuid.UUID
+			MeetingID uuid.UUID
+			Status    string
+		}
+		h.DB.Table("attendance_records r").
+			Select("r.student_id, s.meeting_id, r.status").
+			Joins("JOIN attendance_sessions s ON s.id = r.session_id").
+			Joins("JOIN meetings m ON m.id = s.meeting_id").
+			Where("m.subject_id = ? AND s.class_id = ? AND s.archived_at IS NULL", exam.SubjectID, class.ID).
+			Scan(&records)
+		attended := make(map[uuid.UUID]map[uuid.UUID]bool)
+		for _, r := range records {
+			if !models.IsAttendedStatus(r.Status) {
+				continue
+			}
+			if attended[r.StudentID] == nil {
+				attended[r.StudentID] = make(map[uuid.UUID]bool)
+			}
+			attended[r.StudentID][r.MeetingID] = true
+		}
+
+		for _, s := range students {
+			if seen[s.UserID] {
+				continue
+			}
+			seen[s.UserID] = true
+			cand := ExamCandidate{
+				StudentID:      s.UserID,
+				NIM:            s.NIM,
+				FullName:       s.FullName,
+				ClassID:        class.ID,
+				ClassName:      class.Name,
+				HeldMeetings:   len(held),
+				Attended:       len(attended[s.UserID]),
+				AttendanceRate: 100,
+			}
+			if cand.HeldMeetings > 0 {
+				cand.AttendanceRate = round2(float64(cand.Attended) * 100 / float64(cand.HeldMeetings))
+			}
+			cand.Eligible = cand.AttendanceRate >= threshold
+			candidates = append(candidates, cand)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].NIM < candidates[j].NIM })
+	return candidates
+}
+
+// Helper: Students expected at an exam: the seated ones once seating exists, else every candidate
+func (h *AcademicHandler) examStudents(exam *models.Exam) map[uuid.UUID]bool {
+	students := make(map[uuid.UUID]bool)
+	if exam.SeatedAt != nil {
+		var ids []uuid.UUID
+		h.DB.Model(&models.ExamSeat{}).Where("exam_id = ?", exam.ID).Pluck("student_id", &ids)
+		for _, id := range ids {
+			students[id] = true
+		}
+		return students
+	}
+	for _, cand := range h.examCandidates(exam) {
+		students[cand.StudentID] = true
+	}
+	return students
+}
+
+// findExamClashes reports students expected at two exams that overlap in time
+func (h *AcademicHandler) findExamClashes(exams []models.Exam) []ExamClash {
+	sort.Slice(exams, func(i, j int) bool { return exams[i].StartsAt.Before(exams[j].StartsAt) })
+	studentsOf := make(map[uuid.UUID]map[uuid.UUID]bool, len(exams))
+	brief := func(e models.Exam) ExamBrief {
+		b := ExamBrief{ID: e.ID, Type: e.Type, StartsAt: e.StartsAt, EndsAt: e.EndsAt}
+		if e.Subject != nil {
+			b.Subject = e.Subject.Name
+		}
+		return b
+	}
+
+	clashes := []ExamClash{}
+	var clashIDs []uuid.UUID
+	for i := range exams {
+		for j := i + 1; j < len(exams) && exams[j].StartsAt.Before(exams[i].EndsAt); j++ {
+			for _, k := range []int{i, j} {
+				if studentsOf[exams[k].ID] == nil {
+					studentsOf[exams[k].ID] = h.examStudents(&exams[k])
+				}
+			}
+			for id := range studentsOf[exams[i].ID] {
+				if studentsOf[exams[j].ID][id] {
+					clashes = append(clashes, ExamClash{StudentID: id, Exams: [2]ExamBrief{brief(exams[i]), brief(exams[j])}})
+					clashIDs = append(clashIDs, id)
+				}
+			}
+		}
+	}
+
+	if len(clashIDs) > 0 {
+		var profiles []models.Profile
+		h.DB.Where("user_id IN ?", clashIDs).Find(&profiles)
+		byID := make(map[uuid.UUID]models.Profile, len(profiles))
+		for _, p := range profiles {
+			byID[p.UserID] = p
+		}
+		for i := range clashes {
+			clashes[i].NIM = byID[clashes[i].StudentID].NIM
+			clashes[i].FullName = byID[clashes[i].StudentID].FullName
+		}
+		sort.Slice(clashes, func(i, j int) bool { return clashes[i].NIM < clashes[j].NIM })
+	}
+	return clashes
+}
+
+// Helper: Clashes between one exam and the exams overlapping it
+func (h *AcademicHandler) examClashesWith(exam *models.Exam) []ExamClash {
+	exams := append(h.overlappingExams(exam.StartsAt, exam.EndsAt, exam.ID), *exam)
+	all := h.findExamClashes(exams)
+	clashes := []ExamClash{}
+	for _, cl := range all {
+		if cl.Exams[0].ID == exam.ID || cl.Exams[1].ID == exam.ID {
+			clashes = append(clashes, cl)
+		}
+	}
+	return clashes
+}
+
+// examsQuery scopes exams to what a user takes part in: admin dev sees all, lecturers the
+// subjects they teach and the rooms they invigilate, students the subjects of their class
+// and their KRS enrollments
+func examsQuery(db *gorm.DB, userID uuid.UUID, role models.AppRole, classID *uuid.UUID) *gorm.DB {
+	query := db.Model(&models.Exam{})
+	switch {
+	case role == models.RoleAdminDev:
+	case role == models.RoleAdminDosen:
+		query = query.Where("subject_id IN (?) OR id IN (?)",
+			db.Model(&models.TeachingAssignment{}).Select("subject_id").Where("lecturer_id = ?", userID),
+			db.Model(&models.ExamRoom{}).Select("exam_id").Where("invigilator_id = ?", userID))
+	default:
+		classSubjects := db.Model(&models.TeachingAssignment{}).Select("subject_id").Where("class_id = ?", uuid.Nil)
+		if classID != nil {
+			classSubjects = db.Model(&models.TeachingAssignment{}).Select("subject_id").Where("class_id = ?", *classID)
+		}
+		query = query.Where("subject_id IN (?) OR subject_id IN (?) OR id IN (?)",
+			classSubjects,
+			db.Model(&models.Enrollment{}).Select("subject_id").Where("student_id = ?", userID),
+			db.Model(&models.ExamSeat{}).Select("exam_id").Where("student_id = ?", userID))
+	}
+	return query
+}
+
+// Helper: Load an exam with subject and rooms, checking a lecturer teaches or invigilates it
+func (h *AcademicHandler) examForStaff(c *fiber.Ctx) (*models.Exam, error) {
+	user := c.Locals("user").(middleware.UserContext)
+	examID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid exam ID"})
+	}
+
+	var exam models.Exam
+	err = h.DB.Preload("Subject").Preload("Rooms", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("id = ?", examID).First(&exam).Error
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Ujian tidak ditemukan"})
+	}
+
+	allowed := teachesSubject(h.DB, user, exam.SubjectID)
+	for _, r := range exam.Rooms {
+		if r.InvigilatorID != nil && *r.InvigilatorID == user.UserID {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak mengajar atau mengawasi ujian ini"})
+	}
+	return &exam, nil
+}
+
+// GetExams lists the exams the caller takes part in, with their own seat for students
+// GET /api/academic/exams?subject_id=&type=&all=
+func (h *AcademicHandler) GetExams(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := examsQuery(h.DB, user.UserID, user.Role, user.ClassID).
+		Preload("Subject").Preload("Rooms", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+	if subjectID := c.Query("subject_id"); subjectID != "" {
+		if _, err := uuid.Parse(subjectID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "subject_id tidak valid"})
+		}
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	if examType := c.Query("type"); examType != "" {
+		query = query.Where("type = ?", examType)
+	}
+	// Exams of archived terms only with ?all=true
+	if c.Query("all") != "true" {
+		query = query.Where("term_id IS NULL OR term_id IN (?)", h.DB.Model(&models.AcademicTerm{}).Select("id").Where("archived_at IS NULL"))
+	}
+
+	var exams []models.Exam
+	query.Order("starts_at ASC").Find(&exams)
+
+	seats := make(map[uuid.UUID]models.ExamSeat)
+	if user.Role == models.RoleMahasiswa || user.Role == models.RoleAdminKelas {
+		var mine []models.ExamSeat
+		h.DB.Preload("Room").Where("student_id = ?", user.UserID).Find(&mine)
+		for _, s := range mine {
+			seats[s.ExamID] = s
+		}
+	}
+
+	data := make([]fiber.Map, 0, len(exams))
+	for _, e := range exams {
+		item := fiber.Map{"exam": e}
+		if seat, ok := seats[e.ID]; ok {
+			item["seat"] = seat
+		}
+		data = append(data, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// CreateExam schedules a UTS/UAS with its rooms and invigilators
+// POST /api/academic/exams
+func (h *AcademicHandler) CreateExam(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req ExamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	exam := models.Exam{CreatedBy: &user.UserID}
+	rooms, status, msg := h.buildExam(&req, &exam)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+	var term models.AcademicTerm
+	if err := h.DB.Where("is_active = ?", true).First(&term).Error; err == nil {
+		exam.TermID = &term.ID
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Subject", "Rooms").Create(&exam).Error; err != nil {
+			return err
+		}
+		for i := range rooms {
+			rooms[i].ExamID = exam.ID
+		}
+		return tx.Create(&rooms).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat jadwal ujian"})
+	}
+	exam.Rooms = rooms
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    exam,
+		"clashes": h.examClashesWith(&exam),
+		"message": "Jadwal ujian berhasil dibuat",
+	})
+}
+
+// UpdateExam replaces an exam's time and rooms. Existing seating is cleared and has to be
+// generated again.
+// PUT /api/academic/exams/:id
+func (h *AcademicHandler) UpdateExam(c *fiber.Ctx) error {
+	examID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid exam ID"})
+	}
+
+	var exam models.Exam
+	if err := h.DB.Where("id = ?", examID).First(&exam).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Ujian tidak ditemukan"})
+	}
+
+	var req ExamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	rooms, status, msg := h.buildExam(&req, &exam)
+	if status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+	exam.SeatedAt = nil
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamSeat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamRoom{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Subject", "Rooms").Save(&exam).Error; err != nil {
+			return err
+		}
+		for i := range rooms {
+			rooms[i].ExamID = exam.ID
+		}
+		return tx.Create(&rooms).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui jadwal ujian"})
+	}
+	exam.Rooms = rooms
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    exam,
+		"clashes": h.examClashesWith(&exam),
+		"message": "Jadwal ujian diperbarui. Susun ulang tempat duduk.",
+	})
+}
+
+// DeleteExam removes an exam with its rooms and seating
+// DELETE /api/academic/exams/:id
+func (h *AcademicHandler) DeleteExam(c *fiber.Ctx) error {
+	examID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid exam ID"})
+	}
+
+	var exam models.Exam
+	if err := h.DB.Where("id = ?", examID).First(&exam).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Ujian tidak ditemukan"})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		return deleteExams(tx, []uuid.UUID{exam.ID})
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus ujian"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Ujian berhasil dihapus",
+	})
+}
+
+// Helper: Delete exams with their rooms and seats (caller provides the transaction)
+func deleteExams(tx *gorm.DB, examIDs []uuid.UUID) error {
+	if len(examIDs) == 0 {
+		return nil
+	}
+	for _, model := range []interface{}{&models.ExamSeat{}, &models.ExamRoom{}} {
+		if err := tx.Where("exam_id IN ?", examIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id IN ?", examIDs).Delete(&models.Exam{}).Error
+}
+
+// GetExamEligibility lists the subject's students with attendance against the threshold
+// GET /api/academic/exams/:id/eligibility
+func (h *AcademicHandler) GetExamEligibility(c *fiber.Ctx) error {
+	exam, errResp := h.examForStaff(c)
+	if exam == nil {
+		return errResp
+	}
+
+	candidates := h.examCandidates(exam)
+	eligible := 0
+	for _, cand := range candidates {
+		if cand.Eligible {
+			eligible++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"data":           candidates,
+		"min_attendance": h.examThreshold(exam),
+		"eligible":       eligible,
+		"ineligible":     len(candidates) - eligible,
+	})
+}
+
+// GenerateSeating seats the eligible students by NIM, filling the rooms in order up to
+// their capacity. Previous seating of the exam is replaced.
+// POST /api/academic/exams/:id/seating
+func (h *AcademicHandler) GenerateSeating(c *fiber.Ctx) error {
+	exam, errResp := h.examForStaff(c)
+	if exam == nil {
+		return errResp
+	}
+
+	var eligible []ExamCandidate
+	ineligible := []ExamCandidate{}
+	for _, cand := range h.examCandidates(exam) {
+		if cand.Eligible {
+			eligible = append(eligible, cand)
+		} else {
+			ineligible = append(ineligible, cand)
+		}
+	}
+
+	capacity := 0
+	for _, r := range exam.Rooms {
+		capacity += r.Capacity
+	}
+	if capacity < len(eligible) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":  false,
+			"error":    "Kapasitas ruangan tidak cukup untuk semua peserta",
+			"needed":   len(eligible),
+			"capacity": capacity,
+		})
+	}
+
+	seats := make([]models.ExamSeat, 0, len(eligible))
+	room, seat := 0, 0
+	for _, cand := range eligible {
+		if seat == exam.Rooms[room].Capacity {
+			room, seat = room+1, 0
+		}
+		seat++
+		seats = append(seats, models.ExamSeat{
+			ExamID:         exam.ID,
+			StudentID:      cand.StudentID,
+			RoomID:         exam.Rooms[room].ID,
+			SeatNumber:     seat,
+			AttendanceRate: cand.AttendanceRate,
+		})
+	}
+
+	now := time.Now()
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamSeat{}).Error; err != nil {
+			return err
+		}
+		if len(seats) > 0 {
+			if err := tx.Create(&seats).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Exam{}).Where("id = ?", exam.ID).Update("seated_at", now).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyusun tempat duduk"})
+	}
+	exam.SeatedAt = &now
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"seated":     len(seats),
+		"ineligible": ineligible,
+		"clashes":    h.examClashesWith(exam),
+		"message":    fmt.Sprintf("%d peserta mendapat tempat duduk", len(seats)),
+	})
+}
+
+// Helper: Seats of an exam ordered by room and seat number, with students
+func (h *AcademicHandler) examSeating(exam *models.Exam) []models.ExamSeat {
+	var seats []models.ExamSeat
+	h.DB.Preload("Student").Preload("Student.Class").
+		Joins("JOIN exam_rooms ON exam_rooms.id = exam_seats.room_id").
+		Where("exam_seats.exam_id = ?", exam.ID).
+		Order("exam_rooms.position ASC, exam_seats.seat_number ASC").
+		Find(&seats)
+	return seats
+}
+
+// GetSeating returns the seating of an exam grouped by room
+// GET /api/academic/exams/:id/seating
+func (h *AcademicHandler) GetSeating(c *fiber.Ctx) error {
+	exam, errResp := h.examForStaff(c)
+	if exam == nil {
+		return errResp
+	}
+
+	byRoom := make(map[uuid.UUID][]models.ExamSeat)
+	for _, s := range h.examSeating(exam) {
+		byRoom[s.RoomID] = append(byRoom[s.RoomID], s)
+	}
+	rooms := make([]fiber.Map, 0, len(exam.Rooms))
+	for _, r := range exam.Rooms {
+		seats := byRoom[r.ID]
+		if seats == nil {
+			seats = []models.ExamSeat{}
+		}
+		rooms = append(rooms, fiber.Map{"room": r, "seats": seats})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"exam":  exam,
+			"rooms": rooms,
+		},
+	})
+}
+
+// Helper: Excel sheet name for a room — forbidden characters replaced, at most 31 characters
+// (cut on rune boundaries) and unique within the workbook, which compares names case-insensitively
+func seatingSheetName(room string, used map[string]bool) string {
+	base := strings.Trim(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(room)), "'")
+	if base == "" {
+		base = "Ruangan"
+	}
+
+	name := truncateRunes(base, 31)
+	for n := 2; used[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		name = truncateRunes(base, 31-len(suffix)) + suffix
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// Helper: The first max runes of s
+func truncateRunes(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
+
+// ExportSeating downloads the seating list as Excel, one sheet per room with a signature column
+// GET /api/academic/exams/:id/seating/export
+func (h *AcademicHandler) ExportSeating(c *fiber.Ctx) error {
+	exam, errResp := h.examForStaff(c)
+	if exam == nil {
+		return errResp
+	}
+	if exam.SeatedAt == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Tempat duduk belum disusun"})
+	}
+
+	byRoom := make(map[uuid.UUID][]models.ExamSeat)
+	for _, s := range h.examSeating(exam) {
+		byRoom[s.RoomID] = append(byRoom[s.RoomID], s)
+	}
+	invigilators := make(map[uuid.UUID]string)
+	var ids []uuid.UUID
+	for _, r := range exam.Rooms {
+		if r.InvigilatorID != nil {
+			ids = append(ids, *r.InvigilatorID)
+		}
+	}
+	if len(ids) > 0 {
+		var profiles []models.Profile
+		h.DB.Where("user_id IN ?", ids).Find(&profiles)
+		for _, p := range profiles {
+			invigilators[p.UserID] = p.FullName
+		}
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"1E293B"}, Pattern: 1},
+		Font:      &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+		Border:    []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
+	})
+	cellStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{{Type: "left", Color: "000000", Style: 1}, {Type: "top", Color: "000000", Style: 1}, {Type: "bottom", Color: "000000", Style: 1}, {Type: "right", Color: "000000", Style: 1}},
+	})
+	titleStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	metaLabelStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+
+	start := exam.StartsAt.In(WIB)
+	usedSheets := make(map[string]bool)
+	for i, room := range exam.Rooms {
+		sheet := seatingSheetName(room.Room, usedSheets)
+		if i == 0 {
+			f.SetSheetName("Sheet1", sheet)
+		} else {
+			f.NewSheet(sheet)
+		}
+
+		f.SetCellValue(sheet, "A1", fmt.Sprintf("DAFTAR HADIR %s", strings.ToUpper(exam.Type)))
+		f.SetCellStyle(sheet, "A1", "A1", titleStyle)
+		meta := [][2]string{
+			{"Mata Kuliah", exam.Subject.Name},
+			{"Waktu", fmt.Sprintf("%s, %s - %s WIB", start.Format("02/01/2006"), start.Format("15:04"), exam.EndsAt.In(WIB).Format("15:04"))},
+			{"Ruangan", room.Room},
+			{"Pengawas", "-"},
+		}
+		if room.InvigilatorID != nil && invigilators[*room.InvigilatorID] != "" {
+			meta[3][1] = invigilators[*room.InvigilatorID]
+		}
+		for j, m := range meta {
+			row := j + 3
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), m[0])
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), ": "+m[1])
+			f.SetCellStyle(sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("A%d", row), metaLabelStyle)
+		}
+
+		headers := []string{"No Kursi", "NIM", "Nama Mahasiswa", "Kelas", "Tanda Tangan"}
+		for j, head := range headers {
+			cell, _ := excelize.CoordinatesToCellName(j+1, 8)
+			f.SetCellValue(sheet, cell, head)
+		}
+		f.SetCellStyle(sheet, "A8", "E8", headerStyle)
+
+		row := 9
+		for _, s := range byRoom[room.ID] {
+			nim, name, className := "-", "-", "-"
+			if s.Student != nil {
+				nim, name = s.Student.NIM, s.Student.FullName
+				if s.Student.Class != nil {
+					className = s.Student.Class.Name
+				}
+			}
+			f.SetCellValue(sheet, fmt.Sprintf("A%d", row), s.SeatNumber)
+			f.SetCellValue(sheet, fmt.Sprintf("B%d", row), nim)
+			f.SetCellValue(sheet, fmt.Sprintf("C%d", row), name)
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), className)
+			f.SetRowHeight(sheet, row, 22)
+			row++
+		}
+		if row > 9 {
+			f.SetCellStyle(sheet, "A9", fmt.Sprintf("E%d", row-1), cellStyle)
+		}
+		f.SetColWidth(sheet, "A", "A", 10)
+		f.SetColWidth(sheet, "B", "B", 16)
+		f.SetColWidth(sheet, "C", "C", 36)
+		f.SetColWidth(sheet, "D", "D", 14)
+		f.SetColWidth(sheet, "E", "E", 22)
+	}
+
+	filename := fmt.Sprintf("Denah_%s_%s.xlsx", strings.ToUpper(exam.Type), strings.ReplaceAll(exam.Subject.Name, " ", "_"))
+	c.Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	return f.Write(c.Response().BodyWriter())
+}
+
+// GetExamClashes lists students expected at two overlapping exams
+// GET /api/academic/exams/clashes
+func (h *AcademicHandler) GetExamClashes(c *fiber.Ctx) error {
+	var exams []models.Exam
+	h.DB.Preload("Subject").Where("ends_at > ?", time.Now().AddDate(0, 0, -1)).Find(&exams)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.findExamClashes(exams),
+	})
+}
+
+// GetExamCard renders a student's exam card (kartu ujian) as PDF: every seated exam of the
+// active term with room and seat number
+// GET /api/academic/exams/card?type=
+// GET /api/academic/exams/card/:studentId?type=
+func (h *AcademicHandler) GetExamCard(c *fiber.Ctx) error {
+	student, errResp := h.transcriptStudent(c)
+	if student == nil {
+		return errResp
+	}
+
+	query := h.DB.Preload("Room").
+		Joins("JOIN exams ON exams.id = exam_seats.exam_id").
+		Where("exam_seats.student_id = ?", student.UserID).
+		Where("exams.term_id IS NULL OR exams.term_id IN (?)", h.DB.Model(&models.AcademicTerm{}).Select("id").Where("archived_at IS NULL"))
+	if examType := c.Query("type"); examType != "" {
+		query = query.Where("exams.type = ?", examType)
+	}
+	var seats []models.ExamSeat
+	query.Order("exams.starts_at ASC").Find(&seats)
+	if len(seats) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Belum ada jadwal ujian dengan tempat duduk"})
+	}
+
+	examIDs := make([]uuid.UUID, len(seats))
+	for i, s := range seats {
+		examIDs[i] = s.ExamID
+	}
+	var exams []models.Exam
+	h.DB.Preload("Subject").Where("id IN ?", examIDs).Find(&exams)
+	examByID := make(map[uuid.UUID]models.Exam, len(exams))
+	for _, e := range exams {
+		examByID[e.ID] = e
+	}
+
+	className := "-"
+	if student.ClassID != nil {
+		var class models.Class
+		if err := h.DB.Where("id = ?", *student.ClassID).First(&class).Error; err == nil {
+			className = class.Name
+		}
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Kartu Ujian "+student.NIM, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// Header band
+	pdf.SetFillColor(30, 41, 59)
+	pdf.Rect(0, 0, 210, 30, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.SetXY(15, 8)
+	pdf.CellFormat(180, 9, "KARTU UJIAN", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(180, 6, "Portal Mahasiswa PTIK", "", 1, "C", false, 0, "")
+
+	// Student
+	pdf.SetTextColor(15, 23, 42)
+	pdf.SetXY(15, 38)
+	for _, row := range [][2]string{{"Nama", student.FullName}, {"NIM", student.NIM}, {"Kelas", className}} {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(25, 7, row[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(155, 7, tr(": "+row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Exams
+	widths := []float64{12, 58, 14, 30, 26, 24, 16}
+	pdf.SetFillColor(226, 232, 240)
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, head := range []string{"No", "Mata Kuliah", "Jenis", "Tanggal", "Waktu", "Ruangan", "Kursi"} {
+		pdf.CellFormat(widths[i], 8, head, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 10)
+	for i, s := range seats {
+		e := examByID[s.ExamID]
+		subject, room := "-", "-"
+		if e.Subject != nil {
+			subject = e.Subject.Name
+		}
+		if s.Room != nil {
+			room = s.Room.Room
+		}
+		start := e.StartsAt.In(WIB)
+		cells := []string{
+			strconv.Itoa(i + 1),
+			subject,
+			strings.ToUpper(e.Type),
+			start.Format("02/01/2006"),
+			start.Format("15:04") + "-" + e.EndsAt.In(WIB).Format("15:04"),
+			room,
+			strconv.Itoa(s.SeatNumber),
+		}
+		for j, cell := range cells {
+			align := "C"
+			if j == 1 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[j], 8, tr(cell), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.SetTextColor(71, 85, 105)
+	pdf.MultiCell(180, 5, tr("Bawa kartu ini dan kartu mahasiswa saat ujian. Hadir 15 menit sebelum ujian dimulai dan duduk sesuai nomor kursi."), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		fmt.Printf("❌ Exam card PDF Error: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal membuat kartu ujian"})
+	}
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=Kartu_Ujian_%s.pdf", student.NIM))
+	return c.Send(buf.Bytes())
+}
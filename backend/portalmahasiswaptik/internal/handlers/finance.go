@@ -7,6 +7,7 @@ import (
 
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,12 +17,14 @@ import (
 type FinanceHandler struct {
 	DB       *gorm.DB
 	Validate *validator.Validate
+	Notify   *notify.Service
 }
 
-func NewFinanceHandler(db *gorm.DB, validate *validator.Validate) *FinanceHandler {
+func NewFinanceHandler(db *gorm.DB, validate *validator.Validate, notifier *notify.Service) *FinanceHandler {
 	return &FinanceHandler{
 		DB:       db,
 		Validate: validate,
+		Notify:   notifier,
 	}
 }
 
@@ -557,8 +560,23 @@ func (h *FinanceHandler) BulkUpdateDues(c *fiber.Ctx) error {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update due"})
 			}
 		}
+		// paid_at is stamped by the set_weekly_dues_paid_at trigger
 	}
 
+	status := map[string]string{"paid": "lunas", "pending": "menunggu verifikasi", "reset": "belum dibayar"}[req.TargetStatus]
+	if status == "" {
+		status = req.TargetStatus
+	}
+	h.Notify.PublishAsync(notify.Event{
+		Type:    notify.EventDuesUpdated,
+		UserIDs: []uuid.UUID{studentID},
+		Link:    "/dashboard/payment",
+		Data: map[string]interface{}{
+			"period": fmt.Sprintf("%02d/%d", req.Month, req.Year),
+			"status": status,
+		},
+	})
+
 	return c.JSON(fiber.Map{"success": true})
 }
 
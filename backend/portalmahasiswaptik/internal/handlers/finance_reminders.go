@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/dues"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DuesReminderRunRequest triggers the reminder run by hand
+type DuesReminderRunRequest struct {
+	DryRun *bool `json:"dry_run"` // default true
+}
+
+// DispensationRequest pauses dues reminders for a student
+type DispensationRequest struct {
+	StudentID uuid.UUID `json:"student_id" validate:"required"`
+	Reason    string    `json:"reason" validate:"required,min=5"`
+	Until     string    `json:"until"` // YYYY-MM-DD, inclusive; empty = until lifted
+}
+
+// Helper: Class a treasurer is limited to (nil for admin_dev, who may pass ?class_id=)
+func (h *FinanceHandler) duesClassScope(c *fiber.Ctx) (*uuid.UUID, error) {
+	user := c.Locals("user").(middleware.UserContext)
+	if user.Role == models.RoleAdminKelas {
+		if user.ClassID == nil {
+			return nil, errors.New("Akun admin kelas belum terhubung ke kelas")
+		}
+		return user.ClassID, nil
+	}
+	if raw := c.Query("class_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, errors.New("Invalid class_id")
+		}
+		return &id, nil
+	}
+	return nil, nil
+}
+
+// GetDuesArrears lists students with overdue or soon-due weekly dues in the billing range
+// GET /api/finance/dues/arrears
+func (h *FinanceHandler) GetDuesArrears(c *fiber.Ctx) error {
+	classID, err := h.duesClassScope(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	arrears, err := dues.Arrears(h.DB, time.Now(), classID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to compute arrears"})
+	}
+
+	var total float64
+	overdue := 0
+	for _, a := range arrears {
+		total += a.Amount
+		if len(a.Overdue) > 0 {
+			overdue++
+		}
+	}
+	start, end := dues.BillingRange(h.DB)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"billing":          [2]int{start, end},
+			"students":         arrears,
+			"overdue_students": overdue,
+			"total_overdue":    total,
+		},
+	})
+}
+
+// RunDuesReminders sends the due reminders now instead of waiting for the hourly worker.
+// Dry run by default.
+// POST /api/finance/dues/reminders/run
+func (h *FinanceHandler) RunDuesReminders(c *fiber.Ctx) error {
+	var req DuesReminderRunRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+		}
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	report, err := dues.Run(h.DB, h.Notify, time.Now(), dryRun)
+	if errors.Is(err, dues.ErrBusy) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": err.Error()})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengirim pengingat: " + err.Error()})
+	}
+
+	message := "Pengingat iuran terkirim"
+	if dryRun {
+		message = "Simulasi pengingat iuran (belum dikirim)"
+	}
+	return c.JSON(fiber.Map{"success": true, "data": report, "message": message})
+}
+
+// GetDispensations lists dues dispensations, active ones only unless ?all=true
+// GET /api/finance/dues/dispensations
+func (h *FinanceHandler) GetDispensations(c *fiber.Ctx) error {
+	classID, err := h.duesClassScope(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	query := h.DB.Preload("Student").Order("created_at DESC")
+	if classID != nil {
+		query = query.Where("class_id = ?", *classID)
+	}
+	if c.Query("all") != "true" {
+		today := time.Now().In(WIB).Format("2006-01-02")
+		query = query.Where("until IS NULL OR until >= ?", today)
+	}
+
+	var dispensations []models.DuesDispensation
+	if err := query.Find(&dispensations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch dispensations"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": dispensations})
+}
+
+// CreateDispensation pauses dues reminders for a student of the treasurer's class
+// POST /api/finance/dues/dispensations
+func (h *FinanceHandler) CreateDispensation(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req DispensationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var until *time.Time
+	if req.Until != "" {
+		d, err := time.ParseInLocation("2006-01-02", req.Until, WIB)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "until harus berformat YYYY-MM-DD"})
+		}
+		if d.Format("2006-01-02") < time.Now().In(WIB).Format("2006-01-02") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "until tidak boleh di masa lalu"})
+		}
+		until = &d
+	}
+
+	var student models.Profile
+	if err := h.DB.Where("user_id = ?", req.StudentID).First(&student).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mahasiswa tidak ditemukan"})
+	}
+	if user.Role == models.RoleAdminKelas && (user.ClassID == nil || student.ClassID == nil || *user.ClassID != *student.ClassID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Hanya dapat memberi dispensasi untuk mahasiswa kelas Anda"})
+	}
+
+	dispensation := models.DuesDispensation{
+		StudentID: student.UserID,
+		ClassID:   student.ClassID,
+		Reason:    req.Reason,
+		Until:     until,
+		CreatedBy: user.UserID,
+	}
+	if err := h.DB.Create(&dispensation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to create dispensation"})
+	}
+	dispensation.Student = &student
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    dispensation,
+		"message": "Pengingat iuran untuk " + student.FullName + " dihentikan sementara",
+	})
+}
+
+// DeleteDispensation lifts a dispensation so reminders resume
+// DELETE /api/finance/dues/dispensations/:id
+func (h *FinanceHandler) DeleteDispensation(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var dispensation models.DuesDispensation
+	if err := h.DB.Where("id = ?", c.Params("id")).First(&dispensation).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Dispensasi tidak ditemukan"})
+	}
+	if user.Role == models.RoleAdminKelas && (user.ClassID == nil || dispensation.ClassID == nil || *user.ClassID != *dispensation.ClassID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Access denied"})
+	}
+
+	if err := h.DB.Delete(&dispensation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to lift dispensation"})
+	}
+	return c.JSON(fiber.Map{"success": true, "message": "Dispensasi dicabut, pengingat iuran aktif kembali"})
+}
@@ -0,0 +1,822 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GradeBand is one row of the grading scale
+type GradeBand struct {
+	Letter string  `json:"letter"`
+	Min    float64 `json:"min"` // lowest final score for the letter
+	Point  float64 `json:"point"`
+}
+
+// UNJGradeScale is the UNJ letter scale, highest band first
+var UNJGradeScale = []GradeBand{
+	{Letter: "A", Min: 86, Point: 4.0},
+	{Letter: "A-", Min: 81, Point: 3.7},
+	{Letter: "B+", Min: 76, Point: 3.3},
+	{Letter: "B", Min: 71, Point: 3.0},
+	{Letter: "B-", Min: 66, Point: 2.7},
+	{Letter: "C+", Min: 61, Point: 2.3},
+	{Letter: "C", Min: 56, Point: 2.0},
+	{Letter: "C-", Min: 51, Point: 1.7},
+	{Letter: "D", Min: 46, Point: 1.0},
+	{Letter: "E", Min: 0, Point: 0},
+}
+
+// Components created for a subject that has none yet
+var defaultGradeComponents = []models.GradeComponent{
+	{Name: "Tugas", Weight: 30, Position: 1},
+	{Name: "UTS", Weight: 30, Position: 2},
+	{Name: "UAS", Weight: 40, Position: 3},
+}
+
+// Helper: Letter and grade point for a final score
+func gradeForScore(score float64) (string, float64) {
+	for _, band := range UNJGradeScale {
+		if score >= band.Min {
+			return band.Letter, band.Point
+		}
+	}
+	return "E", 0
+}
+
+// Helper: Grade point of a letter (false for unknown letters)
+func gradePoint(letter string) (float64, bool) {
+	for _, band := range UNJGradeScale {
+		if strings.EqualFold(band.Letter, strings.TrimSpace(letter)) {
+			return band.Point, true
+		}
+	}
+	return 0, false
+}
+
+// Helper: Round an index to two decimals
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ensureGradeComponents returns the subject's components, creating the defaults on first use.
+// Concurrent first loads race here; the (subject_id, name) unique index makes the loser's
+// inserts no-ops and both read back the same set.
+func ensureGradeComponents(db *gorm.DB, subjectID uuid.UUID) ([]models.GradeComponent, error) {
+	var components []models.GradeComponent
+	db.Where("subject_id = ?", subjectID).Order("position ASC, created_at ASC").Find(&components)
+	if len(components) > 0 {
+		return components, nil
+	}
+
+	defaults := make([]models.GradeComponent, 0, len(defaultGradeComponents))
+	for _, d := range defaultGradeComponents {
+		d.SubjectID = subjectID
+		defaults = append(defaults, d)
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subject_id"}, {Name: "name"}},
+		DoNothing: true,
+	}).Create(&defaults).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("subject_id = ?", subjectID).Order("position ASC, created_at ASC").Find(&components).Error; err != nil {
+		return nil, err
+	}
+	return components, nil
+}
+
+// Helper: Term grades are kept under: the given one, else the active term (uuid.Nil when
+// no term is set up, the bucket of grades recorded before terms existed)
+func gradeTermID(db *gorm.DB, termID *uuid.UUID) uuid.UUID {
+	if termID != nil {
+		return *termID
+	}
+	active, _ := enrollment.ActiveTermID(db)
+	return active
+}
+
+// recomputeGrades rebuilds StudentGrade rows of the subject in one term for the given students
+// (nil = everyone with a score). A grade stays without letter until every component is scored.
+func recomputeGrades(tx *gorm.DB, subjectID, termID uuid.UUID, studentIDs []uuid.UUID, updatedBy uuid.UUID) error {
+	var subject models.Subject
+	if err := tx.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return err
+	}
+	var components []models.GradeComponent
+	tx.Where("subject_id = ?", subjectID).Find(&components)
+
+	query := tx.Where("subject_id = ? AND term_id = ?", subjectID, termID)
+	if studentIDs != nil {
+		query = query.Where("student_id IN ?", studentIDs)
+	}
+	var scores []models.GradeScore
+	query.Find(&scores)
+
+	byStudent := make(map[uuid.UUID]map[uuid.UUID]float64)
+	for _, s := range scores {
+		if byStudent[s.StudentID] == nil {
+			byStudent[s.StudentID] = make(map[uuid.UUID]float64)
+		}
+		byStudent[s.StudentID][s.ComponentID] = s.Score
+	}
+	if studentIDs == nil {
+		for id := range byStudent {
+			studentIDs = append(studentIDs, id)
+		}
+	}
+
+	for _, studentID := range studentIDs {
+		studentScores := byStudent[studentID]
+		if len(studentScores) == 0 {
+			if err := tx.Where("student_id = ? AND subject_id = ? AND term_id = ?", studentID, subjectID, termID).Delete(&models.StudentGrade{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		grade := models.StudentGrade{
+			StudentID: studentID,
+			SubjectID: subjectID,
+			TermID:    termID,
+			Semester:  subject.Semester,
+			UpdatedBy: &updatedBy,
+		}
+		complete := len(components) > 0
+		total := 0.0
+		for _, comp := range components {
+			score, ok := studentScores[comp.ID]
+			if !ok {
+				complete = false
+				break
+			}
+			total += score * comp.Weight / 100
+		}
+		if complete {
+			final := round2(total)
+			grade.FinalScore = &final
+			grade.Letter, grade.GradePoint = gradeForScore(final)
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "student_id"}, {Name: "subject_id"}, {Name: "term_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"semester", "final_score", "letter", "grade_point", "updated_by", "updated_at"}),
+		}).Create(&grade).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ========================================
+// GRADE COMPONENTS
+// ========================================
+
+// GetGradeComponents lists the weighted components of a subject
+// GET /api/academic/subjects/:id/grade-components
+func (h *AcademicHandler) GetGradeComponents(c *fiber.Ctx) error {
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+
+	var count int64
+	h.DB.Model(&models.Subject{}).Where("id = ?", subjectID).Count(&count)
+	if count == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+	}
+
+	components, err := ensureGradeComponents(h.DB, subjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memuat komponen nilai"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    components,
+		"scale":   UNJGradeScale,
+	})
+}
+
+// GradeComponentInput is one component in an update; entries without ID are created
+type GradeComponentInput struct {
+	ID     *uuid.UUID `json:"id"`
+	Name   string     `json:"name" validate:"required,max=50"`
+	Weight float64    `json:"weight" validate:"gt=0,lte=100"`
+}
+
+// UpdateGradeComponentsRequest replaces a subject's component set
+type UpdateGradeComponentsRequest struct {
+	Components []GradeComponentInput `json:"components" validate:"required,min=1,max=10,dive"`
+}
+
+// UpdateGradeComponents replaces the component set (weights must total 100). Components left
+// out are deleted with their scores, and the subject's grades of the active term are
+// recomputed; grades of earlier terms keep the result they were finalized with.
+// PUT /api/academic/subjects/:id/grade-components
+func (h *AcademicHandler) UpdateGradeComponents(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	subjectID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid subject ID"})
+	}
+	if !teachesSubject(h.DB, user, subjectID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak mengampu mata kuliah ini"})
+	}
+
+	var req UpdateGradeComponentsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	total := 0.0
+	names := make(map[string]bool, len(req.Components))
+	for _, comp := range req.Components {
+		total += comp.Weight
+		name := strings.ToLower(strings.TrimSpace(comp.Name))
+		if names[name] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Nama komponen tidak boleh sama: " + comp.Name})
+		}
+		names[name] = true
+	}
+	if math.Abs(total-100) > 0.01 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Total bobot harus 100%%, saat ini %.2f%%", total),
+		})
+	}
+
+	existing, err := ensureGradeComponents(h.DB, subjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memuat komponen nilai"})
+	}
+	known := make(map[uuid.UUID]bool, len(existing))
+	for _, comp := range existing {
+		known[comp.ID] = true
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		kept := make(map[uuid.UUID]bool)
+		for i, in := range req.Components {
+			comp := models.GradeComponent{SubjectID: subjectID, Name: strings.TrimSpace(in.Name), Weight: in.Weight, Position: i + 1}
+			if in.ID != nil {
+				if !known[*in.ID] {
+					return fmt.Errorf("komponen %s bukan milik mata kuliah ini", in.ID)
+				}
+				kept[*in.ID] = true
+				if err := tx.Model(&models.GradeComponent{}).Where("id = ?", *in.ID).
+					Updates(map[string]interface{}{"name": comp.Name, "weight": comp.Weight, "position": comp.Position}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Create(&comp).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, comp := range existing {
+			if kept[comp.ID] {
+				continue
+			}
+			if err := tx.Where("component_id = ?", comp.ID).Delete(&models.GradeScore{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&comp).Error; err != nil {
+				return err
+			}
+		}
+
+		termID := gradeTermID(tx, nil)
+		var studentIDs []uuid.UUID
+		tx.Model(&models.StudentGrade{}).Where("subject_id = ? AND term_id = ?", subjectID, termID).Pluck("student_id", &studentIDs)
+		if len(studentIDs) == 0 {
+			return nil
+		}
+		return recomputeGrades(tx, subjectID, termID, studentIDs, user.UserID)
+	})
+	if err != nil {
+		fmt.Printf("❌ Update grade components failed: %v\n", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan komponen nilai: " + err.Error()})
+	}
+
+	components, _ := ensureGradeComponents(h.DB, subjectID)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    components,
+		"message": "Komponen nilai diperbarui, nilai akhir dihitung ulang",
+	})
+}
+
+// ========================================
+// SCORES
+// ========================================
+
+// GradeSheetRow is one student's scores in a grade sheet
+type GradeSheetRow struct {
+	StudentID  uuid.UUID             `json:"student_id"`
+	NIM        string                `json:"nim"`
+	FullName   string                `json:"full_name"`
+	Scores     map[uuid.UUID]float64 `json:"scores"` // component_id -> score
+	FinalScore *float64              `json:"final_score"`
+	Letter     string                `json:"letter"`
+	GradePoint float64               `json:"grade_point"`
+	Published  bool                  `json:"published"`
+}
+
+// GetGradeSheet returns every student of the class with their component scores and final grade
+// in the active term (or ?term_id=)
+// GET /api/academic/grades/sheet?subject_id=&class_id=&term_id=
+func (h *AcademicHandler) GetGradeSheet(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	subjectID, err := uuid.Parse(c.Query("subject_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid subject_id required"})
+	}
+	classID, err := uuid.Parse(c.Query("class_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid class_id required"})
+	}
+	if !isAssigned(h.DB, user, subjectID, classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut"})
+	}
+	var requested *uuid.UUID
+	if raw := c.Query("term_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid term_id"})
+		}
+		requested = &id
+	}
+	termID := gradeTermID(h.DB, requested)
+
+	components, err := ensureGradeComponents(h.DB, subjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memuat komponen nilai"})
+	}
+
+	var students []models.Profile
+	enrollment.Roster(h.DB, termID, subjectID, classID).Order("nim ASC").Find(&students)
+	ids := make([]uuid.UUID, len(students))
+	for i, s := range students {
+		ids[i] = s.UserID
+	}
+
+	scoresBy := make(map[uuid.UUID]map[uuid.UUID]float64)
+	gradeBy := make(map[uuid.UUID]models.StudentGrade)
+	if len(ids) > 0 {
+		var scores []models.GradeScore
+		h.DB.Where("subject_id = ? AND term_id = ? AND student_id IN ?", subjectID, termID, ids).Find(&scores)
+		for _, s := range scores {
+			if scoresBy[s.StudentID] == nil {
+				scoresBy[s.StudentID] = make(map[uuid.UUID]float64)
+			}
+			scoresBy[s.StudentID][s.ComponentID] = s.Score
+		}
+
+		var grades []models.StudentGrade
+		h.DB.Where("subject_id = ? AND term_id = ? AND student_id IN ?", subjectID, termID, ids).Find(&grades)
+		for _, g := range grades {
+			gradeBy[g.StudentID] = g
+		}
+	}
+
+	rows := make([]GradeSheetRow, len(students))
+	for i, s := range students {
+		row := GradeSheetRow{StudentID: s.UserID, NIM: s.NIM, FullName: s.FullName, Scores: scoresBy[s.UserID]}
+		if row.Scores == nil {
+			row.Scores = map[uuid.UUID]float64{}
+		}
+		if g, ok := gradeBy[s.UserID]; ok {
+			row.FinalScore, row.Letter, row.GradePoint, row.Published = g.FinalScore, g.Letter, g.GradePoint, g.Published
+		}
+		rows[i] = row
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"term_id":    termID,
+			"components": components,
+			"students":   rows,
+			"scale":      UNJGradeScale,
+		},
+	})
+}
+
+// ScoreInput is one component score; a null score clears it
+type ScoreInput struct {
+	StudentID   uuid.UUID `json:"student_id" validate:"required"`
+	ComponentID uuid.UUID `json:"component_id" validate:"required"`
+	Score       *float64  `json:"score" validate:"omitempty,gte=0,lte=100"`
+}
+
+// SaveScoresRequest is a batch of scores for one subject × class (in the active term unless
+// term_id is given)
+type SaveScoresRequest struct {
+	SubjectID uuid.UUID    `json:"subject_id" validate:"required"`
+	ClassID   uuid.UUID    `json:"class_id" validate:"required"`
+	TermID    *uuid.UUID   `json:"term_id"`
+	Scores    []ScoreInput `json:"scores" validate:"required,min=1,max=2000,dive"`
+}
+
+// SaveGradeScores records component scores and recomputes the affected final grades
+// PUT /api/academic/grades/scores
+func (h *AcademicHandler) SaveGradeScores(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req SaveScoresRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	if !isAssigned(h.DB, user, req.SubjectID, req.ClassID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut"})
+	}
+
+	components, err := ensureGradeComponents(h.DB, req.SubjectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memuat komponen nilai"})
+	}
+	termID := gradeTermID(h.DB, req.TermID)
+	validComponent := make(map[uuid.UUID]bool, len(components))
+	for _, comp := range components {
+		validComponent[comp.ID] = true
+	}
+
+	var roster []uuid.UUID
+	enrollment.Roster(h.DB, termID, req.SubjectID, req.ClassID).Pluck("user_id", &roster)
+	inClass := make(map[uuid.UUID]bool, len(roster))
+	for _, id := range roster {
+		inClass[id] = true
+	}
+
+	touched := make(map[uuid.UUID]bool)
+	for i, s := range req.Scores {
+		if !inClass[s.StudentID] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": fmt.Sprintf("Baris %d: mahasiswa bukan anggota kelas ini", i+1)})
+		}
+		if !validComponent[s.ComponentID] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": fmt.Sprintf("Baris %d: komponen nilai tidak dikenal", i+1)})
+		}
+		touched[s.StudentID] = true
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		for _, s := range req.Scores {
+			if s.Score == nil {
+				if err := tx.Where("student_id = ? AND component_id = ? AND term_id = ?", s.StudentID, s.ComponentID, termID).Delete(&models.GradeScore{}).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			score := models.GradeScore{
+				StudentID:   s.StudentID,
+				ComponentID: s.ComponentID,
+				SubjectID:   req.SubjectID,
+				TermID:      termID,
+				Score:       *s.Score,
+				UpdatedBy:   &user.UserID,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "student_id"}, {Name: "component_id"}, {Name: "term_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"score", "updated_by", "updated_at"}),
+			}).Create(&score).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		ids := make([]uuid.UUID, 0, len(touched))
+		for id := range touched {
+			ids = append(ids, id)
+		}
+		return recomputeGrades(tx, req.SubjectID, termID, ids, user.UserID)
+	})
+	if err != nil {
+		fmt.Printf("❌ Save grade scores failed: %v\n", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan nilai"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d nilai tersimpan untuk %d mahasiswa", len(req.Scores), len(touched)),
+	})
+}
+
+// PublishGradesRequest toggles grade visibility for a subject × class (active term unless
+// term_id is given)
+type PublishGradesRequest struct {
+	SubjectID uuid.UUID  `json:"subject_id" validate:"required"`
+	ClassID   uuid.UUID  `json:"class_id" validate:"required"`
+	TermID    *uuid.UUID `json:"term_id"`
+	Published bool       `json:"published"`
+}
+
+// PublishGrades makes the class's final grades visible to students (or hides them again).
+// Only complete grades are published.
+// POST /api/academic/grades/publish
+func (h *AcademicHandler) PublishGrades(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req PublishGradesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	if !isAssigned(h.DB, user, req.SubjectID, req.ClassID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut"})
+	}
+
+	termID := gradeTermID(h.DB, req.TermID)
+	query := h.DB.Model(&models.StudentGrade{}).
+		Where("subject_id = ? AND term_id = ? AND student_id IN (?)", req.SubjectID, termID, enrollment.Roster(h.DB, termID, req.SubjectID, req.ClassID).Select("user_id"))
+	if req.Published {
+		query = query.Where("final_score IS NOT NULL")
+	}
+	res := query.Update("published", req.Published)
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui status publikasi"})
+	}
+
+	message := fmt.Sprintf("%d nilai dipublikasikan", res.RowsAffected)
+	if !req.Published {
+		message = fmt.Sprintf("%d nilai disembunyikan", res.RowsAffected)
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
+
+// ========================================
+// TRANSCRIPT & IPK
+// ========================================
+
+// TranscriptCourse is one graded subject on a transcript
+type TranscriptCourse struct {
+	SubjectID  uuid.UUID `json:"subject_id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	SKS        int       `json:"sks"`
+	FinalScore *float64  `json:"final_score,omitempty"`
+	Letter     string    `json:"letter"`
+	GradePoint float64   `json:"grade_point"`
+}
+
+// TranscriptSemester groups courses with the semester's IP
+type TranscriptSemester struct {
+	Semester int                `json:"semester"`
+	Courses  []TranscriptCourse `json:"courses"`
+	SKS      int                `json:"sks"`
+	IP       float64            `json:"ip"`
+}
+
+// Helper: SKS and weighted grade point average of a course list
+func gradePointAverage(courses []TranscriptCourse) (int, float64) {
+	sks, weighted := 0, 0.0
+	for _, c := range courses {
+		sks += c.SKS
+		weighted += c.GradePoint * float64(c.SKS)
+	}
+	if sks == 0 {
+		return 0, 0
+	}
+	return sks, round2(weighted / float64(sks))
+}
+
+// Helper: Published, complete grades of a student keyed by subject. A retaken subject counts
+// with its latest attempt.
+func (h *AcademicHandler) publishedGrades(studentID uuid.UUID) map[uuid.UUID]models.StudentGrade {
+	var grades []models.StudentGrade
+	h.DB.Preload("Subject").
+		Joins("LEFT JOIN academic_terms t ON t.id = student_grades.term_id").
+		Where("student_grades.student_id = ? AND student_grades.published = true AND student_grades.final_score IS NOT NULL", studentID).
+		Order("t.start_date ASC NULLS FIRST, student_grades.updated_at ASC").
+		Find(&grades)
+
+	bySubject := make(map[uuid.UUID]models.StudentGrade, len(grades))
+	for _, g := range grades {
+		if g.Subject != nil {
+			bySubject[g.SubjectID] = g
+		}
+	}
+	return bySubject
+}
+
+// Helper: Resolve :studentId (or the caller) and check the caller may see that student's records
+func (h *AcademicHandler) transcriptStudent(c *fiber.Ctx) (*models.Profile, error) {
+	user := c.Locals("user").(middleware.UserContext)
+
+	studentID := user.UserID
+	if param := c.Params("studentId"); param != "" {
+		parsed, err := uuid.Parse(param)
+		if err != nil {
+			return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid student ID"})
+		}
+		studentID = parsed
+	}
+
+	var student models.Profile
+	if err := h.DB.Where("user_id = ?", studentID).First(&student).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mahasiswa tidak ditemukan"})
+	}
+	if !canViewStudent(h.DB, user, &student) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak memiliki akses ke data mahasiswa ini"})
+	}
+	return &student, nil
+}
+
+// GetTranscript returns published grades per semester with IP and cumulative IPK
+// GET /api/academic/grades/transcript
+// GET /api/academic/grades/transcript/:studentId
+func (h *AcademicHandler) GetTranscript(c *fiber.Ctx) error {
+	student, errResp := h.transcriptStudent(c)
+	if student == nil {
+		return errResp
+	}
+
+	bySemester := make(map[int][]TranscriptCourse)
+	var all []TranscriptCourse
+	for _, g := range h.publishedGrades(student.UserID) {
+		course := TranscriptCourse{
+			SubjectID:  g.SubjectID,
+			Code:       g.Subject.Code,
+			Name:       g.Subject.Name,
+			SKS:        g.Subject.SKS,
+			FinalScore: g.FinalScore,
+			Letter:     g.Letter,
+			GradePoint: g.GradePoint,
+		}
+		bySemester[g.Semester] = append(bySemester[g.Semester], course)
+		all = append(all, course)
+	}
+
+	semesters := make([]TranscriptSemester, 0, len(bySemester))
+	for sem, courses := range bySemester {
+		sort.Slice(courses, func(i, j int) bool { return courses[i].Code < courses[j].Code })
+		sks, ip := gradePointAverage(courses)
+		semesters = append(semesters, TranscriptSemester{Semester: sem, Courses: courses, SKS: sks, IP: ip})
+	}
+	sort.Slice(semesters, func(i, j int) bool { return semesters[i].Semester < semesters[j].Semester })
+
+	totalSKS, ipk := gradePointAverage(all)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"student": fiber.Map{
+				"user_id":   student.UserID,
+				"nim":       student.NIM,
+				"full_name": student.FullName,
+			},
+			"semesters": semesters,
+			"total_sks": totalSKS,
+			"ipk":       ipk,
+		},
+	})
+}
+
+// IPKSubject is a subject in the simulator with the caller's current grade, if any
+type IPKSubject struct {
+	SubjectID uuid.UUID `json:"subject_id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	SKS       int       `json:"sks"`
+	Semester  int       `json:"semester"`
+	Graded    bool      `json:"graded"`
+	Letter    string    `json:"letter,omitempty"`
+}
+
+// GetIPKSubjects lists the curriculum with the caller's published grades; ungraded
+// subjects are the ones the simulator projects
+// GET /api/academic/ipk/subjects
+func (h *AcademicHandler) GetIPKSubjects(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var subjects []models.Subject
+	h.DB.Order("semester ASC, name ASC").Find(&subjects)
+	grades := h.publishedGrades(user.UserID)
+
+	result := make([]IPKSubject, len(subjects))
+	for i, s := range subjects {
+		item := IPKSubject{SubjectID: s.ID, Code: s.Code, Name: s.Name, SKS: s.SKS, Semester: s.Semester}
+		if g, ok := grades[s.ID]; ok {
+			item.Graded = true
+			item.Letter = g.Letter
+		}
+		result[i] = item
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+		"scale":   UNJGradeScale,
+	})
+}
+
+// SimulateIPKRequest holds hypothetical letters for remaining (or retaken) subjects
+type SimulateIPKRequest struct {
+	Grades []struct {
+		SubjectID uuid.UUID `json:"subject_id" validate:"required"`
+		Letter    string    `json:"letter" validate:"required"`
+	} `json:"grades" validate:"required,min=1,max=100,dive"`
+}
+
+// SimulateIPK projects the cumulative IPK from the published grades plus hypothetical ones.
+// A hypothetical grade for an already graded subject replaces it (retake).
+// POST /api/academic/ipk/simulate
+func (h *AcademicHandler) SimulateIPK(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req SimulateIPKRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	grades := h.publishedGrades(user.UserID)
+	courses := make(map[uuid.UUID]TranscriptCourse, len(grades)+len(req.Grades))
+	var current []TranscriptCourse
+	for _, g := range grades {
+		course := TranscriptCourse{SubjectID: g.SubjectID, Code: g.Subject.Code, Name: g.Subject.Name, SKS: g.Subject.SKS, Letter: g.Letter, GradePoint: g.GradePoint}
+		courses[g.SubjectID] = course
+		current = append(current, course)
+	}
+
+	ids := make([]uuid.UUID, len(req.Grades))
+	for i, g := range req.Grades {
+		ids[i] = g.SubjectID
+	}
+	var subjects []models.Subject
+	h.DB.Where("id IN ?", ids).Find(&subjects)
+	subjectBy := make(map[uuid.UUID]models.Subject, len(subjects))
+	for _, s := range subjects {
+		subjectBy[s.ID] = s
+	}
+
+	hypothetical := make([]TranscriptCourse, 0, len(req.Grades))
+	for _, g := range req.Grades {
+		subject, ok := subjectBy[g.SubjectID]
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Mata kuliah " + g.SubjectID.String() + " tidak ditemukan"})
+		}
+		point, ok := gradePoint(g.Letter)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Nilai huruf tidak dikenal: " + g.Letter})
+		}
+		course := TranscriptCourse{SubjectID: subject.ID, Code: subject.Code, Name: subject.Name, SKS: subject.SKS, Letter: strings.ToUpper(strings.TrimSpace(g.Letter)), GradePoint: point}
+		courses[subject.ID] = course
+		hypothetical = append(hypothetical, course)
+	}
+
+	projected := make([]TranscriptCourse, 0, len(courses))
+	for _, course := range courses {
+		projected = append(projected, course)
+	}
+
+	currentSKS, currentIPK := gradePointAverage(current)
+	projectedSKS, projectedIPK := gradePointAverage(projected)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"current":      fiber.Map{"sks": currentSKS, "ipk": currentIPK},
+			"projected":    fiber.Map{"sks": projectedSKS, "ipk": projectedIPK},
+			"delta":        round2(projectedIPK - currentIPK),
+			"hypothetical": hypothetical,
+		},
+	})
+}
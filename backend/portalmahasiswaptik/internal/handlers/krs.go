@@ -0,0 +1,578 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ========================================
+// KRS (COURSE REGISTRATION)
+// ========================================
+
+// SKSLimit is the SKS a student may take given the IP of their previous semester
+type SKSLimit struct {
+	MinIP  float64 `json:"min_ip"`
+	MaxSKS int     `json:"max_sks"`
+}
+
+// KRSSKSLimits follows the UNJ academic rules, highest band first
+var KRSSKSLimits = []SKSLimit{
+	{MinIP: 3.00, MaxSKS: 24},
+	{MinIP: 2.50, MaxSKS: 21},
+	{MinIP: 2.00, MaxSKS: 18},
+	{MinIP: 1.50, MaxSKS: 15},
+	{MinIP: 0, MaxSKS: 12},
+}
+
+// First-semester students (no published grades yet) take the package of 20 SKS
+const krsFirstSemesterSKS = 20
+
+// KRSOffering is a subject taught to a class this term that can be put on a KRS
+type KRSOffering struct {
+	SubjectID    uuid.UUID `json:"subject_id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	SKS          int       `json:"sks"`
+	Semester     int       `json:"semester"`
+	ClassID      uuid.UUID `json:"class_id"`
+	ClassName    string    `json:"class_name"`
+	LecturerName string    `json:"lecturer_name"`
+	OwnClass     bool      `json:"own_class"`
+	IsRetake     bool      `json:"is_retake"`
+	PassedLetter string    `json:"passed_letter,omitempty"` // published grade of an earlier attempt
+}
+
+// KRSItemInput is one subject picked on a KRS; class_id defaults to the student's class
+type KRSItemInput struct {
+	SubjectID uuid.UUID  `json:"subject_id" validate:"required"`
+	ClassID   *uuid.UUID `json:"class_id"`
+}
+
+// SaveKRSRequest replaces the items of the caller's draft KRS
+type SaveKRSRequest struct {
+	Items []KRSItemInput `json:"items" validate:"dive"`
+}
+
+// ReviewKRSRequest represents the advisor's decision
+type ReviewKRSRequest struct {
+	Action string `json:"action" validate:"required,oneof=approve reject"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// SetAdvisorRequest assigns an academic advisor to students
+type SetAdvisorRequest struct {
+	AdvisorID  uuid.UUID   `json:"advisor_id" validate:"required"`
+	StudentIDs []uuid.UUID `json:"student_ids" validate:"required,min=1"`
+}
+
+// Helper: Active term or a 409 response
+func (h *AcademicHandler) krsTerm(c *fiber.Ctx) (*models.AcademicTerm, error) {
+	var term models.AcademicTerm
+	if err := h.DB.Where("is_active = ?", true).First(&term).Error; err != nil {
+		return nil, c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Belum ada semester aktif untuk pengisian KRS"})
+	}
+	return &term, nil
+}
+
+// Helper: SKS limit from the IP of the latest semester with published grades
+func (h *AcademicHandler) krsMaxSKS(studentID uuid.UUID) (int, *float64) {
+	bySemester := make(map[int][]TranscriptCourse)
+	latest := 0
+	for _, g := range h.publishedGrades(studentID) {
+		bySemester[g.Semester] = append(bySemester[g.Semester], TranscriptCourse{SKS: g.Subject.SKS, GradePoint: g.GradePoint})
+		if g.Semester > latest {
+			latest = g.Semester
+		}
+	}
+	if latest == 0 {
+		return krsFirstSemesterSKS, nil
+	}
+	_, ip := gradePointAverage(bySemester[latest])
+	for _, band := range KRSSKSLimits {
+		if ip >= band.MinIP {
+			return band.MaxSKS, &ip
+		}
+	}
+	return KRSSKSLimits[len(KRSSKSLimits)-1].MaxSKS, &ip
+}
+
+// Helper: Subjects the student took before (a graded attempt or an enrollment in an earlier term)
+func (h *AcademicHandler) takenSubjects(studentID, termID uuid.UUID) map[uuid.UUID]bool {
+	var rows []struct{ SubjectID uuid.UUID }
+	h.DB.Raw(`
+		SELECT subject_id FROM student_grades WHERE student_id = ? AND final_score IS NOT NULL
+		UNION
+		SELECT subject_id FROM enrollments WHERE student_id = ? AND term_id <> ?`, studentID, studentID, termID).Scan(&rows)
+	taken := make(map[uuid.UUID]bool, len(rows))
+	for _, r := range rows {
+		taken[r.SubjectID] = true
+	}
+	return taken
+}
+
+// Helper: KRS with items, subjects and classes
+func (h *AcademicHandler) loadKRS(query *gorm.DB) (*models.KRS, error) {
+	var krs models.KRS
+	err := query.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Items.Subject").Preload("Items.Class").First(&krs).Error
+	if err != nil {
+		return nil, err
+	}
+	return &krs, nil
+}
+
+// GetKRSOfferings lists the subject × class sections open for KRS this term
+// GET /api/academic/krs/offerings?semester=&class_id=
+func (h *AcademicHandler) GetKRSOfferings(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	term, errResp := h.krsTerm(c)
+	if term == nil {
+		return errResp
+	}
+
+	query := h.DB.Preload("Subject").Preload("Class").Where(enrollment.CurrentAssignment("teaching_assignments"))
+	if classID := c.Query("class_id"); classID != "" {
+		if _, err := uuid.Parse(classID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "class_id tidak valid"})
+		}
+		query = query.Where("class_id = ?", classID)
+	}
+	if semester := c.QueryInt("semester", 0); semester > 0 {
+		query = query.Where("semester = ?", semester)
+	}
+	var assignments []models.TeachingAssignment
+	query.Find(&assignments)
+
+	lecturerIDs := make([]uuid.UUID, 0, len(assignments))
+	for _, a := range assignments {
+		lecturerIDs = append(lecturerIDs, a.LecturerID)
+	}
+	var lecturers []models.Profile
+	h.DB.Where("user_id IN ?", lecturerIDs).Find(&lecturers)
+	lecturerName := make(map[uuid.UUID]string, len(lecturers))
+	for _, l := range lecturers {
+		lecturerName[l.UserID] = l.FullName
+	}
+
+	taken := h.takenSubjects(user.UserID, term.ID)
+	passed := h.publishedGrades(user.UserID)
+
+	// One offering per section; co-teaching lecturers are joined
+	offerings := []KRSOffering{}
+	index := make(map[[2]uuid.UUID]int)
+	for _, a := range assignments {
+		if a.Subject == nil || a.Class == nil {
+			continue
+		}
+		key := [2]uuid.UUID{a.SubjectID, a.ClassID}
+		if i, ok := index[key]; ok {
+			if name := lecturerName[a.LecturerID]; name != "" {
+				offerings[i].LecturerName += ", " + name
+			}
+			continue
+		}
+		offering := KRSOffering{
+			SubjectID:    a.SubjectID,
+			Code:         a.Subject.Code,
+			Name:         a.Subject.Name,
+			SKS:          a.Subject.SKS,
+			Semester:     a.Subject.Semester,
+			ClassID:      a.ClassID,
+			ClassName:    a.Class.Name,
+			LecturerName: lecturerName[a.LecturerID],
+			OwnClass:     user.ClassID != nil && *user.ClassID == a.ClassID,
+			IsRetake:     taken[a.SubjectID],
+		}
+		if g, ok := passed[a.SubjectID]; ok {
+			offering.PassedLetter = g.Letter
+		}
+		index[key] = len(offerings)
+		offerings = append(offerings, offering)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    offerings,
+		"term":    term,
+	})
+}
+
+// GetMyKRS returns the caller's KRS for the active term (an empty draft when none is saved)
+// GET /api/academic/krs
+func (h *AcademicHandler) GetMyKRS(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	term, errResp := h.krsTerm(c)
+	if term == nil {
+		return errResp
+	}
+
+	maxSKS, ip := h.krsMaxSKS(user.UserID)
+	krs, err := h.loadKRS(h.DB.Where("student_id = ? AND term_id = ?", user.UserID, term.ID))
+	if err != nil {
+		krs = &models.KRS{StudentID: user.UserID, TermID: term.ID, Status: models.KRSDraft, MaxSKS: maxSKS, Items: []models.KRSItem{}}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    krs,
+		"term":    term,
+		"prev_ip": ip,
+		"max_sks": maxSKS,
+	})
+}
+
+// SaveKRS replaces the subjects on the caller's KRS. Only drafts and rejected KRS can be edited;
+// a rejected KRS goes back to draft.
+// PUT /api/academic/krs
+func (h *AcademicHandler) SaveKRS(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	term, errResp := h.krsTerm(c)
+	if term == nil {
+		return errResp
+	}
+
+	var req SaveKRSRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var krs models.KRS
+	err := h.DB.Where("student_id = ? AND term_id = ?", user.UserID, term.ID).First(&krs).Error
+	if err == nil && krs.Status != models.KRSDraft && krs.Status != models.KRSRejected {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "KRS sudah diajukan atau disetujui dan tidak bisa diubah"})
+	}
+
+	// Each item must be a section taught this term; a subject appears once
+	taken := h.takenSubjects(user.UserID, term.ID)
+	items := make([]models.KRSItem, 0, len(req.Items))
+	seen := make(map[uuid.UUID]bool, len(req.Items))
+	totalSKS := 0
+	for _, in := range req.Items {
+		classID := user.ClassID
+		if in.ClassID != nil {
+			classID = in.ClassID
+		}
+		if classID == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "class_id wajib diisi"})
+		}
+		if seen[in.SubjectID] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak boleh diambil dua kali"})
+		}
+		seen[in.SubjectID] = true
+
+		var subject models.Subject
+		if err := h.DB.Where("id = ?", in.SubjectID).First(&subject).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+		}
+		var offered int64
+		h.DB.Model(&models.TeachingAssignment{}).Where("subject_id = ? AND class_id = ?", in.SubjectID, *classID).
+			Where(enrollment.CurrentAssignment("teaching_assignments")).Count(&offered)
+		if offered == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": subject.Name + " tidak dibuka untuk kelas tersebut"})
+		}
+
+		totalSKS += subject.SKS
+		items = append(items, models.KRSItem{SubjectID: in.SubjectID, ClassID: *classID, IsRetake: taken[in.SubjectID]})
+	}
+
+	maxSKS, _ := h.krsMaxSKS(user.UserID)
+	if totalSKS > maxSKS {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Total SKS melebihi batas",
+			"sks":     totalSKS,
+			"max_sks": maxSKS,
+		})
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		krs.StudentID, krs.TermID = user.UserID, term.ID
+		krs.Status, krs.TotalSKS, krs.MaxSKS = models.KRSDraft, totalSKS, maxSKS
+		if err := tx.Save(&krs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("krs_id = ?", krs.ID).Delete(&models.KRSItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].KRSID = krs.ID
+		}
+		if len(items) > 0 {
+			return tx.Create(&items).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan KRS"})
+	}
+
+	saved, _ := h.loadKRS(h.DB.Where("id = ?", krs.ID))
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    saved,
+		"message": "KRS tersimpan sebagai draft",
+	})
+}
+
+// SubmitKRS sends the caller's draft KRS to their academic advisor
+// POST /api/academic/krs/submit
+func (h *AcademicHandler) SubmitKRS(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	term, errResp := h.krsTerm(c)
+	if term == nil {
+		return errResp
+	}
+
+	var student models.Profile
+	if err := h.DB.Where("user_id = ?", user.UserID).First(&student).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Profil tidak ditemukan"})
+	}
+	if student.AdvisorID == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Belum ada dosen pembimbing akademik. Hubungi admin."})
+	}
+
+	var krs models.KRS
+	if err := h.DB.Where("student_id = ? AND term_id = ?", user.UserID, term.ID).First(&krs).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "KRS belum diisi"})
+	}
+	if krs.Status != models.KRSDraft && krs.Status != models.KRSRejected {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "KRS sudah diajukan"})
+	}
+	if krs.TotalSKS == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "KRS masih kosong"})
+	}
+
+	now := time.Now()
+	krs.Status = models.KRSSubmitted
+	krs.AdvisorID = student.AdvisorID
+	krs.SubmittedAt = &now
+	krs.ReviewedBy, krs.ReviewedAt, krs.ReviewNote = nil, nil, ""
+	if err := h.DB.Save(&krs).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengajukan KRS"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    krs,
+		"message": "KRS diajukan ke dosen pembimbing akademik",
+	})
+}
+
+// GetAdviseeKRS lists the KRS of the caller's advisees this term (admin dev sees every KRS)
+// GET /api/academic/krs/advisees?status=
+func (h *AcademicHandler) GetAdviseeKRS(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	term, errResp := h.krsTerm(c)
+	if term == nil {
+		return errResp
+	}
+
+	query := h.DB.Preload("Student").Preload("Items.Subject").Preload("Items.Class").
+		Where("term_id = ?", term.ID)
+	if user.Role != models.RoleAdminDev {
+		query = query.Where("advisor_id = ?", user.UserID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var list []models.KRS
+	query.Order("submitted_at ASC NULLS LAST").Find(&list)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// ReviewKRS approves or rejects a submitted KRS. Approval writes the enrollments that feed
+// rosters; rejecting an approved KRS withdraws them so the student can revise it.
+// POST /api/academic/krs/:id/review
+func (h *AcademicHandler) ReviewKRS(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	krsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid KRS ID"})
+	}
+
+	var req ReviewKRSRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	krs, err := h.loadKRS(h.DB.Where("id = ?", krsID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "KRS tidak ditemukan"})
+	}
+	if user.Role != models.RoleAdminDev && (krs.AdvisorID == nil || *krs.AdvisorID != user.UserID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda bukan dosen pembimbing akademik mahasiswa ini"})
+	}
+	switch {
+	case req.Action == "approve" && krs.Status != models.KRSSubmitted:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Hanya KRS yang sudah diajukan yang bisa disetujui"})
+	case req.Action == "reject" && krs.Status != models.KRSSubmitted && krs.Status != models.KRSApproved:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "KRS belum diajukan"})
+	}
+
+	now := time.Now()
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("krs_id = ?", krs.ID).Delete(&models.Enrollment{}).Error; err != nil {
+			return err
+		}
+		status := models.KRSRejected
+		if req.Action == "approve" {
+			status = models.KRSApproved
+			enrollments := make([]models.Enrollment, 0, len(krs.Items))
+			for _, item := range krs.Items {
+				enrollments = append(enrollments, models.Enrollment{
+					StudentID: krs.StudentID,
+					SubjectID: item.SubjectID,
+					ClassID:   item.ClassID,
+					TermID:    krs.TermID,
+					KRSID:     &krs.ID,
+					IsRetake:  item.IsRetake,
+				})
+			}
+			if len(enrollments) > 0 {
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "student_id"}, {Name: "subject_id"}, {Name: "term_id"}},
+					DoUpdates: clause.AssignmentColumns([]string{"class_id", "krs_id", "is_retake"}),
+				}).Create(&enrollments).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(&models.KRS{}).Where("id = ?", krs.ID).Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": user.UserID,
+			"reviewed_at": now,
+			"review_note": strings.TrimSpace(req.Note),
+		}).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memproses KRS"})
+	}
+
+	message := "KRS disetujui"
+	if req.Action == "reject" {
+		message = "KRS dikembalikan ke mahasiswa"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+	})
+}
+
+// GetSectionRoster lists the students on a section's roster and where the roster comes from
+// GET /api/academic/krs/roster?subject_id=&class_id=
+func (h *AcademicHandler) GetSectionRoster(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	subjectID, err := uuid.Parse(c.Query("subject_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid subject_id required"})
+	}
+	classID, err := uuid.Parse(c.Query("class_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "valid class_id required"})
+	}
+	if !isAssigned(h.DB, user, subjectID, classID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Anda tidak ditugaskan mengajar mata kuliah ini di kelas tersebut"})
+	}
+
+	termID, _ := enrollment.ActiveTermID(h.DB)
+	var students []models.Profile
+	enrollment.Roster(h.DB, termID, subjectID, classID).Order("nim ASC").Find(&students)
+
+	var enrolled []models.Enrollment
+	h.DB.Where("term_id = ? AND subject_id = ? AND class_id = ?", termID, subjectID, classID).Find(&enrolled)
+	fromKRS := make(map[uuid.UUID]bool, len(enrolled))
+	retake := make(map[uuid.UUID]bool)
+	for _, e := range enrolled {
+		fromKRS[e.StudentID] = true
+		retake[e.StudentID] = e.IsRetake
+	}
+
+	// Students still without an approved KRS stay on the roster through their class
+	rows := make([]fiber.Map, 0, len(students))
+	viaKRS := 0
+	for _, s := range students {
+		from := "class"
+		if fromKRS[s.UserID] {
+			from = "krs"
+			viaKRS++
+		}
+		rows = append(rows, fiber.Map{
+			"user_id":   s.UserID,
+			"nim":       s.NIM,
+			"full_name": s.FullName,
+			"class_id":  s.ClassID,
+			"is_retake": retake[s.UserID],
+			"source":    from,
+		})
+	}
+
+	source := "mixed"
+	if viaKRS == 0 {
+		source = "class"
+	} else if viaKRS == len(students) {
+		source = "krs"
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    rows,
+		"source":  source,
+	})
+}
+
+// SetAdvisor makes a lecturer the academic advisor (dosen PA) of the given students
+// PUT /api/academic/advisors
+func (h *AcademicHandler) SetAdvisor(c *fiber.Ctx) error {
+	var req SetAdvisorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var advisor models.Profile
+	err := h.DB.Where("user_id = ? AND role = ?", req.AdvisorID, models.RoleAdminDosen).First(&advisor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Dosen pembimbing tidak ditemukan"})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memuat dosen"})
+	}
+
+	res := h.DB.Model(&models.Profile{}).Where("user_id IN ?", req.StudentIDs).Update("advisor_id", req.AdvisorID)
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan dosen pembimbing"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"updated": res.RowsAffected,
+		"message": advisor.FullName + " menjadi dosen pembimbing akademik",
+	})
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// NotificationHandler serves the in-app inbox, channel preferences and delivery status
+type NotificationHandler struct {
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Notify   *notify.Service
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(db *gorm.DB, validate *validator.Validate, notifier *notify.Service) *NotificationHandler {
+	return &NotificationHandler{DB: db, Validate: validate, Notify: notifier}
+}
+
+// NotificationPreferenceRequest represents the preferences payload
+type NotificationPreferenceRequest struct {
+	Language        string   `json:"language" validate:"omitempty,oneof=id en"`
+	EmailEnabled    bool     `json:"email_enabled"`
+	WhatsAppEnabled bool     `json:"whatsapp_enabled"`
+	Email           string   `json:"email" validate:"omitempty,email,max=200"`
+	Phone           string   `json:"phone" validate:"omitempty,max=20"`
+	MutedEvents     []string `json:"muted_events"`
+	QuietStart      string   `json:"quiet_start"` // "HH:MM" WIB, empty = no quiet hours
+	QuietEnd        string   `json:"quiet_end"`
+}
+
+// GetNotifications lists the caller's inbox, newest first
+// GET /api/notifications?unread=true&limit=50&before=<RFC3339>
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	query := h.DB.Where("user_id = ?", user.UserID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if before := c.Query("before"); before != "" {
+		t, err := time.Parse(time.RFC3339, before)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "before harus berformat RFC3339"})
+		}
+		query = query.Where("created_at < ?", t)
+	}
+
+	var notifications []models.Notification
+	query.Order("created_at DESC").Limit(limit).Find(&notifications)
+
+	var unread int64
+	h.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.UserID).Count(&unread)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    notifications,
+		"unread":  unread,
+	})
+}
+
+// GetNotificationUnreadCount returns the number of unread inbox entries
+// GET /api/notifications/unread-count
+func (h *NotificationHandler) GetNotificationUnreadCount(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var unread int64
+	h.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.UserID).Count(&unread)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"unread": unread},
+	})
+}
+
+// MarkNotificationRead marks one inbox entry as read
+// POST /api/notifications/:id/read
+func (h *NotificationHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid notification ID"})
+	}
+
+	res := h.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, user.UserID).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menandai notifikasi"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Notifikasi ditandai sudah dibaca",
+	})
+}
+
+// MarkAllNotificationsRead marks the whole inbox as read
+// POST /api/notifications/read-all
+func (h *NotificationHandler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	res := h.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", user.UserID).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menandai notifikasi"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"marked":  res.RowsAffected,
+		"message": "Semua notifikasi ditandai sudah dibaca",
+	})
+}
+
+// DeleteNotification removes an entry from the caller's inbox
+// DELETE /api/notifications/:id
+func (h *NotificationHandler) DeleteNotification(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid notification ID"})
+	}
+
+	res := h.DB.Where("id = ? AND user_id = ?", id, user.UserID).Delete(&models.Notification{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus notifikasi"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Notifikasi tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Notifikasi dihapus",
+	})
+}
+
+// GetNotificationPreferences returns the caller's channels and quiet hours, plus which
+// channels the server has configured
+// GET /api/notifications/preferences
+func (h *NotificationHandler) GetNotificationPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	pref := h.Notify.Preferences([]uuid.UUID{user.UserID})[user.UserID]
+	if pref.MutedEvents == nil {
+		pref.MutedEvents = pq.StringArray{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    pref,
+		"channels": fiber.Map{
+			models.ChannelInApp:    true,
+			models.ChannelEmail:    h.Notify.Senders[models.ChannelEmail] != nil,
+			models.ChannelWhatsApp: h.Notify.Senders[models.ChannelWhatsApp] != nil,
+		},
+		"events": notify.Events(),
+	})
+}
+
+// UpdateNotificationPreferences saves the caller's channels and quiet hours
+// PUT /api/notifications/preferences
+func (h *NotificationHandler) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req NotificationPreferenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+	if (req.QuietStart == "") != (req.QuietEnd == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "quiet_start dan quiet_end harus diisi bersamaan"})
+	}
+	if req.QuietStart != "" && (!clockPattern.MatchString(req.QuietStart) || !clockPattern.MatchString(req.QuietEnd)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "quiet_start dan quiet_end harus berformat HH:MM"})
+	}
+	if req.WhatsAppEnabled && strings.TrimSpace(req.Phone) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Nomor WhatsApp wajib diisi untuk mengaktifkan notifikasi WhatsApp"})
+	}
+	known := make(map[string]bool)
+	for _, e := range notify.Events() {
+		known[e] = true
+	}
+	for _, e := range req.MutedEvents {
+		if !known[e] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Jenis notifikasi tidak dikenal: " + e})
+		}
+	}
+
+	pref := models.NotificationPreference{
+		UserID:          user.UserID,
+		Language:        req.Language,
+		EmailEnabled:    req.EmailEnabled,
+		WhatsAppEnabled: req.WhatsAppEnabled,
+		Email:           strings.TrimSpace(req.Email),
+		Phone:           notify.NormalizePhone(req.Phone),
+		MutedEvents:     pq.StringArray(req.MutedEvents),
+		QuietStart:      req.QuietStart,
+		QuietEnd:        req.QuietEnd,
+	}
+	if pref.Language == "" {
+		pref.Language = "id"
+	}
+	if pref.MutedEvents == nil {
+		pref.MutedEvents = pq.StringArray{}
+	}
+	if err := h.DB.Save(&pref).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan preferensi notifikasi"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    pref,
+		"message": "Preferensi notifikasi disimpan",
+	})
+}
+
+// SendTestNotification sends a test message to the caller on every enabled channel
+// POST /api/notifications/test
+func (h *NotificationHandler) SendTestNotification(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var profile models.Profile
+	h.DB.Where("user_id = ?", user.UserID).First(&profile)
+
+	err := h.Notify.Publish(notify.Event{
+		Type:    notify.EventTest,
+		UserIDs: []uuid.UUID{user.UserID},
+		Link:    "/dashboard/profile",
+		Data:    map[string]interface{}{"name": profile.FullName},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengirim notifikasi tes"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Notifikasi tes masuk antrean",
+	})
+}
+
+// GetDeliveries lists email/WhatsApp deliveries with their status, for admins tracking failures
+// GET /api/notifications/deliveries?status=&channel=&user_id=
+func (h *NotificationHandler) GetDeliveries(c *fiber.Ctx) error {
+	query := h.DB.Preload("Notification")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "user_id tidak valid"})
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var deliveries []models.NotificationDelivery
+	query.Order("created_at DESC").Limit(200).Find(&deliveries)
+
+	var counts []struct {
+		Status string `json:"status"`
+		Total  int64  `json:"total"`
+	}
+	h.DB.Model(&models.NotificationDelivery{}).Select("status, count(*) AS total").Group("status").Scan(&counts)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    deliveries,
+		"counts":  counts,
+	})
+}
+
+// RetryDelivery puts a failed delivery back in the queue
+// POST /api/notifications/deliveries/:id/retry
+func (h *NotificationHandler) RetryDelivery(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid delivery ID"})
+	}
+
+	res := h.DB.Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ?", id, models.DeliveryFailed).
+		Updates(map[string]interface{}{"status": models.DeliveryPending, "attempts": 0, "next_attempt_at": time.Now()})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal mengantrekan ulang"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Pengiriman gagal tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pengiriman diantrekan ulang",
+	})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/points"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PointsHandler serves the participation points ledger, its rules and leaderboards
+type PointsHandler struct {
+	DB       *gorm.DB
+	Validate *validator.Validate
+}
+
+// NewPointsHandler creates a new points handler
+func NewPointsHandler(db *gorm.DB, validate *validator.Validate) *PointsHandler {
+	return &PointsHandler{DB: db, Validate: validate}
+}
+
+// PointRuleRequest updates a scoring rule
+type PointRuleRequest struct {
+	Points *int  `json:"points" validate:"required,min=-100,max=1000"`
+	Active *bool `json:"active"`
+}
+
+// Helper: Period bounds from ?period=week|semester|all (week takes ?date=, semester ?term_id=)
+func (h *PointsHandler) pointsPeriod(c *fiber.Ctx) (string, *time.Time, *time.Time, error) {
+	period := c.Query("period", "week")
+	switch period {
+	case "week":
+		ref := time.Now()
+		if raw := c.Query("date"); raw != "" {
+			d, err := time.ParseInLocation("2006-01-02", raw, WIB)
+			if err != nil {
+				return "", nil, nil, errors.New("Invalid date (YYYY-MM-DD)")
+			}
+			ref = d
+		}
+		from, to := points.WeekRange(ref)
+		return period, &from, &to, nil
+	case "semester":
+		from, to, err := h.semesterRange(c.Query("term_id"))
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return period, from, to, nil
+	case "all":
+		return period, nil, nil, nil
+	}
+	return "", nil, nil, errors.New("period must be week, semester or all")
+}
+
+// Helper: Bounds of the given academic term, or of the active one when termID is empty
+func (h *PointsHandler) semesterRange(termID string) (*time.Time, *time.Time, error) {
+	var term models.AcademicTerm
+	query := h.DB
+	if termID != "" {
+		id, err := uuid.Parse(termID)
+		if err != nil {
+			return nil, nil, errors.New("Invalid term_id")
+		}
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.First(&term).Error; err != nil {
+		return nil, nil, errors.New("Semester tidak ditemukan")
+	}
+	from := time.Date(term.StartDate.Year(), term.StartDate.Month(), term.StartDate.Day(), 0, 0, 0, 0, WIB)
+	to := time.Date(term.EndDate.Year(), term.EndDate.Month(), term.EndDate.Day()+1, 0, 0, 0, 0, WIB)
+	return &from, &to, nil
+}
+
+// GetPointRules lists the scoring rules
+// GET /api/points/rules
+func (h *PointsHandler) GetPointRules(c *fiber.Ctx) error {
+	var rules []models.PointRule
+	if err := h.DB.Order("key").Find(&rules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch rules"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": rules})
+}
+
+// UpdatePointRule changes a rule's points or switches it off, then rebuilds the ledger so
+// every leaderboard reflects the new rule
+// PUT /api/points/rules/:key
+func (h *PointsHandler) UpdatePointRule(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var rule models.PointRule
+	if err := h.DB.Where("key = ?", c.Params("key")).First(&rule).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Aturan poin tidak ditemukan"})
+	}
+
+	var req PointRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	updates := map[string]interface{}{
+		"points":     *req.Points,
+		"updated_at": time.Now(),
+		"updated_by": user.UserID,
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if err := h.DB.Model(&rule).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to update rule"})
+	}
+	h.DB.Where("key = ?", rule.Key).First(&rule)
+
+	report, err := points.Recompute(h.DB)
+	if err != nil {
+		// The rule is saved; the background worker picks it up on its next run
+		return c.JSON(fiber.Map{"success": true, "data": rule, "message": "Aturan disimpan, poin akan dihitung ulang otomatis"})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": fiber.Map{"rule": rule, "recompute": report}, "message": "Aturan disimpan dan poin dihitung ulang"})
+}
+
+// RecomputePoints rebuilds the ledger now instead of waiting for the worker
+// POST /api/points/recompute
+func (h *PointsHandler) RecomputePoints(c *fiber.Ctx) error {
+	report, err := points.Recompute(h.DB)
+	if errors.Is(err, points.ErrBusy) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": err.Error()})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghitung ulang poin: " + err.Error()})
+	}
+	return c.JSON(fiber.Map{"success": true, "data": report, "message": "Poin dihitung ulang"})
+}
+
+// GetPointsLeaderboard ranks students of a class (?scope=class, default own class), the whole
+// batch (?scope=batch) or the classes themselves (?scope=classes) over ?period=week|semester|all
+// GET /api/points/leaderboard
+func (h *PointsHandler) GetPointsLeaderboard(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	period, from, to, err := h.pointsPeriod(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	var standings []points.Standing
+	scope := c.Query("scope", "class")
+	switch scope {
+	case "class":
+		classID := user.ClassID
+		if raw := c.Query("class_id"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid class_id"})
+			}
+			classID = &id
+		}
+		if classID == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "class_id wajib diisi"})
+		}
+		standings, err = points.StudentStandings(h.DB, classID, from, to)
+	case "batch":
+		standings, err = points.StudentStandings(h.DB, nil, from, to)
+	case "classes":
+		standings, err = points.ClassStandings(h.DB, from, to)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "scope must be class, batch or classes"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to compute leaderboard"})
+	}
+
+	if limit := c.QueryInt("limit", 0); limit > 0 && limit < len(standings) {
+		standings = standings[:limit]
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    standings,
+		"meta":    fiber.Map{"scope": scope, "period": period, "from": from, "to": to},
+	})
+}
+
+// GetMyPointsHistory returns the caller's points history
+// GET /api/points/history
+func (h *PointsHandler) GetMyPointsHistory(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+	return h.pointsHistory(c, user.UserID)
+}
+
+// GetStudentPointsHistory returns a student's points history for staff allowed to see them
+// GET /api/points/history/:studentId
+func (h *PointsHandler) GetStudentPointsHistory(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	studentID, err := uuid.Parse(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid student ID"})
+	}
+	var student models.Profile
+	if err := h.DB.Where("user_id = ?", studentID).First(&student).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mahasiswa tidak ditemukan"})
+	}
+	if !canViewStudent(h.DB, user, &student) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"success": false, "error": "Access denied"})
+	}
+	return h.pointsHistory(c, studentID)
+}
+
+// Helper: Totals, per-rule breakdown and paginated ledger lines of one student
+func (h *PointsHandler) pointsHistory(c *fiber.Ctx, studentID uuid.UUID) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 50)
+	offset := (page - 1) * limit
+
+	sum := func(from, to *time.Time) int {
+		var total int
+		query := h.DB.Model(&models.PointEntry{}).Select("COALESCE(SUM(points), 0)").Where("student_id = ?", studentID)
+		if from != nil {
+			query = query.Where("occurred_at >= ? AND occurred_at < ?", *from, *to)
+		}
+		query.Scan(&total)
+		return total
+	}
+	weekFrom, weekTo := points.WeekRange(time.Now())
+	summary := fiber.Map{
+		"total": sum(nil, nil),
+		"week":  sum(&weekFrom, &weekTo),
+	}
+	if from, to, err := h.semesterRange(""); err == nil {
+		summary["semester"] = sum(from, to)
+	}
+
+	var byRule []struct {
+		RuleKey string `json:"rule_key"`
+		Name    string `json:"name"`
+		Entries int    `json:"entries"`
+		Points  int    `json:"points"`
+	}
+	h.DB.Table("points_ledger l").
+		Select("l.rule_key, r.name, COUNT(*) AS entries, SUM(l.points) AS points").
+		Joins("LEFT JOIN point_rules r ON r.key = l.rule_key").
+		Where("l.student_id = ?", studentID).
+		Group("l.rule_key, r.name").
+		Order("points DESC").
+		Scan(&byRule)
+	summary["by_rule"] = byRule
+
+	var total int64
+	var entries []models.PointEntry
+	query := h.DB.Model(&models.PointEntry{}).Where("student_id = ?", studentID)
+	if rule := c.Query("rule"); rule != "" {
+		query = query.Where("rule_key = ?", rule)
+	}
+	query.Count(&total)
+	if err := query.Order("occurred_at DESC, id").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch points history"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"summary": summary, "entries": entries},
+		"meta": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
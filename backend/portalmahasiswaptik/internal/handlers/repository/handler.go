@@ -3,7 +3,9 @@ package repository
 import (
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -27,7 +29,7 @@ func NewRepositoryHandler(db *gorm.DB, supabaseStorage *storage.SupabaseStorage)
 	}
 }
 
-// Mock Data
+// Semester is a curriculum semester as shown on the repository page
 type Semester struct {
 	ID       int      `json:"id"`
 	Name     string   `json:"name"`
@@ -35,98 +37,142 @@ type Semester struct {
 	Courses  []string `json:"courses"`
 }
 
-var mockSemesters = []Semester{
-	{ID: 1, Name: "Semester 1", Gradient: "from-primary/20 to-primary/5", Courses: []string{"Pengantar Teknologi Informasi", "Algoritma & Pemrograman", "Matematika Dasar", "Bahasa Inggris I", "Pendidikan Pancasila", "Fisika Dasar"}},
-	{ID: 2, Name: "Semester 2", Gradient: "from-success/20 to-success/5", Courses: []string{"Struktur Data", "Pemrograman Lanjut", "Kalkulus", "Bahasa Inggris II", "Statistika", "Sistem Digital"}},
-	{ID: 3, Name: "Semester 3", Gradient: "from-warning/20 to-warning/5", Courses: []string{"Basis Data", "Pemrograman Web", "Sistem Operasi", "Jaringan Komputer", "Interaksi Manusia Komputer", "Matematika Diskrit"}},
-	{ID: 4, Name: "Semester 4", Gradient: "from-destructive/20 to-destructive/5", Courses: []string{"Rekayasa Perangkat Lunak", "Pemrograman Mobile", "Keamanan Informasi", "Cloud Computing", "Data Mining", "Kecerdasan Buatan"}},
-	{ID: 5, Name: "Semester 5", Gradient: "from-accent/40 to-accent/10", Courses: []string{"Machine Learning", "Big Data", "Pemrograman IoT", "Manajemen Proyek TI", "Etika Profesi", "Kapita Slekta"}},
-	{ID: 6, Name: "Semester 6", Gradient: "from-primary/30 to-success/10", Courses: []string{"Deep Learning", "Blockchain", "DevOps", "Cyber Security", "Metodologi Penelitian", "Kerja Praktek"}},
-	{ID: 7, Name: "Semester 7", Gradient: "from-success/30 to-warning/10", Courses: []string{"Skripsi", "Seminar", "Magang Industri", "Pengembangan Karir", "Kewirausahaan Digital", "Proyek Akhir"}},
+// Card gradients, cycled by semester number
+var semesterGradients = []string{
+	"from-primary/20 to-primary/5",
+	"from-success/20 to-success/5",
+	"from-warning/20 to-warning/5",
+	"from-destructive/20 to-destructive/5",
+	"from-accent/40 to-accent/10",
+	"from-primary/30 to-success/10",
+	"from-success/30 to-warning/10",
 }
 
+// maxMaterialSize caps material uploads (matches the server body limit)
+const maxMaterialSize = 20 << 20
+
+// File is a material as listed on a course page. The URL is left out on purpose: downloads
+// go through DownloadMaterial, which enforces the quota.
 type File struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Size string `json:"size"`
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Description  *string   `json:"description,omitempty"`
+	Type         string    `json:"type"`
+	Size         string    `json:"size"`
+	SizeBytes    *int      `json:"size_bytes,omitempty"`
+	SubjectID    uuid.UUID `json:"subject_id"`
+	Semester     int       `json:"semester"`
+	UploadedBy   uuid.UUID `json:"uploaded_by"`
+	UploaderName string    `json:"uploader_name"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
-// GetSemesters returns list of semesters
-// GET /api/repository/semesters
-func (h *RepositoryHandler) GetSemesters(c *fiber.Ctx) error {
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    mockSemesters,
-	})
+// Course is a subject of a semester with a summary of its materials
+type Course struct {
+	ID         uuid.UUID  `json:"id"`
+	Code       string     `json:"code"`
+	Name       string     `json:"name"`
+	SKS        int        `json:"sks"`
+	Materials  int        `json:"materials"`
+	LastUpload *time.Time `json:"last_upload,omitempty"`
 }
 
-// GetFiles returns files for a specific course
-// GET /api/repository/files?course=...
-func (h *RepositoryHandler) GetFiles(c *fiber.Ctx) error {
-	courseName := c.Query("course")
-	if courseName == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "course parameter required"})
+// MaterialRequest carries the editable fields of a material (multipart form or JSON)
+type MaterialRequest struct {
+	SubjectID   string  `json:"subject_id" form:"subject_id"`
+	Title       string  `json:"title" form:"title"`
+	Description *string `json:"description" form:"description"`
+}
+
+// Kita izinkan MIME type standar untuk dokumen/gambar/video
+var allowedTypes = []string{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"video/mp4",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",       // Excel
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document", // Word
+	"text/plain; charset=utf-8",
+}
+
+// Helper: Human readable file size ("2.4 MB")
+func formatSize(size *int) string {
+	if size == nil {
+		return "-"
 	}
+	n := float64(*size)
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", n/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", n/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0f KB", n/(1<<10))
+	}
+	return fmt.Sprintf("%d B", *size)
+}
 
-	mockFiles := []File{
-		{ID: 1, Name: "Modul " + courseName + " - Pertemuan 1.pdf", Type: "document", Size: "2.4 MB"},
-		{ID: 2, Name: "Slide Presentasi Minggu 1.pptx", Type: "document", Size: "5.1 MB"},
-		{ID: 3, Name: "Video Pembelajaran - Intro.mp4", Type: "video", Size: "124 MB"},
-		{ID: 4, Name: "Latihan Soal Bab 1.pdf", Type: "document", Size: "890 KB"},
-		{ID: 5, Name: "Tutorial Praktikum.mp4", Type: "video", Size: "89 MB"},
-		{ID: 6, Name: "Rangkuman Materi.pdf", Type: "document", Size: "1.2 MB"},
+// Helper: Material category, limited by the materials.file_type CHECK (pdf, video, image, other)
+func materialType(contentType string) string {
+	switch {
+	case contentType == "application/pdf":
+		return "pdf"
+	case strings.HasPrefix(contentType, "video/"):
+		return "video"
+	case strings.HasPrefix(contentType, "image/"):
+		return "image"
 	}
+	return "other"
+}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    mockFiles,
-	})
+// Helper: Only the uploader and admin dev may change or remove a material
+func canManageMaterial(user middleware.UserContext, m *models.Material) bool {
+	return user.Role == models.RoleAdminDev || m.UploadedBy == user.UserID
 }
 
-// UploadToDrive handles file uploads to Supabase Storage (Repository Bucket)
-// POST /api/repository/upload-drive
-func (h *RepositoryHandler) UploadToDrive(c *fiber.Ctx) error {
+// Helper: Remove a material's object from storage; files hosted elsewhere are left alone
+func (h *RepositoryHandler) removeObject(fileURL string) {
 	if h.SupabaseStorage == nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Supabase Storage service not initialized"})
+		return
+	}
+	name, ok := h.SupabaseStorage.ObjectPath(fileURL)
+	if !ok {
+		return
+	}
+	if err := h.SupabaseStorage.DeleteFile(name); err != nil {
+		fmt.Printf("⚠️ Warning: Failed to delete storage object %s: %v\n", name, err)
 	}
+}
 
-	file, err := c.FormFile("file")
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "file is required"})
+// Helper: Validate an uploaded file by its magic bytes and store it under the subject's
+// folder. Returns the public URL, the material type and the size; the error is user facing.
+func (h *RepositoryHandler) storeUpload(file *multipart.FileHeader, subject *models.Subject) (string, string, int, *fiber.Error) {
+	if file.Size > maxMaterialSize {
+		return "", "", 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Ukuran file maksimal %d MB", maxMaterialSize>>20))
 	}
 
 	fileContent, err := file.Open()
 	if err != nil {
 		fmt.Printf("❌ Error: Failed to open file: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": "failed to open file"})
+		return "", "", 0, fiber.NewError(fiber.StatusInternalServerError, "failed to open file")
 	}
 	defer fileContent.Close()
 
 	// 1. Validasi Magic Bytes (MIME Sniffing) - Defense in Depth
 	// Read first 512 bytes to detect content type
 	buffer := make([]byte, 512)
-	_, err = fileContent.Read(buffer)
-	if err != nil && err != fmt.Errorf("EOF") {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to read file for validation"})
+	n, err := fileContent.Read(buffer)
+	if err != nil && err != io.EOF {
+		return "", "", 0, fiber.NewError(fiber.StatusInternalServerError, "failed to read file for validation")
 	}
 	// Reset file pointer after reading
 	if seeker, ok := fileContent.(io.Seeker); ok {
 		seeker.Seek(0, io.SeekStart)
 	}
 
-	detectedType := http.DetectContentType(buffer)
-	// Kita izinkan MIME type standar untuk dokumen/gambar
-	allowedTypes := []string{
-		"application/pdf",
-		"image/jpeg",
-		"image/png",
-		"image/gif",
-		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",       // Excel
-		"application/vnd.openxmlformats-officedocument.wordprocessingml.document", // Word
-		"text/plain; charset=utf-8",
-	}
-
+	detectedType := http.DetectContentType(buffer[:n])
 	isAllowed := false
 	for _, t := range allowedTypes {
 		if detectedType == t {
@@ -139,33 +185,296 @@ func (h *RepositoryHandler) UploadToDrive(c *fiber.Ctx) error {
 	fmt.Printf("🔍 Detected MIME Type: %s | Original Ext: %s\n", detectedType, file.Filename)
 
 	if !isAllowed && detectedType != "application/octet-stream" { // octet-stream is generic, handle with care
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Tipe file tidak diizinkan untuk alasan keamanan.",
+		return "", "", 0, fiber.NewError(fiber.StatusBadRequest, "Tipe file tidak diizinkan untuk alasan keamanan.")
+	}
+
+	// 2. Randomize Filename (Anti Path Traversal / Collision), grouped per semester/subject
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	objectName := fmt.Sprintf("%d/%s/%s%s", subject.Semester, subject.ID, uuid.New().String(), ext)
+
+	fmt.Printf("📤 Uploading: %s -> %s\n", file.Filename, objectName)
+
+	publicURL, err := h.SupabaseStorage.UploadFile(objectName, detectedType, fileContent)
+	if err != nil {
+		fmt.Printf("❌ Error: Upload to Supabase Storage failed: %v\n", err)
+		return "", "", 0, fiber.NewError(fiber.StatusInternalServerError, "Gagal mengunggah file ke storage.")
+	}
+	return publicURL, materialType(detectedType), int(file.Size), nil
+}
+
+// GetSemesters returns list of semesters
+// GET /api/repository/semesters
+func (h *RepositoryHandler) GetSemesters(c *fiber.Ctx) error {
+	var semesters []models.Semester
+	if err := h.DB.Order("id ASC").Find(&semesters).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch semesters"})
+	}
+	var subjects []models.Subject
+	h.DB.Order("name ASC").Find(&subjects)
+
+	result := make([]Semester, 0, len(semesters))
+	for i, sem := range semesters {
+		courses := []string{}
+		for _, s := range subjects {
+			if s.Semester == sem.ID {
+				courses = append(courses, s.Name)
+			}
+		}
+		result = append(result, Semester{
+			ID:       sem.ID,
+			Name:     sem.Name,
+			Gradient: semesterGradients[i%len(semesterGradients)],
+			Courses:  courses,
 		})
 	}
 
-	// 2. Randomize Filename (Anti Path Traversal / Collision)
-	ext := ""
-	if idx := strings.LastIndex(file.Filename, "."); idx != -1 {
-		ext = file.Filename[idx:]
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetCourses lists the subjects of a semester with their material counts
+// GET /api/repository/semesters/:id/courses
+func (h *RepositoryHandler) GetCourses(c *fiber.Ctx) error {
+	semester, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "invalid semester id"})
+	}
+
+	var courses []Course
+	if err := h.DB.Table("subjects s").
+		Select("s.id, s.code, s.name, s.sks, COUNT(m.id) AS materials, MAX(m.created_at) AS last_upload").
+		Joins("LEFT JOIN materials m ON m.subject_id = s.id").
+		Where("s.semester = ?", semester).
+		Group("s.id, s.code, s.name, s.sks").
+		Order("s.name ASC").
+		Scan(&courses).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch courses"})
 	}
-	randomName := fmt.Sprintf("%s%s", uuid.New().String(), ext)
 
-	fmt.Printf("📤 Uploading: %s -> %s\n", file.Filename, randomName)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    courses,
+	})
+}
+
+// GetFiles returns the materials of a course, by ?subject_id= or by ?course= (name)
+// GET /api/repository/files
+func (h *RepositoryHandler) GetFiles(c *fiber.Ctx) error {
+	query := h.DB.Table("materials m").
+		Select("m.*, COALESCE(p.full_name, '-') AS uploader_name").
+		Joins("LEFT JOIN profiles p ON p.user_id = m.uploaded_by")
+
+	if raw := c.Query("subject_id"); raw != "" {
+		subjectID, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid subject_id"})
+		}
+		query = query.Where("m.subject_id = ?", subjectID)
+	} else if courseName := c.Query("course"); courseName != "" {
+		query = query.Joins("JOIN subjects s ON s.id = m.subject_id").Where("s.name = ?", courseName)
+		if semester := c.QueryInt("semester"); semester > 0 {
+			query = query.Where("s.semester = ?", semester)
+		}
+	} else {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "subject_id or course parameter required"})
+	}
+	if fileType := c.Query("type"); fileType != "" {
+		query = query.Where("m.file_type = ?", fileType)
+	}
+
+	var rows []struct {
+		models.Material
+		UploaderName string
+	}
+	if err := query.Order("m.created_at DESC").Scan(&rows).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Failed to fetch materials"})
+	}
+
+	files := make([]File, len(rows))
+	for i, r := range rows {
+		files[i] = File{
+			ID:           r.ID,
+			Name:         r.Title,
+			Description:  r.Description,
+			Type:         r.FileType,
+			Size:         formatSize(r.FileSize),
+			SizeBytes:    r.FileSize,
+			SubjectID:    r.SubjectID,
+			Semester:     r.Semester,
+			UploadedBy:   r.UploadedBy,
+			UploaderName: r.UploaderName,
+			CreatedAt:    r.CreatedAt,
+			UpdatedAt:    r.UpdatedAt,
+		}
+	}
 
-	publicURL, err := h.SupabaseStorage.UploadFile(randomName, detectedType, fileContent)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    files,
+	})
+}
+
+// UploadToDrive stores a file in Supabase Storage (Repository Bucket) and records it as a
+// material of the given subject (form fields: file, subject_id, title, description)
+// POST /api/repository/upload-drive
+func (h *RepositoryHandler) UploadToDrive(c *fiber.Ctx) error {
+	if h.SupabaseStorage == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Supabase Storage service not initialized"})
+	}
+	user := c.Locals("user").(middleware.UserContext)
+
+	file, err := c.FormFile("file")
 	if err != nil {
-		fmt.Printf("❌ Error: Upload to Supabase Storage failed: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Gagal mengunggah file ke storage."})
+		return c.Status(400).JSON(fiber.Map{"error": "file is required"})
+	}
+
+	var req MaterialRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "subject_id is required"})
+	}
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Mata kuliah tidak ditemukan"})
+	}
+
+	publicURL, fileType, size, ferr := h.storeUpload(file, &subject)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = file.Filename
+	}
+	material := models.Material{
+		SubjectID:   subject.ID,
+		Title:       title,
+		Description: req.Description,
+		FileURL:     publicURL,
+		FileType:    fileType,
+		FileSize:    &size,
+		Semester:    subject.Semester,
+		UploadedBy:  user.UserID,
+	}
+	if err := h.DB.Create(&material).Error; err != nil {
+		// Don't leave an orphaned object behind
+		h.removeObject(publicURL)
+		return c.Status(500).JSON(fiber.Map{"error": "Gagal menyimpan data materi."})
 	}
 
 	return c.JSON(fiber.Map{
 		"success":      true,
+		"data":         material,
 		"file_name":    file.Filename,
 		"webViewLink":  publicURL,
 		"storage_type": "supabase_storage",
 	})
 }
+
+// UpdateMaterial edits a material's title, description or subject; an attached file
+// replaces the stored one, which is then deleted
+// PUT /api/repository/materials/:id
+func (h *RepositoryHandler) UpdateMaterial(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid material id"})
+	}
+	var material models.Material
+	if err := h.DB.Where("id = ?", id).First(&material).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "materi tidak ditemukan"})
+	}
+	if !canManageMaterial(user, &material) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Hanya pengunggah atau admin yang dapat mengubah materi ini"})
+	}
+
+	var req MaterialRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	subject := models.Subject{ID: material.SubjectID, Semester: material.Semester}
+	if req.SubjectID != "" {
+		subjectID, err := uuid.Parse(req.SubjectID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid subject_id"})
+		}
+		if err := h.DB.Where("id = ?", subjectID).First(&subject).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Mata kuliah tidak ditemukan"})
+		}
+	}
+
+	updates := map[string]interface{}{
+		"subject_id": subject.ID,
+		"semester":   subject.Semester,
+		"updated_at": time.Now(),
+	}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		updates["title"] = title
+	}
+	if req.Description != nil {
+		updates["description"] = req.Description
+	}
+
+	oldURL := ""
+	if file, err := c.FormFile("file"); err == nil {
+		if h.SupabaseStorage == nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Supabase Storage service not initialized"})
+		}
+		publicURL, fileType, size, ferr := h.storeUpload(file, &subject)
+		if ferr != nil {
+			return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+		}
+		updates["file_url"], updates["file_type"], updates["file_size"] = publicURL, fileType, size
+		oldURL = material.FileURL
+	}
+
+	if err := h.DB.Model(&material).Updates(updates).Error; err != nil {
+		if url, ok := updates["file_url"].(string); ok {
+			h.removeObject(url)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal memperbarui materi."})
+	}
+	if oldURL != "" {
+		h.removeObject(oldURL)
+	}
+
+	h.DB.Where("id = ?", id).First(&material)
+	return c.JSON(fiber.Map{"success": true, "data": material, "message": "Materi diperbarui"})
+}
+
+// DeleteMaterial removes a material and its stored file
+// DELETE /api/repository/materials/:id
+func (h *RepositoryHandler) DeleteMaterial(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid material id"})
+	}
+	var material models.Material
+	if err := h.DB.Where("id = ?", id).First(&material).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "materi tidak ditemukan"})
+	}
+	if !canManageMaterial(user, &material) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Hanya pengunggah atau admin yang dapat menghapus materi ini"})
+	}
+
+	if err := h.DB.Delete(&material).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Gagal menghapus materi."})
+	}
+	h.removeObject(material.FileURL)
+
+	return c.JSON(fiber.Map{"success": true, "message": "Materi dihapus"})
+}
+
 // DownloadMaterial handles material downloads with quota enforcement
 // GET /api/repository/download/:id
 func (h *RepositoryHandler) DownloadMaterial(c *fiber.Ctx) error {
@@ -194,7 +503,7 @@ func (h *RepositoryHandler) DownloadMaterial(c *fiber.Ctx) error {
 		Remaining  int       `json:"remaining"`
 		ResetAt    time.Time `json:"reset_at"`
 	}
-	
+
 	// Query check_download_quota (Pass ID as string to match TEXT in DB)
 	if err := h.DB.Raw("SELECT * FROM public.check_download_quota(?, ?, ?, ?)",
 		user.UserID, "material", user.Role, id.String()).Scan(&quota).Error; err != nil {
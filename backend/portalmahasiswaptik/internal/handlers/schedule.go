@@ -0,0 +1,646 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScheduleSlotRequest represents a create/update timetable slot payload
+type ScheduleSlotRequest struct {
+	ClassID    uuid.UUID `json:"class_id" validate:"required"`
+	SubjectID  uuid.UUID `json:"subject_id" validate:"required"`
+	LecturerID uuid.UUID `json:"lecturer_id" validate:"required"`
+	Semester   int       `json:"semester" validate:"min=0,max=14"` // defaults to the subject's semester
+	Day        int       `json:"day" validate:"required,min=1,max=7"`
+	StartTime  string    `json:"start_time" validate:"required"` // HH:MM WIB
+	EndTime    string    `json:"end_time" validate:"required"`   // HH:MM WIB
+	Room       string    `json:"room" validate:"required,max=100"`
+	ValidFrom  string    `json:"valid_from"`  // YYYY-MM-DD, optional
+	ValidUntil string    `json:"valid_until"` // YYYY-MM-DD, optional
+}
+
+// ScheduleConflict describes a clash between two slots on the same day
+type ScheduleConflict struct {
+	Type    string    `json:"type"` // room | lecturer | class
+	SlotID  uuid.UUID `json:"slot_id"`
+	OtherID uuid.UUID `json:"other_id"`
+	Day     string    `json:"day"`
+	Time    string    `json:"time"`
+	Detail  string    `json:"detail"`
+}
+
+// ScheduleItem is a slot as shown to users. subject/time/room/lecturer/isActive/isNext keep
+// the keys the frontend used with the old mock schedule.
+type ScheduleItem struct {
+	models.ScheduleSlot
+	SubjectName  string `json:"subject"`
+	SubjectCode  string `json:"subject_code"`
+	ClassName    string `json:"class_name"`
+	LecturerName string `json:"lecturer"`
+	DayName      string `json:"day_name"`
+	Time         string `json:"time"`
+	IsActive     bool   `json:"isActive"`
+	IsNext       bool   `json:"isNext"`
+}
+
+// Helper: ISO weekday (Senin = 1 … Minggu = 7)
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// Helper: Whether the slot runs on the given (WIB) date
+func slotValidOn(slot models.ScheduleSlot, day time.Time) bool {
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if slot.ValidFrom != nil && date.Before(time.Date(slot.ValidFrom.Year(), slot.ValidFrom.Month(), slot.ValidFrom.Day(), 0, 0, 0, 0, time.UTC)) {
+		return false
+	}
+	if slot.ValidUntil != nil && date.After(time.Date(slot.ValidUntil.Year(), slot.ValidUntil.Month(), slot.ValidUntil.Day(), 0, 0, 0, 0, time.UTC)) {
+		return false
+	}
+	return true
+}
+
+// Helper: Same term, same day, overlapping hours and overlapping validity ranges. Slots of
+// different terms never clash, even when their dates were left open.
+func slotsOverlap(a, b models.ScheduleSlot) bool {
+	if a.Day != b.Day || a.StartTime >= b.EndTime || b.StartTime >= a.EndTime {
+		return false
+	}
+	if a.TermID != nil && b.TermID != nil && *a.TermID != *b.TermID {
+		return false
+	}
+	if a.ValidFrom != nil && b.ValidUntil != nil && a.ValidFrom.After(*b.ValidUntil) {
+		return false
+	}
+	if b.ValidFrom != nil && a.ValidUntil != nil && b.ValidFrom.After(*a.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// scheduleConflicts lists the ways slot clashes with others (room, lecturer or class double-booked)
+func scheduleConflicts(slot models.ScheduleSlot, others []models.ScheduleSlot) []ScheduleConflict {
+	conflicts := []ScheduleConflict{}
+	for _, o := range others {
+		if o.ID == slot.ID || !slotsOverlap(slot, o) {
+			continue
+		}
+		base := ScheduleConflict{
+			SlotID:  slot.ID,
+			OtherID: o.ID,
+			Day:     models.ScheduleDayNames[o.Day],
+			Time:    o.StartTime + " - " + o.EndTime,
+		}
+		if strings.EqualFold(strings.TrimSpace(o.Room), strings.TrimSpace(slot.Room)) {
+			c := base
+			c.Type, c.Detail = "room", "Ruang "+o.Room+" sudah dipakai"
+			conflicts = append(conflicts, c)
+		}
+		if o.LecturerID == slot.LecturerID {
+			c := base
+			c.Type, c.Detail = "lecturer", "Dosen sudah mengajar di jam yang sama"
+			conflicts = append(conflicts, c)
+		}
+		if o.ClassID == slot.ClassID {
+			c := base
+			c.Type, c.Detail = "class", "Kelas sudah memiliki jadwal di jam yang sama"
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// Helper: Build ScheduleItems with subject, class and lecturer names
+func (h *AcademicHandler) scheduleItems(slots []models.ScheduleSlot) []ScheduleItem {
+	ids := make([]uuid.UUID, 0, len(slots))
+	for _, s := range slots {
+		ids = append(ids, s.LecturerID)
+	}
+	names := make(map[uuid.UUID]string)
+	if len(ids) > 0 {
+		var profiles []models.Profile
+		h.DB.Where("user_id IN ?", ids).Find(&profiles)
+		for _, p := range profiles {
+			names[p.UserID] = p.FullName
+		}
+	}
+
+	items := make([]ScheduleItem, len(slots))
+	for i, s := range slots {
+		item := ScheduleItem{
+			ScheduleSlot: s,
+			LecturerName: names[s.LecturerID],
+			DayName:      models.ScheduleDayNames[s.Day],
+			Time:         s.StartTime + " - " + s.EndTime,
+		}
+		if s.Subject != nil {
+			item.SubjectName = s.Subject.Name
+			item.SubjectCode = s.Subject.Code
+		}
+		if s.Class != nil {
+			item.ClassName = s.Class.Name
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// Helper: Slots for ?class_id= / ?lecturer_id= / ?semester=. Without filters students get
+// their class and lecturers their own teaching.
+func (h *AcademicHandler) scheduleQuery(c *fiber.Ctx) (*gorm.DB, error) {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := h.DB.Model(&models.ScheduleSlot{}).Preload("Subject").Preload("Class")
+	classID, lecturerID := c.Query("class_id"), c.Query("lecturer_id")
+	if classID == "" && lecturerID == "" {
+		switch {
+		case user.Role == models.RoleAdminDosen:
+			lecturerID = user.UserID.String()
+		case user.ClassID != nil:
+			classID = user.ClassID.String()
+		case user.Role != models.RoleAdminDev:
+			return nil, fmt.Errorf("class_id atau lecturer_id wajib diisi")
+		}
+	}
+	if classID != "" {
+		if _, err := uuid.Parse(classID); err != nil {
+			return nil, fmt.Errorf("class_id tidak valid")
+		}
+		query = query.Where("class_id = ?", classID)
+	}
+	if lecturerID != "" {
+		if _, err := uuid.Parse(lecturerID); err != nil {
+			return nil, fmt.Errorf("lecturer_id tidak valid")
+		}
+		query = query.Where("lecturer_id = ?", lecturerID)
+	}
+	if semester := c.QueryInt("semester", 0); semester > 0 {
+		query = query.Where("semester = ?", semester)
+	}
+	// Slots archived by the semester rollover only show up with ?all=true
+	if c.Query("all") != "true" {
+		query = query.Where("archived_at IS NULL")
+	}
+	return query, nil
+}
+
+// GetSchedule returns the weekly timetable grouped by day name
+// GET /api/academic/schedule?class_id=&lecturer_id=&semester=
+func (h *AcademicHandler) GetSchedule(c *fiber.Ctx) error {
+	query, err := h.scheduleQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	var slots []models.ScheduleSlot
+	query.Order("day ASC, start_time ASC").Find(&slots)
+
+	// Slots outside their validity window today are hidden unless ?all=true
+	if c.Query("all") != "true" {
+		today := time.Now().In(WIB)
+		current := slots[:0]
+		for _, s := range slots {
+			if slotValidOn(s, today) {
+				current = append(current, s)
+			}
+		}
+		slots = current
+	}
+
+	items := h.scheduleItems(slots)
+	markActiveAndNext(items, time.Now().In(WIB))
+
+	grouped := make(map[string][]ScheduleItem)
+	for day := 1; day <= 5; day++ {
+		grouped[models.ScheduleDayNames[day]] = []ScheduleItem{}
+	}
+	for _, item := range items {
+		grouped[item.DayName] = append(grouped[item.DayName], item)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    grouped,
+	})
+}
+
+// Helper: Flag the running slot and the next one today (items must be sorted by day/start)
+func markActiveAndNext(items []ScheduleItem, now time.Time) {
+	today := isoWeekday(now)
+	clock := now.Format("15:04")
+	nextMarked := false
+	for i := range items {
+		if items[i].Day != today || !slotValidOn(items[i].ScheduleSlot, now) {
+			continue
+		}
+		if items[i].StartTime <= clock && clock < items[i].EndTime {
+			items[i].IsActive = true
+		} else if items[i].StartTime > clock && !nextMarked {
+			items[i].IsNext = true
+			nextMarked = true
+		}
+	}
+}
+
+// GetTodaySchedule returns today's slots plus the running and next lecture, computed
+// from the server clock in Asia/Jakarta. When nothing is left today the next lecture
+// is searched over the coming week.
+// GET /api/academic/schedule/today?class_id=&lecturer_id=
+func (h *AcademicHandler) GetTodaySchedule(c *fiber.Ctx) error {
+	query, err := h.scheduleQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error()})
+	}
+
+	var slots []models.ScheduleSlot
+	query.Order("day ASC, start_time ASC").Find(&slots)
+	items := h.scheduleItems(slots)
+
+	now := time.Now().In(WIB)
+	clock := now.Format("15:04")
+
+	// Holidays of the coming week cancel every lecture on that date
+	var holidays []models.Holiday
+	h.DB.Where("date BETWEEN ? AND ?", now.Format("2006-01-02"), now.AddDate(0, 0, 7).Format("2006-01-02")).Find(&holidays)
+	holidayOn := make(map[string]string, len(holidays))
+	for _, hol := range holidays {
+		holidayOn[hol.Date.Format("2006-01-02")] = hol.Name
+	}
+
+	todayItems := []ScheduleItem{}
+	var current *ScheduleItem
+	var next fiber.Map
+	for _, item := range items {
+		if item.Day != isoWeekday(now) || !slotValidOn(item.ScheduleSlot, now) {
+			continue
+		}
+		if _, off := holidayOn[now.Format("2006-01-02")]; off {
+			todayItems = append(todayItems, item)
+			continue
+		}
+		if item.StartTime <= clock && clock < item.EndTime {
+			item.IsActive = true
+			active := item
+			current = &active
+		} else if item.StartTime > clock && next == nil {
+			item.IsNext = true
+			next = fiber.Map{"date": now.Format("2006-01-02"), "starts_in_minutes": minutesUntil(now, now, item.StartTime), "slot": item}
+		}
+		todayItems = append(todayItems, item)
+	}
+
+	// Nothing left today: first slot of the following days
+	for offset := 1; next == nil && offset <= 7; offset++ {
+		day := now.AddDate(0, 0, offset)
+		if _, off := holidayOn[day.Format("2006-01-02")]; off {
+			continue
+		}
+		for _, item := range items {
+			if item.Day == isoWeekday(day) && slotValidOn(item.ScheduleSlot, day) {
+				item.IsNext = true
+				next = fiber.Map{"date": day.Format("2006-01-02"), "starts_in_minutes": minutesUntil(now, day, item.StartTime), "slot": item}
+				break
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"now":      now.Format(time.RFC3339),
+			"day":      isoWeekday(now),
+			"day_name": models.ScheduleDayNames[isoWeekday(now)],
+			"holiday":  holidayOn[now.Format("2006-01-02")], // non-empty: today's lectures are cancelled
+			"today":    todayItems,
+			"current":  current,
+			"next":     next,
+		},
+	})
+}
+
+// Helper: Minutes from now until clock ("HH:MM") on the given day
+func minutesUntil(now, day time.Time, clock string) int {
+	start, err := clockOnDate(clock, day)
+	if err != nil {
+		return 0
+	}
+	return int(start.Sub(now).Minutes())
+}
+
+// GetScheduleConflicts lists every clash in the stored timetable
+// GET /api/academic/schedule/conflicts
+func (h *AcademicHandler) GetScheduleConflicts(c *fiber.Ctx) error {
+	var slots []models.ScheduleSlot
+	h.DB.Where("archived_at IS NULL").Order("day ASC, start_time ASC").Find(&slots)
+
+	conflicts := []ScheduleConflict{}
+	for i, slot := range slots {
+		// Each pair is reported once
+		conflicts = append(conflicts, scheduleConflicts(slot, slots[i+1:])...)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    conflicts,
+	})
+}
+
+// Helper: Validate the payload into a slot. Returns status and message on failure.
+func (h *AcademicHandler) buildScheduleSlot(req *ScheduleSlotRequest, slot *models.ScheduleSlot) (int, string) {
+	if !clockPattern.MatchString(req.StartTime) || !clockPattern.MatchString(req.EndTime) {
+		return fiber.StatusBadRequest, "start_time dan end_time harus berformat HH:MM"
+	}
+	if req.StartTime >= req.EndTime {
+		return fiber.StatusBadRequest, "end_time harus setelah start_time"
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", req.SubjectID).First(&subject).Error; err != nil {
+		return fiber.StatusNotFound, "Mata kuliah tidak ditemukan"
+	}
+	var classCount int64
+	h.DB.Model(&models.Class{}).Where("id = ?", req.ClassID).Count(&classCount)
+	if classCount == 0 {
+		return fiber.StatusNotFound, "Kelas tidak ditemukan"
+	}
+
+	semester := req.Semester
+	if semester == 0 {
+		semester = subject.Semester
+	}
+	var assigned int64
+	h.DB.Model(&models.TeachingAssignment{}).
+		Where("lecturer_id = ? AND subject_id = ? AND class_id = ? AND semester = ?", req.LecturerID, req.SubjectID, req.ClassID, semester).
+		Count(&assigned)
+	if assigned == 0 {
+		return fiber.StatusBadRequest, "Dosen belum ditugaskan mengajar mata kuliah ini di kelas tersebut pada semester ini"
+	}
+
+	slot.ValidFrom, slot.ValidUntil = nil, nil
+	if req.ValidFrom != "" {
+		d, err := time.ParseInLocation("2006-01-02", req.ValidFrom, WIB)
+		if err != nil {
+			return fiber.StatusBadRequest, "valid_from harus berformat YYYY-MM-DD"
+		}
+		slot.ValidFrom = &d
+	}
+	if req.ValidUntil != "" {
+		d, err := time.ParseInLocation("2006-01-02", req.ValidUntil, WIB)
+		if err != nil {
+			return fiber.StatusBadRequest, "valid_until harus berformat YYYY-MM-DD"
+		}
+		slot.ValidUntil = &d
+	}
+
+	// Slots belong to the active term and default to its dates
+	slot.TermID = nil
+	var term models.AcademicTerm
+	if err := h.DB.Where("is_active = ?", true).First(&term).Error; err == nil {
+		slot.TermID = &term.ID
+		if slot.ValidFrom == nil {
+			start := term.StartDate
+			slot.ValidFrom = &start
+		}
+		if slot.ValidUntil == nil {
+			end := term.EndDate
+			slot.ValidUntil = &end
+		}
+	}
+
+	if slot.ValidFrom != nil && slot.ValidUntil != nil && slot.ValidUntil.Before(*slot.ValidFrom) {
+		return fiber.StatusBadRequest, "valid_until harus setelah valid_from"
+	}
+
+	slot.ClassID = req.ClassID
+	slot.SubjectID = req.SubjectID
+	slot.LecturerID = req.LecturerID
+	slot.Semester = semester
+	slot.Day = req.Day
+	slot.StartTime = req.StartTime
+	slot.EndTime = req.EndTime
+	slot.Room = strings.TrimSpace(req.Room)
+	return 0, ""
+}
+
+// Helper: Conflicts of slot against the stored slots of the same day, term and dates
+func (h *AcademicHandler) findScheduleConflicts(slot models.ScheduleSlot) []ScheduleConflict {
+	query := h.DB.Where("day = ? AND id <> ? AND archived_at IS NULL", slot.Day, slot.ID)
+	if slot.TermID != nil {
+		query = query.Where("term_id = ? OR term_id IS NULL", *slot.TermID)
+	}
+	if slot.ValidFrom != nil {
+		query = query.Where("valid_until IS NULL OR valid_until >= ?", *slot.ValidFrom)
+	}
+	if slot.ValidUntil != nil {
+		query = query.Where("valid_from IS NULL OR valid_from <= ?", *slot.ValidUntil)
+	}
+
+	var sameDay []models.ScheduleSlot
+	query.Find(&sameDay)
+	return scheduleConflicts(slot, sameDay)
+}
+
+// CreateScheduleSlot adds a timetable slot, refusing room/lecturer/class conflicts
+// POST /api/academic/schedule
+func (h *AcademicHandler) CreateScheduleSlot(c *fiber.Ctx) error {
+	var req ScheduleSlotRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var slot models.ScheduleSlot
+	if status, msg := h.buildScheduleSlot(&req, &slot); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	if conflicts := h.findScheduleConflicts(slot); len(conflicts) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":   false,
+			"error":     "Jadwal bentrok dengan jadwal lain",
+			"conflicts": conflicts,
+		})
+	}
+
+	if err := h.DB.Create(&slot).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan jadwal"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    slot,
+		"message": "Jadwal berhasil ditambahkan",
+	})
+}
+
+// UpdateScheduleSlot replaces a timetable slot, refusing room/lecturer/class conflicts
+// PUT /api/academic/schedule/:id
+func (h *AcademicHandler) UpdateScheduleSlot(c *fiber.Ctx) error {
+	slotID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid schedule ID"})
+	}
+
+	var slot models.ScheduleSlot
+	if err := h.DB.Where("id = ?", slotID).First(&slot).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Jadwal tidak ditemukan"})
+	}
+	if slot.ArchivedAt != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Jadwal semester lalu sudah diarsipkan"})
+	}
+
+	var req ScheduleSlotRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	if status, msg := h.buildScheduleSlot(&req, &slot); status != 0 {
+		return c.Status(status).JSON(fiber.Map{"success": false, "error": msg})
+	}
+
+	if conflicts := h.findScheduleConflicts(slot); len(conflicts) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success":   false,
+			"error":     "Jadwal bentrok dengan jadwal lain",
+			"conflicts": conflicts,
+		})
+	}
+
+	if err := h.DB.Save(&slot).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal memperbarui jadwal"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    slot,
+		"message": "Jadwal berhasil diperbarui",
+	})
+}
+
+// DeleteScheduleSlot removes a timetable slot
+// DELETE /api/academic/schedule/:id
+func (h *AcademicHandler) DeleteScheduleSlot(c *fiber.Ctx) error {
+	slotID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid schedule ID"})
+	}
+
+	res := h.DB.Where("id = ?", slotID).Delete(&models.ScheduleSlot{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus jadwal"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Jadwal tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Jadwal berhasil dihapus",
+	})
+}
+
+// ========================================
+// HOLIDAYS
+// ========================================
+
+// HolidayRequest represents a new holiday
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// GetHolidays lists holidays (?year= filters one year)
+// GET /api/academic/holidays
+func (h *AcademicHandler) GetHolidays(c *fiber.Ctx) error {
+	query := h.DB.Model(&models.Holiday{})
+	if year := c.QueryInt("year", 0); year > 0 {
+		query = query.Where("EXTRACT(YEAR FROM date) = ?", year)
+	}
+
+	var holidays []models.Holiday
+	query.Order("date ASC").Find(&holidays)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    holidays,
+	})
+}
+
+// CreateHoliday adds a holiday; lectures on that date drop out of the schedule and calendar feeds
+// POST /api/academic/holidays
+func (h *AcademicHandler) CreateHoliday(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	var req HolidayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, WIB)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "date harus berformat YYYY-MM-DD"})
+	}
+
+	var dup int64
+	h.DB.Model(&models.Holiday{}).Where("date = ?", req.Date).Count(&dup)
+	if dup > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Tanggal tersebut sudah terdaftar sebagai hari libur"})
+	}
+
+	holiday := models.Holiday{Date: date, Name: strings.TrimSpace(req.Name), CreatedBy: &user.UserID}
+	if err := h.DB.Create(&holiday).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan hari libur"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    holiday,
+		"message": "Hari libur " + holiday.Name + " ditambahkan",
+	})
+}
+
+// DeleteHoliday removes a holiday
+// DELETE /api/academic/holidays/:id
+func (h *AcademicHandler) DeleteHoliday(c *fiber.Ctx) error {
+	holidayID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid holiday ID"})
+	}
+
+	res := h.DB.Where("id = ?", holidayID).Delete(&models.Holiday{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus hari libur"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Hari libur tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Hari libur berhasil dihapus",
+	})
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// isAssigned reports whether the user may act on subject × class.
+// Admin dev always may; lecturers need a teaching assignment for the class's current
+// semester; everyone else never.
+func isAssigned(db *gorm.DB, user middleware.UserContext, subjectID, classID uuid.UUID) bool {
+	if user.Role == models.RoleAdminDev {
+		return true
+	}
+	if user.Role != models.RoleAdminDosen {
+		return false
+	}
+
+	var count int64
+	db.Model(&models.TeachingAssignment{}).
+		Where("lecturer_id = ? AND subject_id = ? AND class_id = ?", user.UserID, subjectID, classID).
+		Where(enrollment.CurrentAssignment("teaching_assignments")).
+		Count(&count)
+	return count > 0
+}
+
+// teachesSubject reports whether the user may manage a subject's meetings and rules
+// (admin dev, or a lecturer assigned to the subject for at least one class this semester)
+func teachesSubject(db *gorm.DB, user middleware.UserContext, subjectID uuid.UUID) bool {
+	if user.Role == models.RoleAdminDev {
+		return true
+	}
+	if user.Role != models.RoleAdminDosen {
+		return false
+	}
+
+	var count int64
+	db.Model(&models.TeachingAssignment{}).
+		Where("lecturer_id = ? AND subject_id = ?", user.UserID, subjectID).
+		Where(enrollment.CurrentAssignment("teaching_assignments")).
+		Count(&count)
+	return count > 0
+}
+
+// canViewStudent reports whether the user may see a student's academic records: the student
+// themself, admin dev, the admin of the student's class, their academic advisor, or a lecturer
+// teaching that class or a section the student is enrolled in
+func canViewStudent(db *gorm.DB, user middleware.UserContext, student *models.Profile) bool {
+	if user.UserID == student.UserID || user.Role == models.RoleAdminDev {
+		return true
+	}
+	switch user.Role {
+	case models.RoleAdminKelas:
+		return user.ClassID != nil && student.ClassID != nil && *user.ClassID == *student.ClassID
+	case models.RoleAdminDosen:
+		if student.AdvisorID != nil && *student.AdvisorID == user.UserID {
+			return true
+		}
+		var count int64
+		if student.ClassID != nil {
+			db.Model(&models.TeachingAssignment{}).
+				Where("lecturer_id = ? AND class_id = ?", user.UserID, *student.ClassID).
+				Where(enrollment.CurrentAssignment("teaching_assignments")).
+				Count(&count)
+		}
+		if count == 0 {
+			db.Model(&models.Enrollment{}).
+				Joins("JOIN teaching_assignments ta ON ta.subject_id = enrollments.subject_id AND ta.class_id = enrollments.class_id").
+				Where("enrollments.student_id = ? AND ta.lecturer_id = ?", student.UserID, user.UserID).
+				Where(enrollment.CurrentAssignment("ta")).
+				Count(&count)
+		}
+		return count > 0
+	}
+	return false
+}
+
+// Helper: Assignment check for an existing session (loads the meeting when needed)
+func (h *AttendanceHandler) canManageSession(user middleware.UserContext, session *models.AttendanceSession) bool {
+	if user.Role == models.RoleAdminDev {
+		return true
+	}
+	subjectID := uuid.Nil
+	if session.Meeting != nil {
+		subjectID = session.Meeting.SubjectID
+	} else {
+		var meeting models.Meeting
+		if err := h.DB.Select("subject_id").Where("id = ?", session.MeetingID).First(&meeting).Error; err != nil {
+			return false
+		}
+		subjectID = meeting.SubjectID
+	}
+	return isAssigned(h.DB, user, subjectID, session.ClassID)
+}
+
+// assignedSessionScope limits a query on attendance_sessions to the lecturer's subject/class pairs
+func assignedSessionScope(user middleware.UserContext) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`EXISTS (
+			SELECT 1 FROM teaching_assignments ta
+			JOIN meetings m ON m.subject_id = ta.subject_id
+			WHERE m.id = attendance_sessions.meeting_id
+			  AND ta.class_id = attendance_sessions.class_id
+			  AND ta.lecturer_id = ?
+			  AND `+enrollment.CurrentAssignment("ta")+`
+		)`, user.UserID)
+	}
+}
+
+// TeachingAssignmentRequest represents a new assignment
+type TeachingAssignmentRequest struct {
+	LecturerID uuid.UUID `json:"lecturer_id" validate:"required"`
+	SubjectID  uuid.UUID `json:"subject_id" validate:"required"`
+	ClassID    uuid.UUID `json:"class_id" validate:"required"`
+	Semester   int       `json:"semester"` // defaults to the subject's semester
+}
+
+// GetAssignments lists teaching assignments (lecturers only see their own)
+// GET /api/academic/assignments?lecturer_id=&subject_id=&class_id=
+func (h *AcademicHandler) GetAssignments(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := h.DB.Model(&models.TeachingAssignment{}).Preload("Subject").Preload("Class")
+	if user.Role == models.RoleAdminDosen {
+		query = query.Where("lecturer_id = ?", user.UserID)
+	} else if lecturerID := c.Query("lecturer_id"); lecturerID != "" {
+		query = query.Where("lecturer_id = ?", lecturerID)
+	}
+	if subjectID := c.Query("subject_id"); subjectID != "" {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	if classID := c.Query("class_id"); classID != "" {
+		query = query.Where("class_id = ?", classID)
+	}
+
+	var assignments []models.TeachingAssignment
+	query.Order("semester ASC, created_at ASC").Find(&assignments)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.withLecturerNames(assignments),
+	})
+}
+
+// CreateAssignment assigns a lecturer to a subject for a class
+// POST /api/academic/assignments
+func (h *AcademicHandler) CreateAssignment(c *fiber.Ctx) error {
+	var req TeachingAssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid request body"})
+	}
+
+	// EXECUTE VALIDATION
+	if err := h.Validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Validasi Gagal: " + err.Error()})
+	}
+
+	var lecturer models.Profile
+	if err := h.DB.Where("user_id = ?", req.LecturerID).First(&lecturer).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Dosen tidak ditemukan"})
+	}
+	var roleCount int64
+	h.DB.Model(&models.UserRole{}).Where("user_id = ? AND role = ?", req.LecturerID, models.RoleAdminDosen).Count(&roleCount)
+	if roleCount == 0 && lecturer.Role != models.RoleAdminDosen {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Pengguna tersebut bukan dosen"})
+	}
+
+	var subject models.Subject
+	if err := h.DB.Where("id = ?", req.SubjectID).First(&subject).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Mata kuliah tidak ditemukan"})
+	}
+	var class models.Class
+	if err := h.DB.Where("id = ?", req.ClassID).First(&class).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Kelas tidak ditemukan"})
+	}
+
+	semester := req.Semester
+	if semester == 0 {
+		semester = subject.Semester
+	}
+
+	assignment := models.TeachingAssignment{
+		LecturerID: req.LecturerID,
+		SubjectID:  req.SubjectID,
+		ClassID:    req.ClassID,
+		Semester:   semester,
+	}
+	var dup int64
+	h.DB.Model(&models.TeachingAssignment{}).
+		Where("lecturer_id = ? AND subject_id = ? AND class_id = ? AND semester = ?", req.LecturerID, req.SubjectID, req.ClassID, semester).
+		Count(&dup)
+	if dup > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"success": false, "error": "Penugasan sudah ada"})
+	}
+
+	if err := h.DB.Create(&assignment).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menyimpan penugasan"})
+	}
+	assignment.Subject = &subject
+	assignment.Class = &class
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    assignment,
+		"message": lecturer.FullName + " ditugaskan mengajar " + subject.Name + " di kelas " + class.Name,
+	})
+}
+
+// DeleteAssignment removes a teaching assignment
+// DELETE /api/academic/assignments/:id
+func (h *AcademicHandler) DeleteAssignment(c *fiber.Ctx) error {
+	assignmentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Invalid assignment ID"})
+	}
+
+	res := h.DB.Where("id = ?", assignmentID).Delete(&models.TeachingAssignment{})
+	if res.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Gagal menghapus penugasan"})
+	}
+	if res.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"success": false, "error": "Penugasan tidak ditemukan"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Penugasan berhasil dihapus",
+	})
+}
+
+// GetMySubjects returns the caller's subjects with their lecturers.
+// Students see their class's assignments; lecturers see what they teach.
+// GET /api/academic/my-subjects
+func (h *AcademicHandler) GetMySubjects(c *fiber.Ctx) error {
+	user := c.Locals("user").(middleware.UserContext)
+
+	query := h.DB.Model(&models.TeachingAssignment{}).Preload("Subject").Preload("Class")
+	switch user.Role {
+	case models.RoleAdminDosen:
+		query = query.Where("lecturer_id = ?", user.UserID)
+	case models.RoleAdminDev:
+		if classID := c.Query("class_id"); classID != "" {
+			query = query.Where("class_id = ?", classID)
+		}
+	default:
+		if user.ClassID == nil {
+			return c.JSON(fiber.Map{"success": true, "data": []interface{}{}})
+		}
+		query = query.Where("class_id = ?", *user.ClassID)
+	}
+
+	var assignments []models.TeachingAssignment
+	query.Order("semester ASC").Find(&assignments)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.withLecturerNames(assignments),
+	})
+}
+
+// AssignmentView is an assignment with the lecturer's display name
+type AssignmentView struct {
+	models.TeachingAssignment
+	LecturerName string `json:"lecturer_name"`
+	LecturerNIP  string `json:"lecturer_nip"`
+}
+
+// Helper: Attach lecturer names (profiles are keyed by user_id, not a GORM relation)
+func (h *AcademicHandler) withLecturerNames(assignments []models.TeachingAssignment) []AssignmentView {
+	ids := make([]uuid.UUID, 0, len(assignments))
+	for _, a := range assignments {
+		ids = append(ids, a.LecturerID)
+	}
+	profiles := make(map[uuid.UUID]models.Profile)
+	if len(ids) > 0 {
+		var list []models.Profile
+		h.DB.Where("user_id IN ?", ids).Find(&list)
+		for _, p := range list {
+			profiles[p.UserID] = p
+		}
+	}
+
+	views := make([]AssignmentView, len(assignments))
+	for i, a := range assignments {
+		p := profiles[a.LecturerID]
+		views[i] = AssignmentView{TeachingAssignment: a, LecturerName: p.FullName, LecturerNIP: p.NIM}
+	}
+	return views
+}
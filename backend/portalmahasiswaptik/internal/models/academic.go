@@ -0,0 +1,259 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Semester is a curriculum level ("Semester 1" … "Semester 8"). Subjects, teaching
+// assignments and schedule slots reference it by ID; the calendar period a semester is
+// taught in is an AcademicTerm.
+type Semester struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+}
+
+func (Semester) TableName() string {
+	return "semesters"
+}
+
+// AcademicYear is a campus year such as "2026/2027", split into a ganjil and a genap term
+type AcademicYear struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name      string    `gorm:"type:text;not null;uniqueIndex" json:"name"`
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Terms []AcademicTerm `gorm:"foreignKey:AcademicYearID" json:"terms,omitempty"`
+}
+
+func (AcademicYear) TableName() string {
+	return "academic_years"
+}
+
+// Academic term parities
+const (
+	TermGanjil = "ganjil"
+	TermGenap  = "genap"
+)
+
+// AcademicTerm is the ganjil or genap half of an academic year. Exactly one term is active;
+// the semester rollover archives it and activates the next one.
+type AcademicTerm struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AcademicYearID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_academic_term" json:"academic_year_id"`
+	Parity         string     `gorm:"type:text;not null;uniqueIndex:idx_academic_term" json:"parity"` // ganjil, genap
+	Name           string     `gorm:"type:text;not null" json:"name"`                                 // "Ganjil 2026/2027"
+	StartDate      time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate        time.Time  `gorm:"type:date;not null" json:"end_date"`
+	IsActive       bool       `gorm:"default:false;index" json:"is_active"`
+	ArchivedAt     *time.Time `json:"archived_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"default:now()" json:"created_at"`
+
+	// Billing range (global_configs) that was in force when the term was archived
+	BillingStartMonth *int `json:"billing_start_month,omitempty"`
+	BillingEndMonth   *int `json:"billing_end_month,omitempty"`
+
+	// Relations
+	AcademicYear *AcademicYear `gorm:"foreignKey:AcademicYearID" json:"academic_year,omitempty"`
+}
+
+func (AcademicTerm) TableName() string {
+	return "academic_terms"
+}
+
+// ScheduleDayNames maps ScheduleSlot.Day (ISO weekday, Monday = 1) to the campus day name
+var ScheduleDayNames = map[int]string{
+	1: "Senin",
+	2: "Selasa",
+	3: "Rabu",
+	4: "Kamis",
+	5: "Jumat",
+	6: "Sabtu",
+	7: "Minggu",
+}
+
+// ScheduleSlot is one weekly lecture of a subject for a class. Slots belong to a semester
+// and may be bounded by the semester's first and last lecture dates.
+type ScheduleSlot struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ClassID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"class_id"`
+	SubjectID  uuid.UUID  `gorm:"type:uuid;not null" json:"subject_id"`
+	LecturerID uuid.UUID  `gorm:"type:uuid;not null;index" json:"lecturer_id"`
+	Semester   int        `gorm:"not null;index" json:"semester"`
+	Day        int        `gorm:"not null" json:"day"`                  // 1 = Senin … 7 = Minggu
+	StartTime  string     `gorm:"type:text;not null" json:"start_time"` // "HH:MM" WIB
+	EndTime    string     `gorm:"type:text;not null" json:"end_time"`   // "HH:MM" WIB
+	Room       string     `gorm:"type:text;not null" json:"room"`
+	ValidFrom  *time.Time `gorm:"type:date" json:"valid_from,omitempty"`  // nil = open-ended
+	ValidUntil *time.Time `gorm:"type:date" json:"valid_until,omitempty"` // nil = open-ended
+	TermID     *uuid.UUID `gorm:"type:uuid;index" json:"term_id,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"` // set by the semester rollover
+	CreatedAt  time.Time  `gorm:"default:now()" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Subject *Subject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+	Class   *Class   `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+}
+
+func (ScheduleSlot) TableName() string {
+	return "schedule_slots"
+}
+
+// Holiday cancels lectures on a date (national holidays, campus closures).
+// Calendar feeds publish it as an EXDATE on every recurring lecture.
+type Holiday struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Date      time.Time  `gorm:"type:date;not null;uniqueIndex" json:"date"`
+	Name      string     `gorm:"type:text;not null" json:"name"`
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (Holiday) TableName() string {
+	return "holidays"
+}
+
+// CalendarToken is the secret in a user's ICS subscription URL. Rotating or deleting it
+// revokes every calendar app subscribed with the old URL.
+type CalendarToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	Token      string     `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (CalendarToken) TableName() string {
+	return "calendar_tokens"
+}
+
+// GradeComponent is one weighted part of a subject's final score (tugas, UTS, UAS, …).
+// Weights of a subject add up to 100.
+type GradeComponent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubjectID uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_grade_component_name" json:"subject_id"`
+	Name      string    `gorm:"type:text;not null;uniqueIndex:idx_grade_component_name" json:"name"`
+	Weight    float64   `gorm:"type:numeric;not null" json:"weight"` // percent
+	Position  int       `gorm:"default:0" json:"position"`
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+}
+
+func (GradeComponent) TableName() string {
+	return "grade_components"
+}
+
+// GradeScore is a student's 0–100 score for one component
+type GradeScore struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_grade_score_term" json:"student_id"`
+	ComponentID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_grade_score_term;index" json:"component_id"`
+	SubjectID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"subject_id"`
+	TermID      uuid.UUID  `gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000000';uniqueIndex:idx_grade_score_term" json:"term_id"` // nil UUID = recorded before terms existed
+	Score       float64    `gorm:"type:numeric;not null" json:"score"`
+	UpdatedBy   *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (GradeScore) TableName() string {
+	return "grade_scores"
+}
+
+// StudentGrade is the computed final grade of a student for a subject in one term (a retake
+// gets its own row). It is recomputed whenever a score or the subject's components change;
+// students only see published grades.
+type StudentGrade struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_student_grade_term" json:"student_id"`
+	SubjectID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_student_grade_term;index" json:"subject_id"`
+	TermID     uuid.UUID  `gorm:"type:uuid;not null;default:'00000000-0000-0000-0000-000000000000';uniqueIndex:idx_student_grade_term" json:"term_id"` // nil UUID = graded before terms existed
+	Semester   int        `gorm:"not null" json:"semester"`
+	FinalScore *float64   `gorm:"type:numeric" json:"final_score,omitempty"` // nil until every component is scored
+	Letter     string     `gorm:"type:text" json:"letter"`
+	GradePoint float64    `gorm:"type:numeric;default:0" json:"grade_point"`
+	Published  bool       `gorm:"default:false" json:"published"`
+	UpdatedBy  *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Subject *Subject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+}
+
+func (StudentGrade) TableName() string {
+	return "student_grades"
+}
+
+// KRS statuses
+const (
+	KRSDraft     = "draft"
+	KRSSubmitted = "submitted"
+	KRSApproved  = "approved"
+	KRSRejected  = "rejected"
+)
+
+// KRS (Kartu Rencana Studi) is a student's course plan for one term. The student edits it
+// as a draft, submits it to their advisor, and approval turns its items into Enrollments.
+type KRS struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_krs_student_term" json:"student_id"`
+	TermID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_krs_student_term;index" json:"term_id"`
+	Status      string     `gorm:"type:text;not null;default:'draft'" json:"status"` // draft, submitted, approved, rejected
+	TotalSKS    int        `gorm:"default:0" json:"total_sks"`
+	MaxSKS      int        `gorm:"not null" json:"max_sks"`
+	AdvisorID   *uuid.UUID `gorm:"type:uuid;index" json:"advisor_id,omitempty"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty"`
+	ReviewedBy  *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote  string     `gorm:"type:text" json:"review_note,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:now()" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Items   []KRSItem `gorm:"foreignKey:KRSID" json:"items,omitempty"`
+	Student *Profile  `gorm:"foreignKey:StudentID;references:UserID" json:"student,omitempty"`
+}
+
+func (KRS) TableName() string {
+	return "krs"
+}
+
+// KRSItem is one subject on a KRS, taken with the given class (the student's own class or
+// another one, e.g. when retaking a course)
+type KRSItem struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	KRSID     uuid.UUID `gorm:"column:krs_id;type:uuid;not null;uniqueIndex:idx_krs_item" json:"krs_id"`
+	SubjectID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_krs_item" json:"subject_id"`
+	ClassID   uuid.UUID `gorm:"type:uuid;not null" json:"class_id"`
+	IsRetake  bool      `gorm:"default:false" json:"is_retake"`
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Subject *Subject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+	Class   *Class   `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+}
+
+func (KRSItem) TableName() string {
+	return "krs_items"
+}
+
+// Enrollment puts a student on the roster of a subject taught to a class in a term.
+// Approved KRS items become enrollments; attendance, grades and exports read rosters from here.
+type Enrollment struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_enrollment" json:"student_id"`
+	SubjectID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_enrollment;index:idx_enrollment_section" json:"subject_id"`
+	ClassID   uuid.UUID  `gorm:"type:uuid;not null;index:idx_enrollment_section" json:"class_id"`
+	TermID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_enrollment;index:idx_enrollment_section" json:"term_id"`
+	KRSID     *uuid.UUID `gorm:"column:krs_id;type:uuid;index" json:"krs_id,omitempty"`
+	IsRetake  bool       `gorm:"default:false" json:"is_retake"`
+	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (Enrollment) TableName() string {
+	return "enrollments"
+}
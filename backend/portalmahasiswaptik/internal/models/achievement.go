@@ -0,0 +1,105 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Achievement review statuses
+const (
+	AchievementPending  = "pending"
+	AchievementVerified = "verified"
+	AchievementRejected = "rejected"
+)
+
+// AchievementLevels are the competition levels, lowest first
+var AchievementLevels = []string{"internal", "regional", "nasional", "internasional"}
+
+// ClassAchievement is a competition result credited to a class. Members links it to the
+// students; StudentNames is kept in sync for the existing leaderboard page. Rows that
+// predate verification were entered by admins and count as verified.
+type ClassAchievement struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ClassID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"class_id"`
+	CompetitionName string     `gorm:"type:text;not null" json:"competition_name"`
+	StudentNames    string     `gorm:"type:text;not null" json:"student_names"` // comma separated, derived from Members
+	Rank            string     `gorm:"type:text;not null" json:"rank"`          // "Juara 1", "Harapan 1", "Finalis", ...
+	EventDate       time.Time  `gorm:"type:date;not null" json:"event_date"`
+	CompetitionID   *uuid.UUID `gorm:"type:uuid;index" json:"competition_id,omitempty"`
+	Level           string     `gorm:"type:text;default:'nasional'" json:"level"`
+	CertificateURL  *string    `gorm:"type:text" json:"certificate_url,omitempty"`
+	Status          string     `gorm:"type:text;default:'verified';index" json:"status"`
+	ReviewedBy      *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote      *string    `gorm:"type:text" json:"review_note,omitempty"`
+	CreatedAt       time.Time  `gorm:"default:now()" json:"created_at"`
+	CreatedBy       *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+
+	// Relations
+	Class       *Class              `gorm:"foreignKey:ClassID" json:"classes,omitempty"`
+	Members     []AchievementMember `gorm:"foreignKey:AchievementID" json:"members,omitempty"`
+	Competition *Competition        `gorm:"foreignKey:CompetitionID" json:"competition,omitempty"`
+}
+
+func (ClassAchievement) TableName() string {
+	return "class_achievements"
+}
+
+// AchievementMember credits an achievement to one student
+type AchievementMember struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AchievementID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_achievement_member" json:"achievement_id"`
+	StudentID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_achievement_member;index" json:"student_id"`
+
+	// Relations
+	Student *Profile `gorm:"foreignKey:StudentID;references:UserID" json:"student,omitempty"`
+}
+
+func (AchievementMember) TableName() string {
+	return "achievement_members"
+}
+
+var rankNumber = regexp.MustCompile(`\b([1-3]|i{1,3})\b`)
+
+// Placement reads the rank text: 1–3 for winners, 4 for harapan (honourable mention),
+// 5 for finalists and 0 for participation
+func (a ClassAchievement) Placement() int {
+	rank := strings.ToLower(a.Rank)
+	switch {
+	case strings.Contains(rank, "harapan"):
+		return 4
+	case strings.Contains(rank, "juara"):
+		switch rankNumber.FindString(strings.ReplaceAll(rank, "juara", "")) {
+		case "1", "i":
+			return 1
+		case "2", "ii":
+			return 2
+		case "3", "iii":
+			return 3
+		}
+		return 1 // "Juara Umum" and the like
+	case strings.Contains(rank, "emas"), strings.Contains(rank, "gold"):
+		return 1
+	case strings.Contains(rank, "perak"), strings.Contains(rank, "silver"):
+		return 2
+	case strings.Contains(rank, "perunggu"), strings.Contains(rank, "bronze"):
+		return 3
+	case strings.Contains(rank, "final"):
+		return 5
+	}
+	return 0
+}
+
+// Points scores the achievement for the leaderboards: the placement's base points scaled
+// by the competition level (internal ×1, regional ×1.5, nasional ×2, internasional ×3)
+func (a ClassAchievement) Points() int {
+	base := map[int]int{1: 100, 2: 80, 3: 60, 4: 40, 5: 25, 0: 10}[a.Placement()]
+	percent := map[string]int{"internal": 100, "regional": 150, "nasional": 200, "internasional": 300}[a.Level]
+	if percent == 0 {
+		percent = 100
+	}
+	return base * percent / 100
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TeachingAssignment grants a lecturer a subject for one class. Lecturers may only run
+// sessions, exports and overrides for the subject/class pairs they are assigned to.
+type TeachingAssignment struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LecturerID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_teaching_assignment" json:"lecturer_id"`
+	SubjectID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_teaching_assignment" json:"subject_id"`
+	ClassID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_teaching_assignment;index" json:"class_id"`
+	Semester   int       `gorm:"not null;uniqueIndex:idx_teaching_assignment" json:"semester"`
+	CreatedAt  time.Time `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Subject *Subject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+	Class   *Class   `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+}
+
+func (TeachingAssignment) TableName() string {
+	return "teaching_assignments"
+}
+
+// Attendance record statuses. "present" is the legacy value written before lateness existed.
+const (
+	AttendanceHadir     = "hadir"
+	AttendanceTerlambat = "terlambat"
+	AttendanceAlpa      = "alpa"
+	AttendanceIzin      = "izin"
+	AttendanceSakit     = "sakit"
+	AttendancePresent   = "present"
+)
+
+// Legacy statuses allowed by the original CHECK constraint (present/late/absent/excused)
+const (
+	AttendanceLate    = "late"
+	AttendanceAbsent  = "absent"
+	AttendanceExcused = "excused"
+)
+
+// AttendanceStatuses are the values attendance_records.status accepts
+var AttendanceStatuses = []string{
+	AttendanceHadir, AttendanceTerlambat, AttendanceAlpa, AttendanceIzin, AttendanceSakit,
+	AttendancePresent, AttendanceLate, AttendanceAbsent, AttendanceExcused,
+}
+
+// NormalizeAttendanceStatus maps a stored status, legacy values included, onto
+// hadir/terlambat/alpa/izin/sakit. Unknown values return "" so callers don't count them.
+func NormalizeAttendanceStatus(status string) string {
+	switch status {
+	case AttendanceHadir, AttendancePresent:
+		return AttendanceHadir
+	case AttendanceTerlambat, AttendanceLate:
+		return AttendanceTerlambat
+	case AttendanceAlpa, AttendanceAbsent, "alpha":
+		return AttendanceAlpa
+	case AttendanceIzin, AttendanceExcused, "permit":
+		return AttendanceIzin
+	case AttendanceSakit:
+		return AttendanceSakit
+	}
+	return ""
+}
+
+// IsAttendedStatus reports whether the status counts as physically present
+func IsAttendedStatus(status string) bool {
+	switch NormalizeAttendanceStatus(status) {
+	case AttendanceHadir, AttendanceTerlambat:
+		return true
+	}
+	return false
+}
+
+// SubjectTimingRule holds the default lateness rules for every session of a subject.
+// Sessions may override any field individually.
+type SubjectTimingRule struct {
+	ID                  uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubjectID           uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"subject_id"`
+	StartTime           *string    `gorm:"type:text" json:"start_time,omitempty"` // "HH:MM" WIB, applied to the session date
+	GraceMinutes        int        `gorm:"default:15" json:"grace_minutes"`
+	LateCutoffMinutes   int        `gorm:"default:60" json:"late_cutoff_minutes"`
+	AbsentCutoffMinutes int        `gorm:"default:0" json:"absent_cutoff_minutes"` // 0 = scans never rejected
+	UpdatedBy           *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (SubjectTimingRule) TableName() string {
+	return "subject_timing_rules"
+}
+
+// Leave request statuses
+const (
+	LeavePending  = "pending"
+	LeaveApproved = "approved"
+	LeaveRejected = "rejected"
+)
+
+// LeaveRequest is a student's izin/sakit request for a date range. Once approved it
+// takes precedence over automatic alpa marking for sessions held in that range.
+type LeaveRequest struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"student_id"`
+	ClassID       *uuid.UUID `gorm:"type:uuid;index" json:"class_id,omitempty"`
+	Type          string     `gorm:"type:text;not null" json:"type"` // izin | sakit
+	StartDate     time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate       time.Time  `gorm:"type:date;not null" json:"end_date"`
+	Reason        string     `gorm:"type:text;not null" json:"reason"`
+	AttachmentURL *string    `gorm:"type:text" json:"attachment_url,omitempty"`
+	Status        string     `gorm:"type:text;default:'pending';index" json:"status"`
+	ReviewedBy    *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    *string    `gorm:"type:text" json:"review_note,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (LeaveRequest) TableName() string {
+	return "leave_requests"
+}
+
+// Suspicious-scan detectors
+const (
+	FlagSharedDevice     = "shared_device"
+	FlagIPBurst          = "ip_burst"
+	FlagImpossibleTravel = "impossible_travel"
+	FlagIdenticalCoords  = "identical_coords"
+)
+
+// ScanFlag marks an attendance record a detector considered suspicious
+type ScanFlag struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SessionID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"session_id"`
+	RecordID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_scan_flag_record_detector" json:"record_id"`
+	StudentID   uuid.UUID  `gorm:"type:uuid;not null" json:"student_id"`
+	Detector    string     `gorm:"type:text;not null;uniqueIndex:idx_scan_flag_record_detector" json:"detector"`
+	Detail      string     `gorm:"type:text" json:"detail"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+	DismissedBy *uuid.UUID `gorm:"type:uuid" json:"dismissed_by,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (ScanFlag) TableName() string {
+	return "attendance_scan_flags"
+}
+
+// OfflineDevice is a student device registered for offline scans. The device keeps the
+// private half of an ECDSA P-256 key pair (non-extractable); only the public key is stored.
+type OfflineDevice struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	DeviceID  string    `gorm:"type:text;not null;uniqueIndex" json:"device_id"`
+	PublicKey []byte    `gorm:"type:bytea;not null" json:"-"` // PKIX (SPKI) DER
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+	UpdatedAt time.Time `gorm:"default:now()" json:"updated_at"`
+}
+
+func (OfflineDevice) TableName() string {
+	return "attendance_offline_devices"
+}
+
+// OfflineClaimUse records that a student redeemed an offline claim; the unique index makes
+// each claim nonce single-use per student
+type OfflineClaimUse struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SessionID uuid.UUID `gorm:"type:uuid;not null;index" json:"session_id"`
+	Nonce     string    `gorm:"type:text;not null;uniqueIndex:idx_offline_claim_use" json:"nonce"`
+	StudentID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_offline_claim_use" json:"student_id"`
+	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+}
+
+func (OfflineClaimUse) TableName() string {
+	return "attendance_offline_claim_uses"
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CompetitionCategories are the categories offered by the competitions page
+var CompetitionCategories = []string{"Hackathon", "Design", "Data Science", "Programming", "Startup", "Security"}
+
+// Competition is an external competition students can join (table from the information
+// module migration; description/location were added later)
+type Competition struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title       string     `gorm:"type:text;not null" json:"title"`
+	Organizer   string     `gorm:"type:text;not null" json:"organizer"`
+	Deadline    time.Time  `gorm:"type:date;not null;index" json:"deadline"` // last registration day, inclusive
+	EventDates  *string    `gorm:"type:text" json:"event_dates"`
+	TeamSize    *string    `gorm:"type:text" json:"team_size"` // free text: "1-3", "3 orang", "Individu", "Maks 5"
+	Prize       *string    `gorm:"type:text" json:"prize"`
+	LinkURL     *string    `gorm:"type:text" json:"link_url"`
+	Category    string     `gorm:"type:text;not null" json:"category"`
+	Badge       *string    `gorm:"type:text" json:"badge"`
+	Description *string    `gorm:"type:text" json:"description"`
+	Location    *string    `gorm:"type:text" json:"location"`
+	CreatedAt   time.Time  `gorm:"default:now()" json:"created_at"`
+	CreatedBy   *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+
+	// Computed per viewer
+	Bookmarked bool `gorm:"-" json:"bookmarked"`
+	Registered bool `gorm:"-" json:"registered"`
+}
+
+func (Competition) TableName() string {
+	return "competitions"
+}
+
+var teamSizeNumber = regexp.MustCompile(`\d+`)
+
+// TeamLimits parses TeamSize into the allowed number of members. ok is false when the text
+// carries no usable limit, in which case any team size is accepted.
+func (c Competition) TeamLimits() (min, max int, ok bool) {
+	if c.TeamSize == nil {
+		return 0, 0, false
+	}
+	text := strings.ToLower(strings.TrimSpace(*c.TeamSize))
+	if strings.Contains(text, "individu") || strings.Contains(text, "perorangan") {
+		return 1, 1, true
+	}
+	numbers := teamSizeNumber.FindAllString(text, 2)
+	switch len(numbers) {
+	case 0:
+		return 0, 0, false
+	case 1:
+		n, _ := strconv.Atoi(numbers[0])
+		if strings.Contains(text, "maks") || strings.Contains(text, "max") || strings.Contains(text, "hingga") {
+			return 1, n, true
+		}
+		return n, n, true
+	default:
+		a, _ := strconv.Atoi(numbers[0])
+		b, _ := strconv.Atoi(numbers[1])
+		if a > b {
+			a, b = b, a
+		}
+		return a, b, true
+	}
+}
+
+// CompetitionBookmark is a competition a user saved. RemindedAt is set once the deadline
+// reminder went out.
+type CompetitionBookmark struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CompetitionID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_competition_bookmark" json:"competition_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_competition_bookmark" json:"user_id"`
+	RemindedAt    *time.Time `json:"reminded_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:now()" json:"created_at"`
+}
+
+func (CompetitionBookmark) TableName() string {
+	return "competition_bookmarks"
+}
+
+// CompetitionTeam is a team registered for a competition by its leader
+type CompetitionTeam struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	CompetitionID uuid.UUID `gorm:"type:uuid;not null;index" json:"competition_id"`
+	Name          string    `gorm:"type:text;not null" json:"name"`
+	LeaderID      uuid.UUID `gorm:"type:uuid;not null" json:"leader_id"`
+	CreatedAt     time.Time `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Members     []CompetitionTeamMember `gorm:"foreignKey:TeamID" json:"members,omitempty"`
+	Competition *Competition            `gorm:"foreignKey:CompetitionID" json:"competition,omitempty"`
+}
+
+func (CompetitionTeam) TableName() string {
+	return "competition_teams"
+}
+
+// CompetitionTeamMember puts a student in a team. A student joins at most one team per
+// competition.
+type CompetitionTeamMember struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TeamID        uuid.UUID `gorm:"type:uuid;not null;index" json:"team_id"`
+	CompetitionID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_competition_member" json:"competition_id"`
+	StudentID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_competition_member" json:"student_id"`
+
+	// Relations
+	Student *Profile `gorm:"foreignKey:StudentID;references:UserID" json:"student,omitempty"`
+}
+
+func (CompetitionTeamMember) TableName() string {
+	return "competition_team_members"
+}
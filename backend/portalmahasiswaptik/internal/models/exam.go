@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Exam types
+const (
+	ExamUTS = "uts"
+	ExamUAS = "uas"
+)
+
+// DefaultExamMinAttendance is the attendance percentage needed to sit an exam when neither
+// the exam nor global_configs (exam_min_attendance) sets one
+const DefaultExamMinAttendance = 75.0
+
+// Exam is a UTS or UAS sitting of a subject. Every class taking the subject sits it together
+// and eligible students are seated across the exam's rooms.
+type Exam struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubjectID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"subject_id"`
+	TermID        *uuid.UUID `gorm:"type:uuid;index" json:"term_id,omitempty"`
+	Type          string     `gorm:"type:text;not null" json:"type"` // uts, uas
+	StartsAt      time.Time  `gorm:"type:timestamptz;not null;index" json:"starts_at"`
+	EndsAt        time.Time  `gorm:"type:timestamptz;not null" json:"ends_at"`
+	MinAttendance *float64   `gorm:"type:numeric" json:"min_attendance,omitempty"` // percent; nil = global default
+	Notes         string     `gorm:"type:text" json:"notes,omitempty"`
+	SeatedAt      *time.Time `json:"seated_at,omitempty"` // last seating generation
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:now()" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Subject *Subject   `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+	Rooms   []ExamRoom `gorm:"foreignKey:ExamID" json:"rooms,omitempty"`
+}
+
+func (Exam) TableName() string {
+	return "exams"
+}
+
+// ExamRoom is a room used by an exam, filled in Position order up to its capacity
+type ExamRoom struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ExamID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"exam_id"`
+	Room          string     `gorm:"type:text;not null" json:"room"`
+	Capacity      int        `gorm:"not null" json:"capacity"`
+	InvigilatorID *uuid.UUID `gorm:"type:uuid;index" json:"invigilator_id,omitempty"`
+	Position      int        `gorm:"default:0" json:"position"`
+}
+
+func (ExamRoom) TableName() string {
+	return "exam_rooms"
+}
+
+// ExamSeat places an eligible student in a room of an exam
+type ExamSeat struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ExamID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_exam_seat_student" json:"exam_id"`
+	StudentID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_exam_seat_student;index" json:"student_id"`
+	RoomID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_exam_seat_number" json:"room_id"`
+	SeatNumber     int       `gorm:"not null;uniqueIndex:idx_exam_seat_number" json:"seat_number"`
+	AttendanceRate float64   `gorm:"type:numeric;default:0" json:"attendance_rate"` // percent at seating time
+	CreatedAt      time.Time `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Room    *ExamRoom `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Student *Profile  `gorm:"foreignKey:StudentID;references:UserID" json:"student,omitempty"`
+}
+
+func (ExamSeat) TableName() string {
+	return "exam_seats"
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // AppRole represents the role enum type
@@ -21,6 +22,9 @@ type Class struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Name      string    `gorm:"type:text;not null" json:"name"`
 	CreatedAt time.Time `gorm:"default:now()" json:"created_at"`
+
+	// CurrentSemester is the curriculum semester the cohort is in; the rollover promotes it
+	CurrentSemester *int `json:"current_semester,omitempty"`
 }
 
 func (Class) TableName() string {
@@ -39,6 +43,9 @@ type Profile struct {
 	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
 	UpdatedAt time.Time  `gorm:"default:now()" json:"updated_at"`
 
+	// AdvisorID is the student's academic advisor (dosen PA), who approves their KRS
+	AdvisorID *uuid.UUID `gorm:"type:uuid;index" json:"advisor_id,omitempty"`
+
 	// Relations
 	Class *Class `gorm:"foreignKey:ClassID" json:"class,omitempty"`
 }
@@ -76,13 +83,17 @@ func (Subject) TableName() string {
 	return "subjects"
 }
 
+// MaxMeetings is the highest meeting number a subject may have (CHECK meeting_number BETWEEN 1 AND 14)
+const MaxMeetings = 14
+
 // Meeting represents meeting sessions per subject
 type Meeting struct {
-	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SubjectID     uuid.UUID `gorm:"type:uuid;not null" json:"subject_id"`
-	MeetingNumber int       `gorm:"not null" json:"meeting_number"`
-	Topic         *string   `gorm:"type:text" json:"topic,omitempty"`
-	CreatedAt     time.Time `gorm:"default:now()" json:"created_at"`
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SubjectID     uuid.UUID  `gorm:"type:uuid;not null" json:"subject_id"`
+	MeetingNumber int        `gorm:"not null" json:"meeting_number"`
+	Topic         *string    `gorm:"type:text" json:"topic,omitempty"`
+	Date          *time.Time `gorm:"type:date" json:"date,omitempty"`
+	CreatedAt     time.Time  `gorm:"default:now()" json:"created_at"`
 
 	// Relations
 	Subject *Subject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
@@ -103,6 +114,27 @@ type AttendanceSession struct {
 	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
 	CreatedAt  time.Time `gorm:"default:now()" json:"created_at"`
 
+	// RequireBiometric forces every scan to carry a WebAuthn assertion bound to the QR
+	RequireBiometric *bool `gorm:"default:false" json:"require_biometric"`
+
+	// Timing overrides; nil falls back to the subject's SubjectTimingRule
+	ScheduledStart      *time.Time `gorm:"type:timestamptz" json:"scheduled_start,omitempty"`
+	GraceMinutes        *int       `json:"grace_minutes,omitempty"`
+	LateCutoffMinutes   *int       `json:"late_cutoff_minutes,omitempty"`
+	AbsentCutoffMinutes *int       `json:"absent_cutoff_minutes,omitempty"`
+
+	// Offline mode: the QR carries a claim signed with OfflineKey that students may sync later
+	AllowOffline *bool  `gorm:"default:false" json:"allow_offline"`
+	OfflineKey   string `gorm:"type:text" json:"-"`
+
+	// FinalizedAt is set once the closer has written alpa/izin records for the class, or
+	// retired the session because a newer QR replaced it
+	FinalizedAt *time.Time `gorm:"index" json:"finalized_at,omitempty"`
+
+	// ArchivedAt is set by the semester rollover for sessions of the previous term; archived
+	// sessions can no longer be scanned or refreshed and no longer count for exam eligibility
+	ArchivedAt *time.Time `gorm:"index" json:"archived_at,omitempty"`
+
 	// Relations
 	Class   *Class   `gorm:"foreignKey:ClassID" json:"class,omitempty"`
 	Meeting *Meeting `gorm:"foreignKey:MeetingID" json:"meeting,omitempty"`
@@ -121,6 +153,31 @@ type AttendanceRecord struct {
 	Method    string    `gorm:"type:text;default:'qr'" json:"method"`
 	ScannedAt time.Time `gorm:"default:now()" json:"scanned_at"`
 
+	// BiometricVerified is true when the scan carried a valid WebAuthn assertion
+	BiometricVerified bool `gorm:"default:false" json:"biometric_verified"`
+
+	// MinutesLate is measured from the session's scheduled start (0 when on time)
+	MinutesLate int `gorm:"default:0" json:"minutes_late"`
+
+	// Scan context kept for fraud review
+	DeviceID  *string  `gorm:"type:text;index" json:"device_id,omitempty"`
+	IPAddress *string  `gorm:"type:text" json:"ip_address,omitempty"`
+	UserAgent *string  `gorm:"type:text" json:"user_agent,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
+	// Set when a lecturer voids the record after review; the status becomes alpa
+	InvalidatedAt *time.Time `json:"invalidated_at,omitempty"`
+	InvalidatedBy *uuid.UUID `gorm:"type:uuid" json:"invalidated_by,omitempty"`
+	InvalidReason *string    `gorm:"type:text" json:"invalid_reason,omitempty"`
+
+	// Manual override by a lecturer (method "manual")
+	OverriddenBy *uuid.UUID `gorm:"type:uuid" json:"overridden_by,omitempty"`
+	Note         *string    `gorm:"type:text" json:"note,omitempty"`
+
+	// UpdatedBy is the last user who wrote the record by hand (override or sheet import)
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+
 	// Relations
 	Session *AttendanceSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
 }
@@ -160,7 +217,7 @@ type WeeklyDue struct {
 	Amount     float64    `gorm:"type:numeric;default:5000" json:"amount"`
 	Status     string     `gorm:"type:text;default:'unpaid'" json:"status"` // unpaid, pending, paid
 	ProofURL   *string    `gorm:"type:text" json:"proof_url,omitempty"`
-	PaidAt     *time.Time `gorm:"type:timestamptz" json:"paid_at,omitempty"`
+	PaidAt     *time.Time `gorm:"type:timestamptz" json:"paid_at,omitempty"` // set by the set_weekly_dues_paid_at trigger
 	VerifiedBy *uuid.UUID `gorm:"type:uuid" json:"verified_by,omitempty"`
 	CreatedAt  time.Time  `gorm:"default:now()" json:"created_at"`
 }
@@ -169,23 +226,100 @@ func (WeeklyDue) TableName() string {
 	return "weekly_dues"
 }
 
-// Announcement represents system announcements
+// DueDate is the last day to pay the week: W1 on the 7th, W2 on the 14th, W3 on the 21st, W4 on the 28th
+func (d WeeklyDue) DueDate() time.Time {
+	return time.Date(d.Year, time.Month(d.Month), 7*d.WeekNumber, 0, 0, 0, 0, time.UTC)
+}
+
+// Dues reminder stages
+const (
+	DuesReminderFriendly = "friendly" // current week is due soon
+	DuesReminderOverdue  = "overdue"  // past weeks still unpaid
+	DuesReminderDigest   = "digest"   // arrears summary for the class treasurer
+)
+
+// DuesReminder logs a reminder that was sent, for throttling
+type DuesReminder struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID *uuid.UUID `gorm:"type:uuid;index" json:"student_id,omitempty"` // nil for class digests
+	ClassID   *uuid.UUID `gorm:"type:uuid;index" json:"class_id,omitempty"`
+	Stage     string     `gorm:"type:text;not null" json:"stage"`
+	Period    string     `gorm:"type:text" json:"period"` // billing week for friendly reminders, e.g. 2026-10-W3
+	Weeks     int        `gorm:"default:0" json:"weeks"`  // overdue weeks at the time
+	SentAt    time.Time  `gorm:"default:now()" json:"sent_at"`
+}
+
+func (DuesReminder) TableName() string {
+	return "dues_reminders"
+}
+
+// DuesDispensation pauses reminders for a student, e.g. while a payment plan is agreed
+type DuesDispensation struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	StudentID uuid.UUID  `gorm:"type:uuid;not null;index" json:"student_id"`
+	ClassID   *uuid.UUID `gorm:"type:uuid;index" json:"class_id,omitempty"`
+	Reason    string     `gorm:"type:text;not null" json:"reason"`
+	Until     *time.Time `gorm:"type:date" json:"until,omitempty"` // inclusive; nil = until lifted
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time  `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Student *Profile `gorm:"foreignKey:StudentID;references:UserID" json:"student,omitempty"`
+}
+
+func (DuesDispensation) TableName() string {
+	return "dues_dispensations"
+}
+
+// Announcement categories
+var AnnouncementCategories = []string{"Akademik", "Keuangan", "Event", "Lomba", "Sistem"}
+
+// Announcement priorities
+const (
+	AnnouncementNormal    = "normal"
+	AnnouncementImportant = "important"
+)
+
+// Announcement represents system announcements. Empty TargetClasses/TargetRoles reach everyone.
 type Announcement struct {
-	ID            uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title         string      `gorm:"type:text;not null" json:"title"`
-	Content       string      `gorm:"type:text;not null" json:"content"`
-	Category      string      `gorm:"type:text;default:'general'" json:"category"`
-	IsPinned      *bool       `gorm:"default:false" json:"is_pinned"`
-	TargetClasses []uuid.UUID `gorm:"type:uuid[]" json:"target_classes,omitempty"`
-	CreatedBy     uuid.UUID   `gorm:"type:uuid;not null" json:"created_by"`
-	ExpiresAt     *time.Time  `gorm:"type:timestamptz" json:"expires_at,omitempty"`
-	CreatedAt     time.Time   `gorm:"default:now()" json:"created_at"`
+	ID            uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title         string         `gorm:"type:text;not null" json:"title"`
+	Content       string         `gorm:"type:text;not null" json:"content"`
+	Category      string         `gorm:"type:text;default:'general'" json:"category"`
+	Priority      string         `gorm:"type:text;default:'normal'" json:"priority"` // normal, important
+	IsPinned      *bool          `gorm:"default:false" json:"is_pinned"`
+	TargetClasses pq.StringArray `gorm:"type:uuid[]" json:"target_classes,omitempty"`
+	TargetRoles   pq.StringArray `gorm:"type:text[]" json:"target_roles,omitempty"`
+	CreatedBy     uuid.UUID      `gorm:"type:uuid;not null" json:"created_by"`
+	PublishAt     *time.Time     `gorm:"type:timestamptz;index" json:"publish_at,omitempty"` // hidden from readers until then; nil = immediately
+	ExpiresAt     *time.Time     `gorm:"type:timestamptz" json:"expires_at,omitempty"`
+	NotifiedAt    *time.Time     `gorm:"type:timestamptz" json:"-"` // audience notified (at publish time)
+	CreatedAt     time.Time      `gorm:"default:now()" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// IsNew is computed per viewer: published and not yet read by them
+	IsNew bool `gorm:"-" json:"is_new"`
+
+	// Relations
+	Author *Profile `gorm:"foreignKey:CreatedBy;references:UserID" json:"author,omitempty"`
 }
 
 func (Announcement) TableName() string {
 	return "announcements"
 }
 
+// AnnouncementRead records that a user has read an announcement
+type AnnouncementRead struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	AnnouncementID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_announcement_read" json:"announcement_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_announcement_read;index" json:"user_id"`
+	ReadAt         time.Time `gorm:"default:now()" json:"read_at"`
+}
+
+func (AnnouncementRead) TableName() string {
+	return "announcement_reads"
+}
+
 // Material represents repository materials
 type Material struct {
 	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -193,7 +327,7 @@ type Material struct {
 	Title       string    `gorm:"type:text;not null" json:"title"`
 	Description *string   `gorm:"type:text" json:"description,omitempty"`
 	FileURL     string    `gorm:"type:text;not null" json:"file_url"`
-	FileType    string    `gorm:"type:text;not null" json:"file_type"` // pdf, video, image
+	FileType    string    `gorm:"type:text;not null" json:"file_type"` // pdf, video, image, other
 	FileSize    *int      `gorm:"type:integer" json:"file_size,omitempty"`
 	Semester    int       `gorm:"not null" json:"semester"`
 	UploadedBy  uuid.UUID `gorm:"type:uuid;not null" json:"uploaded_by"`
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Notification channels
+const (
+	ChannelInApp    = "in_app"
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Delivery statuses
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"  // gave up after the last retry
+	DeliverySkipped = "skipped" // no address for the channel
+)
+
+// Notification is an entry in a user's in-app inbox. Email and WhatsApp copies are
+// tracked as NotificationDelivery rows.
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_notification_user" json:"user_id"`
+	Event     string     `gorm:"type:text;not null" json:"event"` // template key, e.g. announcement.published
+	Title     string     `gorm:"type:text;not null" json:"title"`
+	Body      string     `gorm:"type:text;not null" json:"body"`
+	Link      string     `gorm:"type:text" json:"link,omitempty"` // frontend path
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"default:now();index:idx_notification_user" json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationDelivery is one attempt series to send a notification over an external channel
+type NotificationDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	NotificationID uuid.UUID  `gorm:"type:uuid;not null;index" json:"notification_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Channel        string     `gorm:"type:text;not null" json:"channel"` // email, whatsapp
+	Recipient      string     `gorm:"type:text" json:"recipient"`
+	Status         string     `gorm:"type:text;not null;default:'pending';index:idx_delivery_due" json:"status"`
+	Attempts       int        `gorm:"default:0" json:"attempts"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamptz;not null;default:now();index:idx_delivery_due" json:"next_attempt_at"` // delayed by quiet hours and retries
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"default:now()" json:"created_at"`
+
+	// Relations
+	Notification *Notification `gorm:"foreignKey:NotificationID" json:"notification,omitempty"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// NotificationPreference holds a user's channels and quiet hours. Users without a row get
+// the in-app inbox and email only, in Indonesian, without quiet hours.
+type NotificationPreference struct {
+	UserID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"user_id"`
+	Language        string         `gorm:"type:text;default:'id'" json:"language"` // id, en
+	EmailEnabled    bool           `json:"email_enabled"`
+	WhatsAppEnabled bool           `json:"whatsapp_enabled"`
+	Email           string         `gorm:"type:text" json:"email,omitempty"`       // overrides the login email
+	Phone           string         `gorm:"type:text" json:"phone,omitempty"`       // WhatsApp number, 62…
+	MutedEvents     pq.StringArray `gorm:"type:text[]" json:"muted_events"`        // events that stay in the inbox only
+	QuietStart      string         `gorm:"type:text" json:"quiet_start,omitempty"` // "HH:MM" WIB
+	QuietEnd        string         `gorm:"type:text" json:"quiet_end,omitempty"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
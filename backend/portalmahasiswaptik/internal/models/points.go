@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Point rule keys. Each rule awards its points once per source record.
+const (
+	RuleAttendanceOnTime = "attendance.on_time" // scan without lateness
+	RuleAttendanceLate   = "attendance.late"    // late scan
+	RuleAttendanceAbsent = "attendance.absent"  // alpa (use negative points to penalize)
+	RuleDuesEarly        = "dues.early"         // weekly dues paid before the due date
+	RuleDuesOnTime       = "dues.on_time"       // weekly dues paid on the due date
+	RuleAchievement      = "achievement.verified"
+)
+
+// PointRule is one configurable scoring rule. For RuleAchievement, Points is a percentage
+// of the achievement's own score (see ClassAchievement.Points); for the others it is awarded
+// as is.
+type PointRule struct {
+	Key         string     `gorm:"type:text;primary_key" json:"key"`
+	Name        string     `gorm:"type:text;not null" json:"name"`
+	Points      int        `gorm:"not null" json:"points"`
+	Active      bool       `gorm:"not null" json:"active"`
+	Description string     `gorm:"type:text" json:"description"`
+	UpdatedAt   time.Time  `gorm:"default:now()" json:"updated_at"`
+	UpdatedBy   *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+}
+
+func (PointRule) TableName() string {
+	return "point_rules"
+}
+
+// DefaultPointRules seeds point_rules on startup; existing rows are left alone
+var DefaultPointRules = []PointRule{
+	{Key: RuleAttendanceOnTime, Name: "Hadir tepat waktu", Points: 10, Active: true, Description: "Per pertemuan dengan scan tepat waktu"},
+	{Key: RuleAttendanceLate, Name: "Hadir terlambat", Points: 3, Active: true, Description: "Per pertemuan dengan scan terlambat"},
+	{Key: RuleAttendanceAbsent, Name: "Alpa", Points: 0, Active: true, Description: "Per pertemuan tanpa kehadiran; isi negatif untuk pengurangan poin"},
+	{Key: RuleDuesEarly, Name: "Bayar kas lebih awal", Points: 5, Active: true, Description: "Per minggu iuran yang lunas sebelum jatuh tempo"},
+	{Key: RuleDuesOnTime, Name: "Bayar kas tepat waktu", Points: 2, Active: true, Description: "Per minggu iuran yang lunas pada hari jatuh tempo"},
+	{Key: RuleAchievement, Name: "Prestasi terverifikasi", Points: 100, Active: true, Description: "Persentase dari skor prestasi (peringkat × tingkat lomba)"},
+}
+
+// PointEntry is one line of the points ledger. The ledger is rebuilt from its sources
+// (attendance, dues, achievements) whenever the rules change, so rows are never edited by
+// hand; the ID is derived from rule, source and student and stays stable across rebuilds.
+type PointEntry struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	StudentID  uuid.UUID `gorm:"type:uuid;not null;index:idx_points_student" json:"student_id"`
+	RuleKey    string    `gorm:"type:text;not null" json:"rule_key"`
+	Points     int       `gorm:"not null" json:"points"`
+	SourceType string    `gorm:"type:text;not null" json:"source_type"` // attendance_record, weekly_due, class_achievement
+	SourceID   uuid.UUID `gorm:"type:uuid;not null" json:"source_id"`
+	Reason     string    `gorm:"type:text" json:"reason"`
+	OccurredAt time.Time `gorm:"not null;index:idx_points_student" json:"occurred_at"`
+}
+
+func (PointEntry) TableName() string {
+	return "points_ledger"
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AnnouncementAudience returns a query over profiles (alias p) of everyone an announcement is
+// addressed to, except its author: members of the target classes (lecturers through the
+// classes they teach) with a target role, or everyone when no target is set.
+func AnnouncementAudience(db *gorm.DB, a *models.Announcement) *gorm.DB {
+	query := db.Table("profiles p").Where("p.user_id <> ?", a.CreatedBy)
+	if len(a.TargetClasses) > 0 {
+		classes := []string(a.TargetClasses)
+		query = query.Where("p.class_id IN ? OR (p.role = ? AND EXISTS (SELECT 1 FROM teaching_assignments t WHERE t.lecturer_id = p.user_id AND t.class_id IN ?))",
+			classes, models.RoleAdminDosen, classes)
+	}
+	if len(a.TargetRoles) > 0 {
+		query = query.Where("p.role IN ?", []string(a.TargetRoles))
+	}
+	return query
+}
+
+// PublishAnnouncement notifies the audience of a published announcement
+func (s *Service) PublishAnnouncement(a *models.Announcement) error {
+	if s == nil {
+		return nil
+	}
+	var userIDs []uuid.UUID
+	AnnouncementAudience(s.DB, a).Pluck("p.user_id", &userIDs)
+	return s.Publish(Event{
+		Type:    EventAnnouncement,
+		UserIDs: userIDs,
+		Link:    "/dashboard/announcements",
+		Data: map[string]interface{}{
+			"title":     a.Title,
+			"category":  a.Category,
+			"important": a.Priority == models.AnnouncementImportant,
+			"excerpt":   Excerpt(a.Content, 280),
+		},
+	})
+}
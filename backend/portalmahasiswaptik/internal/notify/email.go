@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+)
+
+// SMTPSender sends plain-text email. Without a username it sends unauthenticated, which
+// is what local sinks such as MailHog or smtp4dev expect.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPSenderFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and
+// SMTP_FROM. Returns nil when SMTP_HOST is unset.
+func NewSMTPSenderFromEnv() *SMTPSender {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	s := &SMTPSender{
+		Host:     host,
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if s.Port == "" {
+		s.Port = "587"
+	}
+	if s.From == "" {
+		s.From = "Portal Mahasiswa PTIK <no-reply@localhost>"
+	}
+	return s
+}
+
+func (s *SMTPSender) Channel() string { return models.ChannelEmail }
+
+// Send delivers the message to one address. STARTTLS is used when the server offers it.
+func (s *SMTPSender) Send(ctx context.Context, to string, msg Message) error {
+	body := msg.Body
+	if msg.URL != "" {
+		body += "\r\n\r\n" + msg.URL
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	from := s.From
+	if addr, err := parseAddress(s.From); err == nil {
+		from = addr
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, from, []string{to}, []byte(b.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Helper: Bare address of "Name <addr>"
+func parseAddress(from string) (string, error) {
+	start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">")
+	if start == -1 || end < start {
+		return "", fmt.Errorf("no angle address in %q", from)
+	}
+	return from[start+1 : end], nil
+}
@@ -0,0 +1,254 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WIB is the clock quiet hours are expressed in
+var WIB = time.FixedZone("WIB", 7*3600)
+
+// Message is a rendered notification ready for a channel
+type Message struct {
+	Subject string
+	Body    string
+	URL     string // absolute link into the portal, if any
+}
+
+// Sender delivers messages over one external channel
+type Sender interface {
+	Channel() string
+	Send(ctx context.Context, to string, msg Message) error
+}
+
+// Event is something worth telling users about. Type selects the template; Data fills it.
+type Event struct {
+	Type    string
+	UserIDs []uuid.UUID
+	Data    map[string]interface{}
+	Link    string // frontend path, e.g. /dashboard/announcements
+}
+
+// Service writes inbox entries and queues email/WhatsApp deliveries. The dispatcher worker
+// sends the queued deliveries with retries.
+type Service struct {
+	DB      *gorm.DB
+	Senders map[string]Sender
+	BaseURL string // portal URL used for links in email/WhatsApp
+}
+
+// NewService configures the channels from the environment: SMTP_* for email and
+// WHATSAPP_GATEWAY_* for WhatsApp. Unconfigured channels are left out.
+func NewService(db *gorm.DB) *Service {
+	s := &Service{
+		DB:      db,
+		Senders: make(map[string]Sender),
+		BaseURL: strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"),
+	}
+	if smtp := NewSMTPSenderFromEnv(); smtp != nil {
+		s.Senders[models.ChannelEmail] = smtp
+	}
+	if wa := NewWhatsAppSenderFromEnv(); wa != nil {
+		s.Senders[models.ChannelWhatsApp] = wa
+	}
+	return s
+}
+
+// Helper: Default preferences for users without a row
+func defaultPreference(userID uuid.UUID) models.NotificationPreference {
+	return models.NotificationPreference{UserID: userID, Language: "id", EmailEnabled: true}
+}
+
+// Preferences returns the preferences of the users, filling in defaults
+func (s *Service) Preferences(userIDs []uuid.UUID) map[uuid.UUID]models.NotificationPreference {
+	prefs := make(map[uuid.UUID]models.NotificationPreference, len(userIDs))
+	for _, id := range userIDs {
+		prefs[id] = defaultPreference(id)
+	}
+	var rows []models.NotificationPreference
+	s.DB.Where("user_id IN ?", userIDs).Find(&rows)
+	for _, p := range rows {
+		prefs[p.UserID] = p
+	}
+	return prefs
+}
+
+// Helper: Login emails from Supabase auth, for users who did not set one in their preferences
+func (s *Service) loginEmails(userIDs []uuid.UUID) map[uuid.UUID]string {
+	emails := make(map[uuid.UUID]string)
+	var rows []struct {
+		ID    uuid.UUID
+		Email string
+	}
+	if err := s.DB.Raw("SELECT id, email FROM auth.users WHERE id IN ?", userIDs).Scan(&rows).Error; err != nil {
+		return emails
+	}
+	for _, r := range rows {
+		emails[r.ID] = r.Email
+	}
+	return emails
+}
+
+// QuietUntil returns when a delivery may go out: now, or the end of the user's quiet hours
+func QuietUntil(pref models.NotificationPreference, now time.Time) time.Time {
+	if pref.QuietStart == "" || pref.QuietEnd == "" || pref.QuietStart == pref.QuietEnd {
+		return now
+	}
+	local := now.In(WIB)
+	clock := local.Format("15:04")
+	var quiet bool
+	if pref.QuietStart < pref.QuietEnd {
+		quiet = clock >= pref.QuietStart && clock < pref.QuietEnd
+	} else { // spans midnight, e.g. 22:00–06:00
+		quiet = clock >= pref.QuietStart || clock < pref.QuietEnd
+	}
+	if !quiet {
+		return now
+	}
+
+	end, err := time.ParseInLocation("15:04", pref.QuietEnd, WIB)
+	if err != nil {
+		return now
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, WIB)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// Publish adds the event to every recipient's inbox and queues a delivery for each external
+// channel they enabled. It never sends anything itself, so it is cheap to call from handlers.
+// A nil service does nothing.
+func (s *Service) Publish(ev Event) error {
+	if s == nil || len(ev.UserIDs) == 0 {
+		return nil
+	}
+	if _, ok := templates[ev.Type]; !ok {
+		return fmt.Errorf("notify: unknown event %q", ev.Type)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(ev.UserIDs))
+	userIDs := make([]uuid.UUID, 0, len(ev.UserIDs))
+	for _, id := range ev.UserIDs {
+		if !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	prefs := s.Preferences(userIDs)
+	var emails map[uuid.UUID]string
+	if s.Senders[models.ChannelEmail] != nil {
+		emails = s.loginEmails(userIDs)
+	}
+
+	now := time.Now()
+	rendered := make(map[string]Message)
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, userID := range userIDs {
+			pref := prefs[userID]
+			msg, ok := rendered[pref.Language]
+			if !ok {
+				var err error
+				if msg, err = Render(ev.Type, pref.Language, ev.Data); err != nil {
+					return err
+				}
+				rendered[pref.Language] = msg
+			}
+
+			notification := models.Notification{UserID: userID, Event: ev.Type, Title: msg.Subject, Body: msg.Body, Link: ev.Link}
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+			if isMuted(pref, ev.Type) {
+				continue
+			}
+
+			var deliveries []models.NotificationDelivery
+			due := QuietUntil(pref, now)
+			if pref.EmailEnabled && s.Senders[models.ChannelEmail] != nil {
+				to := pref.Email
+				if to == "" {
+					to = emails[userID]
+				}
+				deliveries = append(deliveries, newDelivery(notification, models.ChannelEmail, to, due))
+			}
+			if pref.WhatsAppEnabled && s.Senders[models.ChannelWhatsApp] != nil {
+				deliveries = append(deliveries, newDelivery(notification, models.ChannelWhatsApp, pref.Phone, due))
+			}
+			if len(deliveries) > 0 {
+				if err := tx.Create(&deliveries).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// PublishAsync publishes in the background and logs failures, for handlers that should not
+// wait on (or fail because of) notifications
+func (s *Service) PublishAsync(ev Event) {
+	if s == nil {
+		return
+	}
+	go func() {
+		if err := s.Publish(ev); err != nil {
+			log.Printf("⚠️ Notify %s: %v", ev.Type, err)
+		}
+	}()
+}
+
+// Helper: Whether the user only wants the event in their inbox
+func isMuted(pref models.NotificationPreference, event string) bool {
+	for _, e := range pref.MutedEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper: A queued delivery, skipped right away when there is no address
+func newDelivery(n models.Notification, channel, to string, due time.Time) models.NotificationDelivery {
+	d := models.NotificationDelivery{
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+		Channel:        channel,
+		Recipient:      strings.TrimSpace(to),
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  due,
+	}
+	if d.Recipient == "" {
+		d.Status = models.DeliverySkipped
+		d.LastError = "no " + channel + " address"
+	}
+	return d
+}
+
+// Deliver sends one queued delivery over its channel
+func (s *Service) Deliver(ctx context.Context, d *models.NotificationDelivery) error {
+	sender := s.Senders[d.Channel]
+	if sender == nil {
+		return fmt.Errorf("channel %s is not configured", d.Channel)
+	}
+	var n models.Notification
+	if err := s.DB.Where("id = ?", d.NotificationID).First(&n).Error; err != nil {
+		return fmt.Errorf("notification %s: %w", d.NotificationID, err)
+	}
+
+	msg := Message{Subject: n.Title, Body: n.Body}
+	if n.Link != "" && s.BaseURL != "" {
+		msg.URL = s.BaseURL + n.Link
+	}
+	return sender.Send(ctx, d.Recipient, msg)
+}
@@ -0,0 +1,176 @@
+package notify_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify/notifytest"
+	"github.com/google/uuid"
+)
+
+func leaveMessage(t *testing.T) notify.Message {
+	t.Helper()
+	msg, err := notify.Render(notify.EventLeaveReviewed, "id", map[string]interface{}{
+		"type": "sakit", "approved": true, "start": "12/10/2026", "end": "13/10/2026", "reviewer": "Dosen Wali",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	msg.URL = "http://portal.test/dashboard/attendance-history"
+	return msg
+}
+
+func TestSMTPSenderDeliversToSink(t *testing.T) {
+	sink := notifytest.NewSMTPSink(t)
+	msg := leaveMessage(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sink.Sender().Send(ctx, "mahasiswa@example.com", msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	got := sink.Messages()
+	if len(got) != 1 {
+		t.Fatalf("sink received %d messages, want 1", len(got))
+	}
+	for _, want := range []string{"To: mahasiswa@example.com", "Subject: " + msg.Subject, msg.URL} {
+		if !strings.Contains(got[0], want) {
+			t.Errorf("message lacks %q:\n%s", want, got[0])
+		}
+	}
+}
+
+func TestWhatsAppSenderPostsToGateway(t *testing.T) {
+	gateway := notifytest.NewGateway(t, "secret", 1)
+	sender := gateway.Sender()
+	msg := leaveMessage(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := sender.Send(ctx, "0812-3456-7890", msg); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("first Send = %v, want the gateway's 503", err)
+	}
+	if err := sender.Send(ctx, "0812-3456-7890", msg); err != nil {
+		t.Fatalf("second Send: %v", err)
+	}
+
+	got := gateway.Received()
+	if len(got) != 1 {
+		t.Fatalf("gateway accepted %d requests, want 1", len(got))
+	}
+	if got[0]["to"] != "6281234567890" {
+		t.Errorf("to = %q, want 6281234567890", got[0]["to"])
+	}
+	if !strings.Contains(got[0]["message"], msg.Subject) || !strings.Contains(got[0]["message"], msg.URL) {
+		t.Errorf("message = %q, want subject and link", got[0]["message"])
+	}
+
+	sender.Token = "wrong"
+	if err := sender.Send(ctx, "081234567890", msg); err == nil {
+		t.Error("Send with a wrong token succeeded")
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04", "2026-10-18 "+clock, notify.WIB)
+		return tm
+	}
+	night := models.NotificationPreference{QuietStart: "22:00", QuietEnd: "06:00"}
+	lunch := models.NotificationPreference{QuietStart: "12:00", QuietEnd: "13:00"}
+
+	tests := []struct {
+		name string
+		pref models.NotificationPreference
+		now  time.Time
+		want time.Time
+	}{
+		{"no quiet hours", models.NotificationPreference{}, at("23:00"), at("23:00")},
+		{"empty window", models.NotificationPreference{QuietStart: "22:00", QuietEnd: "22:00"}, at("22:30"), at("22:30")},
+		{"before midnight window", night, at("21:59"), at("21:59")},
+		{"inside window before midnight", night, at("23:30"), at("06:00").AddDate(0, 0, 1)},
+		{"inside window after midnight", night, at("02:00"), at("06:00")},
+		{"window end is outside", night, at("06:00"), at("06:00")},
+		{"same-day window", lunch, at("12:30"), at("13:00")},
+		{"after same-day window", lunch, at("13:30"), at("13:30")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notify.QuietUntil(tt.pref, tt.now); !got.Equal(tt.want) {
+				t.Errorf("QuietUntil = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishQueuesDeliveries(t *testing.T) {
+	db := notifytest.DB(t)
+	svc := notifytest.Service(db, notifytest.NewSMTPSink(t), notifytest.NewGateway(t, "secret", 0))
+
+	both := notifytest.Preference(t, db, models.NotificationPreference{
+		EmailEnabled: true, Email: "a@example.com", WhatsAppEnabled: true, Phone: "081234567890",
+	})
+	noPhone := notifytest.Preference(t, db, models.NotificationPreference{
+		EmailEnabled: true, Email: "b@example.com", WhatsAppEnabled: true,
+	})
+	muted := notifytest.Preference(t, db, models.NotificationPreference{
+		EmailEnabled: true, Email: "c@example.com", MutedEvents: []string{notify.EventLeaveReviewed},
+	})
+	local := time.Now().In(notify.WIB)
+	quiet := models.NotificationPreference{
+		EmailEnabled: true, Email: "d@example.com",
+		QuietStart: local.Add(-time.Hour).Format("15:04"), QuietEnd: local.Add(time.Hour).Format("15:04"),
+	}
+	sleeping := notifytest.Preference(t, db, quiet)
+
+	ev := notify.Event{
+		Type:    notify.EventLeaveReviewed,
+		UserIDs: []uuid.UUID{both, noPhone, muted, sleeping, both},
+		Data:    map[string]interface{}{"type": "izin", "approved": false, "start": "01/10/2026", "end": "01/10/2026"},
+		Link:    "/dashboard/attendance-history",
+	}
+	before := time.Now()
+	if err := svc.Publish(ev); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	var inbox int64
+	db.Model(&models.Notification{}).Count(&inbox)
+	if inbox != 4 {
+		t.Errorf("inbox entries = %d, want 4 (one per distinct user)", inbox)
+	}
+
+	d := notifytest.Deliveries(t, db, both)
+	if d[models.ChannelEmail].Status != models.DeliveryPending || d[models.ChannelEmail].Recipient != "a@example.com" {
+		t.Errorf("email delivery = %+v, want pending to a@example.com", d[models.ChannelEmail])
+	}
+	if d[models.ChannelWhatsApp].Status != models.DeliveryPending || d[models.ChannelWhatsApp].Recipient != "081234567890" {
+		t.Errorf("whatsapp delivery = %+v, want pending to 081234567890", d[models.ChannelWhatsApp])
+	}
+	if d[models.ChannelEmail].NextAttemptAt.After(time.Now()) {
+		t.Errorf("email delivery due at %s, want now", d[models.ChannelEmail].NextAttemptAt)
+	}
+
+	d = notifytest.Deliveries(t, db, noPhone)
+	if d[models.ChannelWhatsApp].Status != models.DeliverySkipped {
+		t.Errorf("whatsapp without a number = %s, want skipped", d[models.ChannelWhatsApp].Status)
+	}
+
+	if d = notifytest.Deliveries(t, db, muted); len(d) != 0 {
+		t.Errorf("muted event queued %d deliveries, want none", len(d))
+	}
+
+	d = notifytest.Deliveries(t, db, sleeping)
+	want := notify.QuietUntil(quiet, before)
+	if !want.After(before) {
+		t.Fatalf("QuietUntil(%s–%s) = %s, want the end of the window", quiet.QuietStart, quiet.QuietEnd, want)
+	}
+	if got := d[models.ChannelEmail].NextAttemptAt; !got.Equal(want) {
+		t.Errorf("delivery in quiet hours due at %s, want %s", got, want)
+	}
+}
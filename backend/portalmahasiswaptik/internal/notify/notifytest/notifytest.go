@@ -0,0 +1,256 @@
+// Package notifytest provides an in-process SMTP sink, a fake WhatsApp gateway and a scratch
+// database for testing the notification channels and the dispatcher.
+package notifytest
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SMTPSink is a minimal SMTP server that accepts every message
+type SMTPSink struct {
+	Addr     string
+	listener net.Listener
+	mu       sync.Mutex
+	messages []string
+}
+
+// NewSMTPSink starts a sink on a free local port; it stops when the test ends
+func NewSMTPSink(t testing.TB) *SMTPSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("SMTP sink: %v", err)
+	}
+	s := &SMTPSink{Addr: l.Addr().String(), listener: l}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// Sender returns an SMTP sender pointed at the sink
+func (s *SMTPSink) Sender() *notify.SMTPSender {
+	host, port, _ := net.SplitHostPort(s.Addr)
+	return &notify.SMTPSender{Host: host, Port: port, From: "Portal Mahasiswa PTIK <no-reply@localhost>"}
+}
+
+func (s *SMTPSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end with <CRLF>.<CRLF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, b.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default: // MAIL, RCPT, RSET, NOOP
+			reply("250 ok")
+		}
+	}
+}
+
+// Messages returns the raw messages received so far
+func (s *SMTPSink) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// Gateway is a fake WhatsApp gateway. It rejects requests without the bearer token and
+// answers 503 to the first FailFirst requests (every request when FailFirst is negative).
+type Gateway struct {
+	URL       string
+	Token     string
+	FailFirst int
+
+	mu       sync.Mutex
+	calls    int
+	received []map[string]string
+}
+
+// NewGateway starts a gateway with the given token; it stops when the test ends
+func NewGateway(t testing.TB, token string, failFirst int) *Gateway {
+	t.Helper()
+	g := &Gateway{Token: token, FailFirst: failFirst}
+	srv := httptest.NewServer(g)
+	t.Cleanup(srv.Close)
+	g.URL = srv.URL
+	return g
+}
+
+// Sender returns a WhatsApp sender pointed at the gateway
+func (g *Gateway) Sender() *notify.WhatsAppSender {
+	return &notify.WhatsAppSender{URL: g.URL, Token: g.Token, Client: &http.Client{}}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls++
+	if r.Header.Get("Authorization") != "Bearer "+g.Token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if g.FailFirst < 0 || g.calls <= g.FailFirst {
+		http.Error(w, "gateway busy", http.StatusServiceUnavailable)
+		return
+	}
+	var payload map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	g.received = append(g.received, payload)
+	w.Write([]byte(`{"status":true}`))
+}
+
+// Calls is the number of requests the gateway has seen
+func (g *Gateway) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+// Received returns the payloads of the accepted requests
+func (g *Gateway) Received() []map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]map[string]string(nil), g.received...)
+}
+
+// DB opens TEST_DATABASE_URL in a fresh schema holding the notification tables, dropped when
+// the test ends. The test is skipped when TEST_DATABASE_URL is unset.
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	schema := "notifytest_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	sep := " "
+	if strings.Contains(dsn, "://") {
+		sep = "&"
+		if !strings.Contains(dsn, "?") {
+			sep = "?"
+		}
+	}
+	db, err := gorm.Open(postgres.Open(dsn+sep+"search_path="+schema), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&models.Notification{}, &models.NotificationDelivery{}, &models.NotificationPreference{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// Preference stores a notification preference for a new user and returns the user's ID
+func Preference(t testing.TB, db *gorm.DB, pref models.NotificationPreference) uuid.UUID {
+	t.Helper()
+	pref.UserID = uuid.New()
+	if pref.Language == "" {
+		pref.Language = "id"
+	}
+	if err := db.Create(&pref).Error; err != nil {
+		t.Fatalf("create preference: %v", err)
+	}
+	return pref.UserID
+}
+
+// Deliveries returns the deliveries of a user by channel
+func Deliveries(t testing.TB, db *gorm.DB, userID uuid.UUID) map[string]models.NotificationDelivery {
+	t.Helper()
+	var rows []models.NotificationDelivery
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		t.Fatalf("load deliveries: %v", err)
+	}
+	byChannel := make(map[string]models.NotificationDelivery, len(rows))
+	for _, d := range rows {
+		if _, dup := byChannel[d.Channel]; dup {
+			t.Fatalf("user %s has more than one %s delivery", userID, d.Channel)
+		}
+		byChannel[d.Channel] = d
+	}
+	return byChannel
+}
+
+// Service returns a notify service with both channels wired to the sink and the gateway
+func Service(db *gorm.DB, sink *SMTPSink, gateway *Gateway) *notify.Service {
+	return &notify.Service{
+		DB: db,
+		Senders: map[string]notify.Sender{
+			models.ChannelEmail:    sink.Sender(),
+			models.ChannelWhatsApp: gateway.Sender(),
+		},
+		BaseURL: "http://portal.test",
+	}
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Event types
+const (
+	EventAnnouncement        = "announcement.published"
+	EventLeaveReviewed       = "attendance.leave_reviewed"
+	EventDuesUpdated         = "finance.dues_updated"
+	EventDuesReminder        = "finance.dues_reminder"
+	EventDuesOverdue         = "finance.dues_overdue"
+	EventDuesDigest          = "finance.dues_digest"
+	EventCompetitionDeadline = "competition.deadline"
+	EventCompetitionTeam     = "competition.team_registered"
+	EventAchievementReviewed = "achievement.reviewed"
+	EventTest                = "system.test"
+)
+
+// template pair for one language
+type messageTemplate struct {
+	Subject string
+	Body    string
+}
+
+// templates holds the messages per event and language. Indonesian ("id") is required and
+// used whenever the user's language has no translation.
+var templates = map[string]map[string]messageTemplate{
+	EventAnnouncement: {
+		"id": {
+			Subject: "[{{.category}}] {{.title}}",
+			Body:    "{{.excerpt}}",
+		},
+		"en": {
+			Subject: "[{{.category}}] {{.title}}",
+			Body:    "{{.excerpt}}",
+		},
+	},
+	EventLeaveReviewed: {
+		"id": {
+			Subject: "Pengajuan {{.type}} {{if .approved}}disetujui{{else}}ditolak{{end}}",
+			Body:    "Pengajuan {{.type}} Anda untuk {{.start}} s.d. {{.end}} {{if .approved}}disetujui{{else}}ditolak{{end}} oleh {{.reviewer}}.{{if .note}} Catatan: {{.note}}{{end}}",
+		},
+		"en": {
+			Subject: "Leave request ({{.type}}) {{if .approved}}approved{{else}}rejected{{end}}",
+			Body:    "Your {{.type}} request for {{.start}} to {{.end}} was {{if .approved}}approved{{else}}rejected{{end}} by {{.reviewer}}.{{if .note}} Note: {{.note}}{{end}}",
+		},
+	},
+	EventDuesUpdated: {
+		"id": {
+			Subject: "Iuran kas {{.period}}: {{.status}}",
+			Body:    "Status iuran kas Anda untuk {{.period}} diperbarui menjadi {{.status}}.",
+		},
+		"en": {
+			Subject: "Class dues {{.period}}: {{.status}}",
+			Body:    "Your class dues for {{.period}} are now {{.status}}.",
+		},
+	},
+	EventDuesReminder: {
+		"id": {
+			Subject: "Pengingat iuran kas {{.week}}",
+			Body:    "Iuran kas {{.week}} sebesar {{.amount}} jatuh tempo {{.due}}. Abaikan pesan ini jika sudah membayar.",
+		},
+		"en": {
+			Subject: "Class dues reminder {{.week}}",
+			Body:    "Your class dues for {{.week}} ({{.amount}}) are due on {{.due}}. Ignore this message if you have already paid.",
+		},
+	},
+	EventDuesOverdue: {
+		"id": {
+			Subject: "Tunggakan iuran kas: {{.weeks}} minggu",
+			Body:    "Anda memiliki tunggakan iuran kas {{.weeks}} minggu ({{.periods}}) sebesar {{.amount}}. Segera lakukan pembayaran atau hubungi bendahara kelas.",
+		},
+		"en": {
+			Subject: "Overdue class dues: {{.weeks}} weeks",
+			Body:    "You have {{.weeks}} weeks of unpaid class dues ({{.periods}}) totalling {{.amount}}. Please pay or contact your class treasurer.",
+		},
+	},
+	EventDuesDigest: {
+		"id": {
+			Subject: "Rekap tunggakan kas {{.class}}: {{.students}} mahasiswa",
+			Body:    "{{.students}} mahasiswa {{.class}} menunggak iuran kas dengan total {{.amount}}: {{.list}}.{{if .dispensed}} {{.dispensed}} mahasiswa sedang mendapat dispensasi.{{end}}",
+		},
+		"en": {
+			Subject: "Dues arrears in {{.class}}: {{.students}} students",
+			Body:    "{{.students}} students in {{.class}} have unpaid dues totalling {{.amount}}: {{.list}}.{{if .dispensed}} {{.dispensed}} students currently have a dispensation.{{end}}",
+		},
+	},
+	EventCompetitionDeadline: {
+		"id": {
+			Subject: "Pendaftaran {{.title}} ditutup {{.deadline}}",
+			Body:    "Pendaftaran lomba {{.title}} ({{.organizer}}) ditutup {{.deadline}}, {{.days}} hari lagi.{{if .team}} Tim Anda: {{.team}}.{{else}} Anda belum terdaftar dalam tim.{{end}}",
+		},
+		"en": {
+			Subject: "{{.title}} registration closes {{.deadline}}",
+			Body:    "Registration for {{.title}} ({{.organizer}}) closes on {{.deadline}}, in {{.days}} days.{{if .team}} Your team: {{.team}}.{{else}} You are not in a team yet.{{end}}",
+		},
+	},
+	EventCompetitionTeam: {
+		"id": {
+			Subject: "Anda terdaftar di tim {{.team}}",
+			Body:    "{{.leader}} mendaftarkan Anda di tim {{.team}} untuk lomba {{.title}}. Anggota: {{.members}}.",
+		},
+		"en": {
+			Subject: "You were added to team {{.team}}",
+			Body:    "{{.leader}} registered you in team {{.team}} for {{.title}}. Members: {{.members}}.",
+		},
+	},
+	EventAchievementReviewed: {
+		"id": {
+			Subject: "Prestasi {{.rank}} {{.competition}} {{if .approved}}diverifikasi{{else}}ditolak{{end}}",
+			Body:    "Prestasi {{.rank}} pada {{.competition}} {{if .approved}}telah diverifikasi dan masuk leaderboard{{else}}ditolak{{end}} oleh {{.reviewer}}.{{if .note}} Catatan: {{.note}}{{end}}",
+		},
+		"en": {
+			Subject: "Achievement {{.rank}} at {{.competition}} {{if .approved}}verified{{else}}rejected{{end}}",
+			Body:    "Your achievement {{.rank}} at {{.competition}} was {{if .approved}}verified and now counts on the leaderboard{{else}}rejected{{end}} by {{.reviewer}}.{{if .note}} Note: {{.note}}{{end}}",
+		},
+	},
+	EventTest: {
+		"id": {
+			Subject: "Tes notifikasi Portal Mahasiswa PTIK",
+			Body:    "Jika Anda menerima pesan ini, notifikasi untuk akun {{.name}} sudah berfungsi.",
+		},
+		"en": {
+			Subject: "Portal Mahasiswa PTIK test notification",
+			Body:    "If you received this, notifications for {{.name}} are working.",
+		},
+	},
+}
+
+// Events lists the event types users can mute (everything but the test message)
+func Events() []string {
+	events := make([]string, 0, len(templates))
+	for e := range templates {
+		if e != EventTest {
+			events = append(events, e)
+		}
+	}
+	sort.Strings(events)
+	return events
+}
+
+// Render fills the event's template in the given language (falling back to Indonesian)
+func Render(event, lang string, data map[string]interface{}) (Message, error) {
+	byLang, ok := templates[event]
+	if !ok {
+		return Message{}, fmt.Errorf("notify: unknown event %q", event)
+	}
+	tpl, ok := byLang[lang]
+	if !ok {
+		tpl = byLang["id"]
+	}
+
+	subject, err := execute(event+".subject", tpl.Subject, data)
+	if err != nil {
+		return Message{}, err
+	}
+	body, err := execute(event+".body", tpl.Body, data)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: subject, Body: body}, nil
+}
+
+// Helper: Execute one template text
+func execute(name, text string, data map[string]interface{}) (string, error) {
+	t, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+// Excerpt shortens text to at most n runes on a word boundary
+func Excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
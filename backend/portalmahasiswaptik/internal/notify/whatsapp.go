@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+)
+
+// WhatsAppSender posts messages to an HTTP WhatsApp gateway (Fonnte, Wablas, a self-hosted
+// whatsapp-web bridge, ...). The request is JSON {"to": "62…", "message": "…"} with an
+// optional bearer token; any 2xx response counts as delivered.
+type WhatsAppSender struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+// NewWhatsAppSenderFromEnv reads WHATSAPP_GATEWAY_URL and WHATSAPP_GATEWAY_TOKEN. Returns nil
+// when the URL is unset.
+func NewWhatsAppSenderFromEnv() *WhatsAppSender {
+	url := os.Getenv("WHATSAPP_GATEWAY_URL")
+	if url == "" {
+		return nil
+	}
+	return &WhatsAppSender{
+		URL:    url,
+		Token:  os.Getenv("WHATSAPP_GATEWAY_TOKEN"),
+		Client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (s *WhatsAppSender) Channel() string { return models.ChannelWhatsApp }
+
+// Send posts one message to the gateway
+func (s *WhatsAppSender) Send(ctx context.Context, to string, msg Message) error {
+	text := "*" + msg.Subject + "*\n" + msg.Body
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+	payload, _ := json.Marshal(map[string]string{"to": NormalizePhone(to), "message": text})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 300))
+		return fmt.Errorf("gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// NormalizePhone turns 08xx / +628xx numbers into the 628xx form gateways expect
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "0") {
+		digits = "62" + digits[1:]
+	}
+	return digits
+}
@@ -0,0 +1,293 @@
+package points
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WIB is the clock weeks and due dates are measured in
+var WIB = time.FixedZone("WIB", 7*3600)
+
+// recomputeLock is the advisory lock key that serializes ledger rebuilds
+const recomputeLock = 728303
+
+// ErrBusy is returned when another rebuild is running
+var ErrBusy = errors.New("poin sedang dihitung ulang oleh proses lain")
+
+// Report summarizes a ledger rebuild
+type Report struct {
+	Entries    int64            `json:"entries"`
+	ByRule     map[string]int64 `json:"by_rule"`
+	DurationMS int64            `json:"duration_ms"`
+}
+
+// EntryID is the ledger ID of a rule applied to a source for a student. It matches
+// md5(rule || source || student)::uuid in SQL, so rebuilds produce the same IDs.
+func EntryID(rule string, sourceID, studentID uuid.UUID) uuid.UUID {
+	return uuid.UUID(md5.Sum([]byte(rule + sourceID.String() + studentID.String())))
+}
+
+// Rules returns every rule keyed by its key
+func Rules(db *gorm.DB) (map[string]models.PointRule, error) {
+	var rules []models.PointRule
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.PointRule, len(rules))
+	for _, r := range rules {
+		byKey[r.Key] = r
+	}
+	return byKey, nil
+}
+
+// Attendance and dues rules are plain INSERT ... SELECTs, each the base query plus the
+// rule's condition. Due dates follow models.WeeklyDue.DueDate (day 7×week of the month).
+var sqlRules = map[string]string{
+	models.RuleAttendanceOnTime: attendanceSQL + ` AND r.invalidated_at IS NULL AND r.status IN ('hadir', 'present')`,
+	models.RuleAttendanceLate:   attendanceSQL + ` AND r.invalidated_at IS NULL AND r.status IN ('terlambat', 'late')`,
+	models.RuleAttendanceAbsent: attendanceSQL + ` AND r.status IN ('alpa', 'absent')`,
+	models.RuleDuesEarly:        duesSQL + ` AND (d.paid_at AT TIME ZONE 'Asia/Jakarta')::date < make_date(d.year, d.month, 7 * d.week_number)`,
+	models.RuleDuesOnTime:       duesSQL + ` AND (d.paid_at AT TIME ZONE 'Asia/Jakarta')::date = make_date(d.year, d.month, 7 * d.week_number)`,
+}
+
+// attendanceSQL scores one record per student, meeting and class. A meeting whose QR was
+// regenerated can hold records in several sessions; like the attendance recap, a valid
+// record beats an invalidated one, attendance beats izin/sakit beats alpa, and the later
+// record wins a tie.
+const attendanceSQL = `
+	INSERT INTO points_ledger (id, student_id, rule_key, points, source_type, source_id, reason, occurred_at)
+	SELECT md5(CAST(@rule AS text) || r.id::text || r.student_id::text)::uuid, r.student_id, @rule, @points,
+	       'attendance_record', r.id,
+	       COALESCE(sub.name, 'Kuliah') || ' pertemuan ' || COALESCE(m.meeting_number::text, '-'),
+	       r.scanned_at
+	FROM (
+		SELECT DISTINCT ON (ar.student_id, COALESCE(s.meeting_id, ar.session_id), s.class_id)
+		       ar.id, ar.student_id, ar.status, ar.scanned_at, ar.invalidated_at, s.meeting_id
+		FROM attendance_records ar
+		LEFT JOIN attendance_sessions s ON s.id = ar.session_id
+		ORDER BY ar.student_id, COALESCE(s.meeting_id, ar.session_id), s.class_id,
+		         ar.invalidated_at IS NULL DESC,
+		         CASE
+		             WHEN ar.status IN ('hadir', 'terlambat', 'present', 'late') THEN 3
+		             WHEN ar.status IN ('izin', 'sakit', 'excused') THEN 2
+		             WHEN ar.status IN ('alpa', 'absent') THEN 1
+		             ELSE 0
+		         END DESC,
+		         ar.scanned_at DESC
+	) r
+	JOIN profiles p ON p.user_id = r.student_id
+	LEFT JOIN meetings m ON m.id = r.meeting_id
+	LEFT JOIN subjects sub ON sub.id = m.subject_id
+	WHERE true`
+
+const duesSQL = `
+	INSERT INTO points_ledger (id, student_id, rule_key, points, source_type, source_id, reason, occurred_at)
+	SELECT md5(CAST(@rule AS text) || d.id::text || d.student_id::text)::uuid, d.student_id, @rule, @points,
+	       'weekly_due', d.id,
+	       'Iuran kas W' || d.week_number || ' ' || lpad(d.month::text, 2, '0') || '/' || d.year,
+	       d.paid_at
+	FROM weekly_dues d
+	JOIN profiles p ON p.user_id = d.student_id
+	WHERE d.status IN ('paid', 'lunas') AND d.paid_at IS NOT NULL AND d.week_number BETWEEN 1 AND 4`
+
+// Recompute rebuilds the whole ledger from attendance, dues and verified achievements with
+// the current rules. The result depends only on those sources, so running it twice, or after
+// a rule change, always yields the same ledger.
+func Recompute(db *gorm.DB) (*Report, error) {
+	started := time.Now()
+	report := &Report{ByRule: make(map[string]int64)}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", recomputeLock).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return ErrBusy
+		}
+
+		rules, err := Rules(tx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM points_ledger").Error; err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(sqlRules))
+		for key := range sqlRules {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rule, ok := rules[key]
+			if !ok || !rule.Active || rule.Points == 0 {
+				continue
+			}
+			res := tx.Exec(sqlRules[key], map[string]interface{}{"rule": key, "points": rule.Points})
+			if res.Error != nil {
+				return fmt.Errorf("%s: %w", key, res.Error)
+			}
+			report.ByRule[key] = res.RowsAffected
+		}
+
+		if rule, ok := rules[models.RuleAchievement]; ok && rule.Active && rule.Points != 0 {
+			n, err := achievementEntries(tx, rule)
+			if err != nil {
+				return fmt.Errorf("%s: %w", models.RuleAchievement, err)
+			}
+			report.ByRule[models.RuleAchievement] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, n := range report.ByRule {
+		report.Entries += n
+	}
+	report.DurationMS = time.Since(started).Milliseconds()
+	return report, nil
+}
+
+// Helper: Ledger rows for verified achievements; the score depends on the rank text, which
+// is parsed in Go (models.ClassAchievement.Points)
+func achievementEntries(tx *gorm.DB, rule models.PointRule) (int64, error) {
+	var achievements []models.ClassAchievement
+	if err := tx.Preload("Members").Where("status = ?", models.AchievementVerified).Find(&achievements).Error; err != nil {
+		return 0, err
+	}
+
+	var entries []models.PointEntry
+	for _, a := range achievements {
+		pts := a.Points() * rule.Points / 100
+		if pts == 0 {
+			continue
+		}
+		occurred := time.Date(a.EventDate.Year(), a.EventDate.Month(), a.EventDate.Day(), 0, 0, 0, 0, WIB)
+		for _, m := range a.Members {
+			entries = append(entries, models.PointEntry{
+				ID:         EntryID(rule.Key, a.ID, m.StudentID),
+				StudentID:  m.StudentID,
+				RuleKey:    rule.Key,
+				Points:     pts,
+				SourceType: "class_achievement",
+				SourceID:   a.ID,
+				Reason:     a.Rank + " " + a.CompetitionName,
+				OccurredAt: occurred,
+			})
+		}
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	if err := tx.CreateInBatches(&entries, 500).Error; err != nil {
+		return 0, err
+	}
+	return int64(len(entries)), nil
+}
+
+// WeekRange is the Monday–Sunday week (WIB) containing t, as [from, to)
+func WeekRange(t time.Time) (time.Time, time.Time) {
+	local := t.In(WIB)
+	offset := (int(local.Weekday()) + 6) % 7 // Monday = 0
+	from := time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, WIB)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// Standing is one row of a points leaderboard
+type Standing struct {
+	Rank      int       `json:"rank"`
+	ID        uuid.UUID `json:"id"` // student user ID, or class ID for the class ranking
+	Name      string    `json:"name"`
+	NIM       string    `json:"nim,omitempty"`
+	ClassName string    `json:"class_name,omitempty"`
+	Points    int       `json:"points"`
+	Students  int       `json:"students,omitempty"` // class ranking only
+	Average   float64   `json:"average,omitempty"`  // class ranking only: points per student
+}
+
+// Helper: Ledger join condition for the period (nil bounds = all time)
+func periodJoin(from, to *time.Time) (string, []interface{}) {
+	join := "LEFT JOIN points_ledger l ON l.student_id = p.user_id"
+	var args []interface{}
+	if from != nil {
+		join += " AND l.occurred_at >= ?"
+		args = append(args, *from)
+	}
+	if to != nil {
+		join += " AND l.occurred_at < ?"
+		args = append(args, *to)
+	}
+	return join, args
+}
+
+// StudentStandings ranks students (of one class, or the whole batch when classID is nil)
+// by points earned in the period. Students without points are included at the bottom.
+func StudentStandings(db *gorm.DB, classID *uuid.UUID, from, to *time.Time) ([]Standing, error) {
+	join, args := periodJoin(from, to)
+	query := db.Table("profiles p").
+		Select("p.user_id AS id, p.full_name AS name, p.nim, c.name AS class_name, COALESCE(SUM(l.points), 0) AS points").
+		Joins("LEFT JOIN classes c ON c.id = p.class_id").
+		Joins(join, args...).
+		Where("p.role IN ?", []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas}).
+		Group("p.user_id, p.full_name, p.nim, c.name").
+		Order("points DESC, p.full_name, p.nim")
+	if classID != nil {
+		query = query.Where("p.class_id = ?", *classID)
+	}
+
+	var standings []Standing
+	if err := query.Scan(&standings).Error; err != nil {
+		return nil, err
+	}
+	rank(standings, func(s Standing) float64 { return float64(s.Points) })
+	return standings, nil
+}
+
+// ClassStandings ranks classes by average points per student in the period, so large and
+// small classes compete fairly
+func ClassStandings(db *gorm.DB, from, to *time.Time) ([]Standing, error) {
+	join, args := periodJoin(from, to)
+	var standings []Standing
+	err := db.Table("classes c").
+		Select("c.id, c.name, COALESCE(SUM(l.points), 0) AS points, COUNT(DISTINCT p.user_id) AS students").
+		Joins("LEFT JOIN profiles p ON p.class_id = c.id AND p.role IN ?", []models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas}).
+		Joins(join, args...).
+		Group("c.id, c.name").
+		Scan(&standings).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range standings {
+		if standings[i].Students > 0 {
+			standings[i].Average = float64(int(float64(standings[i].Points)/float64(standings[i].Students)*100+0.5)) / 100
+		}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Average != standings[j].Average {
+			return standings[i].Average > standings[j].Average
+		}
+		return standings[i].Name < standings[j].Name
+	})
+	rank(standings, func(s Standing) float64 { return s.Average })
+	return standings, nil
+}
+
+// Helper: Standard competition ranking over an already sorted slice (ties share a rank)
+func rank(standings []Standing, score func(Standing) float64) {
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && score(standings[i]) == score(standings[i-1]) {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// AttendanceChannel is the Postgres NOTIFY channel shared by every server instance
+const AttendanceChannel = "attendance_events"
+
+// Event types pushed to the lecturer's live feed
+const (
+	EventScanAccepted  = "scan_accepted"
+	EventScanRejected  = "scan_rejected"
+	EventCounts        = "counts"
+	EventSessionClosed = "session_closed"
+)
+
+// AttendanceCounts is the running tally for a session
+type AttendanceCounts struct {
+	Present int64 `json:"present"`
+	Absent  int64 `json:"absent"`
+	Total   int64 `json:"total"`
+}
+
+// AttendanceEvent is a single message on the live feed of an AttendanceSession
+type AttendanceEvent struct {
+	Type        string            `json:"type"`
+	SessionID   uuid.UUID         `json:"session_id"`
+	StudentID   *uuid.UUID        `json:"student_id,omitempty"`
+	StudentName string            `json:"student_name,omitempty"`
+	NIM         string            `json:"nim,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Biometric   bool              `json:"biometric_verified,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Flags       []string          `json:"flags,omitempty"` // suspicious-scan detectors that fired
+	Counts      *AttendanceCounts `json:"counts,omitempty"`
+	At          time.Time         `json:"at"`
+}
+
+// Hub fans out attendance events to SSE subscribers.
+// Events travel through Postgres LISTEN/NOTIFY so a scan handled by one instance
+// reaches a lecturer connected to another.
+type Hub struct {
+	DB       *gorm.DB
+	listener *pq.Listener
+
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[chan AttendanceEvent]struct{}
+}
+
+// NewHub connects a dedicated LISTEN connection. If that fails the hub still works,
+// but only delivers events produced by this instance.
+func NewHub(db *gorm.DB) *Hub {
+	h := &Hub{
+		DB:   db,
+		subs: make(map[uuid.UUID]map[chan AttendanceEvent]struct{}),
+	}
+
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		log.Println("⚠️ Realtime: DATABASE_URL kosong, live feed hanya berjalan di instance ini")
+		return h
+	}
+
+	// LISTEN does not survive PgBouncer transaction pooling, use the direct port
+	if strings.Contains(dsn, ":6543") {
+		dsn = strings.Replace(dsn, ":6543", ":5432", 1)
+		dsn = strings.Replace(dsn, "pgbouncer=true", "pgbouncer=false", 1)
+	}
+
+	listener := pq.NewListener(dsn, 2*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("⚠️ Realtime listener event %d: %v", ev, err)
+		}
+	})
+	if err := listener.Listen(AttendanceChannel); err != nil {
+		log.Printf("⚠️ Realtime: gagal LISTEN %s: %v (fallback ke mode lokal)", AttendanceChannel, err)
+		listener.Close()
+		return h
+	}
+
+	h.listener = listener
+	go h.run()
+
+	log.Printf("✅ Realtime listener aktif di channel '%s'", AttendanceChannel)
+	return h
+}
+
+func (h *Hub) run() {
+	for {
+		select {
+		case n, ok := <-h.listener.Notify:
+			if !ok {
+				return
+			}
+			// nil notification means the connection was re-established
+			if n == nil {
+				continue
+			}
+			var ev AttendanceEvent
+			if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil {
+				log.Printf("⚠️ Realtime: payload tidak valid: %v", err)
+				continue
+			}
+			h.dispatch(ev)
+		case <-time.After(90 * time.Second):
+			go h.listener.Ping()
+		}
+	}
+}
+
+// Publish sends an event to every instance. Without a listener it is delivered locally.
+func (h *Hub) Publish(ev AttendanceEvent) error {
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+
+	if h.listener == nil {
+		h.dispatch(ev)
+		return nil
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+	return h.DB.Exec("SELECT pg_notify(?, ?)", AttendanceChannel, string(payload)).Error
+}
+
+// Subscribe registers a listener for one session. Call the returned func to unsubscribe.
+func (h *Hub) Subscribe(sessionID uuid.UUID) (<-chan AttendanceEvent, func()) {
+	ch := make(chan AttendanceEvent, 32)
+
+	h.mu.Lock()
+	if h.subs[sessionID] == nil {
+		h.subs[sessionID] = make(map[chan AttendanceEvent]struct{})
+	}
+	h.subs[sessionID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if set, ok := h.subs[sessionID]; ok {
+			if _, ok := set[ch]; ok {
+				delete(set, ch)
+				close(ch)
+			}
+			if len(set) == 0 {
+				delete(h.subs, sessionID)
+			}
+		}
+	}
+}
+
+func (h *Hub) dispatch(ev AttendanceEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.subs[ev.SessionID] {
+		// Slow consumers drop events instead of blocking the whole hub
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package rollover
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/dues"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxSemester is the last curriculum semester a cohort can be promoted to (masa studi S1)
+const MaxSemester = 14
+
+// ErrNoActiveTerm is returned when there is no active term to roll over from
+var ErrNoActiveTerm = errors.New("belum ada semester aktif, aktifkan semester terlebih dahulu")
+
+// errDryRun rolls the transaction back after the report has been built
+var errDryRun = errors.New("dry run")
+
+// Options tunes the rollover. Zero values pick the term following the active one.
+type Options struct {
+	NextTermID *uuid.UUID // use this (planned) term instead of the computed one
+	StartDate  *time.Time // next term start; default the day after the active term ends
+	EndDate    *time.Time // next term end; default six months after the start
+	DryRun     bool
+}
+
+// ClassPromotion describes one cohort moving to the next curriculum semester
+type ClassPromotion struct {
+	ClassID uuid.UUID `json:"class_id"`
+	Name    string    `json:"name"`
+	From    int       `json:"from"`
+	To      int       `json:"to"`
+}
+
+// Report summarizes what the rollover did (or would do on a dry run)
+type Report struct {
+	PreviousTerm     models.AcademicTerm `json:"previous_term"`
+	NextTerm         models.AcademicTerm `json:"next_term"`
+	CreatedNextTerm  bool                `json:"created_next_term"`
+	ArchivedSessions int64               `json:"archived_sessions"`
+	ArchivedSlots    int64               `json:"archived_slots"`
+	BillingArchived  [2]int              `json:"billing_archived"` // start, end month of the old term
+	BillingNext      [2]int              `json:"billing_next"`     // start, end month of the new term
+	Promoted         []ClassPromotion    `json:"promoted"`
+	NotPromoted      []string            `json:"not_promoted"` // classes without current_semester or at MaxSemester
+	DryRun           bool                `json:"dry_run"`
+}
+
+// Run archives the active term and activates the next one: attendance sessions and
+// timetable slots of the old term are archived, its billing range is stored on the term
+// and replaced by the new term's months, and every cohort moves up one semester.
+// Everything happens in one transaction; a dry run rolls it back and only reports.
+func Run(db *gorm.DB, opts Options) (*Report, error) {
+	report := &Report{DryRun: opts.DryRun, Promoted: []ClassPromotion{}, NotPromoted: []string{}}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var prev models.AcademicTerm
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("AcademicYear").
+			Where("is_active = ?", true).First(&prev).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoActiveTerm
+		} else if err != nil {
+			return err
+		}
+
+		next, created, err := nextTerm(tx, prev, opts)
+		if err != nil {
+			return err
+		}
+		report.CreatedNextTerm = created
+
+		// 1. Attendance sessions opened up to the last day of the old term
+		prevEnd := prev.EndDate.AddDate(0, 0, 1)
+		res := tx.Model(&models.AttendanceSession{}).
+			Where("archived_at IS NULL AND created_at < ?", prevEnd).
+			Updates(map[string]interface{}{"archived_at": now, "is_active": false})
+		if res.Error != nil {
+			return res.Error
+		}
+		report.ArchivedSessions = res.RowsAffected
+
+		// 2. Timetable slots of the old term (and untagged ones that already started), ending on its last day
+		if err := tx.Model(&models.ScheduleSlot{}).
+			Where("archived_at IS NULL AND (term_id = ? OR (term_id IS NULL AND (valid_from IS NULL OR valid_from <= ?)))", prev.ID, prev.EndDate).
+			Where("valid_until IS NULL OR valid_until > ?", prev.EndDate).
+			Update("valid_until", prev.EndDate).Error; err != nil {
+			return err
+		}
+		res = tx.Model(&models.ScheduleSlot{}).
+			Where("archived_at IS NULL AND (term_id = ? OR (term_id IS NULL AND valid_until <= ?))", prev.ID, prev.EndDate).
+			Update("archived_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		report.ArchivedSlots = res.RowsAffected
+
+		// 3. Billing range: keep the old one on the term, switch to the new term's months
+		start, end := dues.BillingRange(tx)
+		report.BillingArchived = [2]int{start, end}
+		// A term crossing the new year gives a wrapping range (start after end)
+		nextStart, nextEnd := int(next.StartDate.Month()), int(next.EndDate.Month())
+		report.BillingNext = [2]int{nextStart, nextEnd}
+		configs := map[string]int{
+			"billing_start_month":    nextStart,
+			"billing_end_month":      nextEnd,
+			"billing_selected_month": 0,
+		}
+		for key, value := range configs {
+			if err := tx.Exec(`
+				INSERT INTO global_configs (key, value, updated_at)
+				VALUES (?, ?, CURRENT_TIMESTAMP)
+				ON CONFLICT (key) DO UPDATE SET
+					value = EXCLUDED.value,
+					updated_at = EXCLUDED.updated_at
+			`, key, strconv.Itoa(value)).Error; err != nil {
+				return err
+			}
+		}
+
+		// 4. Promote cohorts
+		var classes []models.Class
+		if err := tx.Order("name ASC").Find(&classes).Error; err != nil {
+			return err
+		}
+		for _, class := range classes {
+			if class.CurrentSemester == nil || *class.CurrentSemester >= MaxSemester {
+				report.NotPromoted = append(report.NotPromoted, class.Name)
+				continue
+			}
+			from := *class.CurrentSemester
+			if err := tx.Model(&models.Class{}).Where("id = ?", class.ID).
+				Update("current_semester", from+1).Error; err != nil {
+				return err
+			}
+			report.Promoted = append(report.Promoted, ClassPromotion{ClassID: class.ID, Name: class.Name, From: from, To: from + 1})
+		}
+
+		// 5. Switch the active term
+		if err := tx.Model(&models.AcademicTerm{}).Where("id = ?", prev.ID).Updates(map[string]interface{}{
+			"is_active":           false,
+			"archived_at":         now,
+			"billing_start_month": start,
+			"billing_end_month":   end,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AcademicTerm{}).Where("id = ?", next.ID).Update("is_active", true).Error; err != nil {
+			return err
+		}
+
+		prev.IsActive, prev.ArchivedAt = false, &now
+		prev.BillingStartMonth, prev.BillingEndMonth = &start, &end
+		next.IsActive = true
+		report.PreviousTerm, report.NextTerm = prev, next
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Helper: The term after prev — the planned one from opts, the genap half of the same year,
+// or the ganjil half of the following year. Missing years and terms are created.
+func nextTerm(tx *gorm.DB, prev models.AcademicTerm, opts Options) (models.AcademicTerm, bool, error) {
+	var next models.AcademicTerm
+	start := prev.EndDate.AddDate(0, 0, 1)
+	if opts.StartDate != nil {
+		start = *opts.StartDate
+	}
+	end := start.AddDate(0, 6, -1)
+	if opts.EndDate != nil {
+		end = *opts.EndDate
+	}
+
+	if opts.NextTermID != nil {
+		if err := tx.Preload("AcademicYear").Where("id = ?", *opts.NextTermID).First(&next).Error; err != nil {
+			return next, false, fmt.Errorf("semester tujuan tidak ditemukan")
+		}
+	} else {
+		yearID, parity := prev.AcademicYearID, models.TermGenap
+		if prev.Parity == models.TermGenap {
+			year, err := followingYear(tx, prev, start)
+			if err != nil {
+				return next, false, err
+			}
+			yearID, parity = year.ID, models.TermGanjil
+		}
+
+		err := tx.Preload("AcademicYear").Where("academic_year_id = ? AND parity = ?", yearID, parity).First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var year models.AcademicYear
+			if err := tx.Where("id = ?", yearID).First(&year).Error; err != nil {
+				return next, false, err
+			}
+			next = models.AcademicTerm{
+				AcademicYearID: yearID,
+				Parity:         parity,
+				Name:           TermName(parity, year.Name),
+				StartDate:      start,
+				EndDate:        end,
+			}
+			if err := tx.Create(&next).Error; err != nil {
+				return next, false, err
+			}
+			next.AcademicYear = &year
+			return next, true, nil
+		} else if err != nil {
+			return next, false, err
+		}
+	}
+
+	if next.ID == prev.ID || next.ArchivedAt != nil {
+		return next, false, fmt.Errorf("semester %s sudah diarsipkan", next.Name)
+	}
+	if opts.StartDate != nil || opts.EndDate != nil {
+		next.StartDate, next.EndDate = start, end
+		if err := tx.Model(&models.AcademicTerm{}).Where("id = ?", next.ID).
+			Updates(map[string]interface{}{"start_date": start, "end_date": end}).Error; err != nil {
+			return next, false, err
+		}
+	}
+	return next, false, nil
+}
+
+// Helper: The academic year after prev's ("2026/2027" → "2027/2028"), created when missing
+func followingYear(tx *gorm.DB, prev models.AcademicTerm, start time.Time) (models.AcademicYear, error) {
+	var year models.AcademicYear
+	var first, second int
+	if prev.AcademicYear == nil {
+		return year, fmt.Errorf("tahun akademik semester aktif tidak ditemukan")
+	}
+	if _, err := fmt.Sscanf(prev.AcademicYear.Name, "%d/%d", &first, &second); err != nil {
+		return year, fmt.Errorf("nama tahun akademik %q tidak berformat YYYY/YYYY", prev.AcademicYear.Name)
+	}
+
+	name := fmt.Sprintf("%d/%d", first+1, second+1)
+	err := tx.Where("name = ?", name).First(&year).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		year = models.AcademicYear{Name: name, StartDate: start, EndDate: start.AddDate(1, 0, -1)}
+		err = tx.Create(&year).Error
+	}
+	return year, err
+}
+
+// TermName is the display name of a term, e.g. "Ganjil 2026/2027"
+func TermName(parity, yearName string) string {
+	if parity == models.TermGenap {
+		return "Genap " + yearName
+	}
+	return "Ganjil " + yearName
+}
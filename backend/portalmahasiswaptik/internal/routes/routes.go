@@ -2,11 +2,15 @@ package routes
 
 import (
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers/announcements"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers/auth"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/handlers/repository"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/middleware"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/realtime"
 	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/storage"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/workers"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -14,14 +18,38 @@ import (
 
 // SetupRoutes configures all API routes
 func SetupRoutes(app *fiber.App, db *gorm.DB, storageSrv *storage.SupabaseStorage, validate *validator.Validate) {
+	// Live attendance feed (Postgres LISTEN/NOTIFY, shared across instances)
+	liveHub := realtime.NewHub(db)
+
+	// Background: close expired sessions and mark alpa (advisory-locked, multi-instance safe)
+	workers.NewAttendanceCloser(db, liveHub).Start()
+
+	// Notifications: inbox entries are written immediately, email/WhatsApp go out from the dispatcher
+	notifier := notify.NewService(db)
+	workers.NewNotificationDispatcher(db, notifier).Start()
+	workers.NewDuesReminder(db, notifier).Start()
+	workers.NewCompetitionReminder(db, notifier).Start()
+
+	// Points ledger is rebuilt from attendance, dues and achievements (advisory-locked)
+	workers.NewPointsRecompute(db).Start()
+
+	// WebAuthn first: attendance uses it for biometric-bound scans
+	webauthnHandler, _ := auth.NewWebAuthnHandler(db)
+
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(db, validate)
-	financeHandler := handlers.NewFinanceHandler(db, validate)
-	attendanceHandler := handlers.NewAttendanceHandler(db, validate)
+	financeHandler := handlers.NewFinanceHandler(db, validate, notifier)
+	attendanceHandler := handlers.NewAttendanceHandler(db, validate, liveHub, webauthnHandler, notifier)
 	automationHandler := handlers.NewAutomationHandler(db)
 	repoHandler := repository.NewRepositoryHandler(db, storageSrv)
 	configHandler := handlers.NewConfigHandler(db, validate)
-	webauthnHandler, _ := auth.NewWebAuthnHandler(db)
+	academicHandler := handlers.NewAcademicHandler(db, validate)
+	calendarHandler := handlers.NewCalendarHandler(db)
+	notificationHandler := handlers.NewNotificationHandler(db, validate, notifier)
+	announcementsHandler := announcements.NewAnnouncementsHandler(db, validate, notifier)
+	competitionHandler := handlers.NewCompetitionHandler(db, validate, notifier)
+	achievementHandler := handlers.NewAchievementHandler(db, validate, storageSrv, notifier)
+	pointsHandler := handlers.NewPointsHandler(db, validate)
 
 	// API v1 group
 	api := app.Group("/api")
@@ -37,6 +65,9 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, storageSrv *storage.SupabaseStorag
 	// Public config route
 	api.Get("/config", userHandler.GetSupabaseConfig)
 
+	// ICS feed for calendar apps (the token in the URL is the credential)
+	api.Get("/calendar/feed/:token", calendarHandler.GetFeed)
+
 	// Automation Webhook (Secret/Supabase only)
 	api.Post("/webhooks/automation", automationHandler.HandleSupabaseWebhook)
 
@@ -71,6 +102,11 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, storageSrv *storage.SupabaseStorag
 	finance.Get("/dues/summary", financeHandler.GetWeeklyDuesSummary)
 	finance.Post("/dues/bulk", middleware.RequireAdminDev(), financeHandler.BulkUpdateDues)
 	finance.Get("/dues/matrix", financeHandler.GetDuesMatrix)
+	finance.Get("/dues/arrears", middleware.RequireAdmin(), financeHandler.GetDuesArrears)
+	finance.Post("/dues/reminders/run", middleware.RequireAdminDev(), financeHandler.RunDuesReminders)
+	finance.Get("/dues/dispensations", middleware.RequireAdmin(), financeHandler.GetDispensations)
+	finance.Post("/dues/dispensations", middleware.RequireAdmin(), financeHandler.CreateDispensation)
+	finance.Delete("/dues/dispensations/:id", middleware.RequireAdmin(), financeHandler.DeleteDispensation)
 	finance.Get("/export", financeHandler.ExportFinanceExcel)
 
 	// Attendance
@@ -80,15 +116,165 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, storageSrv *storage.SupabaseStorag
 	attendance.Post("/session", middleware.RequireLecturer(), attendanceHandler.CreateSession)
 	attendance.Get("/sessions", attendanceHandler.GetActiveSessions)
 	attendance.Post("/scan", middleware.RequireRole(models.RoleAdminDev, models.RoleMahasiswa, models.RoleAdminKelas), attendanceHandler.ScanQR)
+	attendance.Post("/scan/biometric/begin", middleware.RequireRole(models.RoleAdminDev, models.RoleMahasiswa, models.RoleAdminKelas), webauthnHandler.BeginScan)
 	attendance.Get("/records", attendanceHandler.GetAttendanceRecords)
+	attendance.Get("/history", attendanceHandler.GetStudentHistory)
+	attendance.Get("/history/:studentId", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen, models.RoleAdminKelas), attendanceHandler.GetStudentHistory)
 	attendance.Post("/session/:id/refresh", middleware.RequireLecturer(), attendanceHandler.RefreshSession)
 	attendance.Post("/session/:id/deactivate", middleware.RequireLecturer(), attendanceHandler.DeactivateSession)
+	attendance.Get("/session/:id/live", middleware.RequireLecturer(), attendanceHandler.StreamSession) // SSE (EventSource pakai ?token=)
+	attendance.Get("/subjects/:subjectId/timing", attendanceHandler.GetSubjectTiming)
+	attendance.Put("/subjects/:subjectId/timing", middleware.RequireLecturer(), attendanceHandler.UpdateSubjectTiming)
+	attendance.Get("/stats", attendanceHandler.GetAttendanceStats)
+	attendance.Post("/leave", middleware.RequireRole(models.RoleMahasiswa, models.RoleAdminKelas), attendanceHandler.SubmitLeave)
+	attendance.Get("/leave", attendanceHandler.GetLeaveRequests)
+	attendance.Post("/leave/:id/review", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen, models.RoleAdminKelas), attendanceHandler.ReviewLeave)
+	attendance.Get("/session/:id/suspicious", middleware.RequireLecturer(), attendanceHandler.GetSuspiciousScans)
+	attendance.Post("/records/:id/invalidate", middleware.RequireLecturer(), attendanceHandler.InvalidateRecord)
+	attendance.Post("/flags/:id/dismiss", middleware.RequireLecturer(), attendanceHandler.DismissFlag)
+	attendance.Get("/session/:id/offline-claim", middleware.RequireLecturer(), attendanceHandler.GetOfflineClaim)
+	attendance.Post("/offline/devices", middleware.RequireRole(models.RoleAdminDev, models.RoleMahasiswa, models.RoleAdminKelas), attendanceHandler.RegisterOfflineDevice)
+	attendance.Post("/scan/offline", middleware.RequireRole(models.RoleAdminDev, models.RoleMahasiswa, models.RoleAdminKelas), attendanceHandler.SyncOfflineScan)
+	attendance.Put("/session/:id/override", middleware.RequireLecturer(), attendanceHandler.OverrideRecord)
+	attendance.Get("/session/:id/qr.png", middleware.RequireLecturer(), attendanceHandler.GetSessionQRPNG)
+	attendance.Get("/session/:id/qr.svg", middleware.RequireLecturer(), attendanceHandler.GetSessionQRSVG)
+	attendance.Get("/session/:id/poster.pdf", middleware.RequireLecturer(), attendanceHandler.GetSessionPoster)
+	attendance.Post("/import", middleware.RequireLecturer(), attendanceHandler.ImportAttendance) // multipart, dry_run=true by default
+
+	// Academic master data (subjects, classes, meetings)
+	academic := protected.Group("/academic")
+	academic.Post("/subjects", middleware.RequireAdminDev(), academicHandler.CreateSubject)
+	academic.Put("/subjects/:id", middleware.RequireAdminDev(), academicHandler.UpdateSubject)
+	academic.Delete("/subjects/:id", middleware.RequireAdminDev(), academicHandler.DeleteSubject)
+	academic.Post("/classes", middleware.RequireAdminDev(), academicHandler.CreateClass)
+	academic.Put("/classes/:id", middleware.RequireAdminDev(), academicHandler.UpdateClass)
+	academic.Delete("/classes/:id", middleware.RequireAdminDev(), academicHandler.DeleteClass)
+	academic.Post("/subjects/:id/meetings/generate", middleware.RequireLecturer(), academicHandler.GenerateMeetings)
+	academic.Put("/subjects/:id/meetings/order", middleware.RequireLecturer(), academicHandler.ReorderMeetings)
+	academic.Put("/meetings/:id", middleware.RequireLecturer(), academicHandler.UpdateMeeting)
+	academic.Delete("/meetings/:id", middleware.RequireLecturer(), academicHandler.DeleteMeeting)
+	academic.Get("/assignments", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen), academicHandler.GetAssignments)
+	academic.Post("/assignments", middleware.RequireAdminDev(), academicHandler.CreateAssignment)
+	academic.Delete("/assignments/:id", middleware.RequireAdminDev(), academicHandler.DeleteAssignment)
+	academic.Get("/my-subjects", academicHandler.GetMySubjects)
+	academic.Get("/semesters", academicHandler.GetSemesters)
+	academic.Get("/years", academicHandler.GetAcademicYears)
+	academic.Post("/years", middleware.RequireAdminDev(), academicHandler.CreateAcademicYear)
+	academic.Get("/terms/active", academicHandler.GetActiveTerm)
+	academic.Post("/terms/rollover", middleware.RequireAdminDev(), academicHandler.RolloverTerm)
+	academic.Put("/terms/:id", middleware.RequireAdminDev(), academicHandler.UpdateTerm)
+	academic.Post("/terms/:id/activate", middleware.RequireAdminDev(), academicHandler.ActivateTerm)
+	academic.Get("/schedule", academicHandler.GetSchedule)
+	academic.Get("/schedule/today", academicHandler.GetTodaySchedule)
+	academic.Get("/schedule/conflicts", middleware.RequireAdminDev(), academicHandler.GetScheduleConflicts)
+	academic.Post("/schedule", middleware.RequireAdminDev(), academicHandler.CreateScheduleSlot)
+	academic.Put("/schedule/:id", middleware.RequireAdminDev(), academicHandler.UpdateScheduleSlot)
+	academic.Delete("/schedule/:id", middleware.RequireAdminDev(), academicHandler.DeleteScheduleSlot)
+	academic.Get("/holidays", academicHandler.GetHolidays)
+	academic.Post("/holidays", middleware.RequireAdminDev(), academicHandler.CreateHoliday)
+	academic.Delete("/holidays/:id", middleware.RequireAdminDev(), academicHandler.DeleteHoliday)
+	academic.Get("/subjects/:id/grade-components", academicHandler.GetGradeComponents)
+	academic.Put("/subjects/:id/grade-components", middleware.RequireLecturer(), academicHandler.UpdateGradeComponents)
+	academic.Get("/grades/sheet", middleware.RequireLecturer(), academicHandler.GetGradeSheet)
+	academic.Put("/grades/scores", middleware.RequireLecturer(), academicHandler.SaveGradeScores)
+	academic.Post("/grades/publish", middleware.RequireLecturer(), academicHandler.PublishGrades)
+	academic.Get("/grades/transcript", academicHandler.GetTranscript)
+	academic.Get("/grades/transcript/:studentId", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen, models.RoleAdminKelas), academicHandler.GetTranscript)
+	academic.Get("/ipk/subjects", academicHandler.GetIPKSubjects)
+	academic.Post("/ipk/simulate", academicHandler.SimulateIPK)
+	academic.Get("/krs", academicHandler.GetMyKRS)
+	academic.Get("/krs/offerings", academicHandler.GetKRSOfferings)
+	academic.Put("/krs", middleware.RequireRole(models.RoleMahasiswa, models.RoleAdminKelas), academicHandler.SaveKRS)
+	academic.Post("/krs/submit", middleware.RequireRole(models.RoleMahasiswa, models.RoleAdminKelas), academicHandler.SubmitKRS)
+	academic.Get("/krs/advisees", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen), academicHandler.GetAdviseeKRS)
+	academic.Get("/krs/roster", middleware.RequireLecturer(), academicHandler.GetSectionRoster)
+	academic.Post("/krs/:id/review", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen), academicHandler.ReviewKRS)
+	academic.Put("/advisors", middleware.RequireAdminDev(), academicHandler.SetAdvisor)
+	academic.Get("/exams", academicHandler.GetExams)
+	academic.Get("/exams/clashes", middleware.RequireAdminDev(), academicHandler.GetExamClashes)
+	academic.Get("/exams/card", middleware.RequireRole(models.RoleMahasiswa, models.RoleAdminKelas), academicHandler.GetExamCard)
+	academic.Get("/exams/card/:studentId", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen, models.RoleAdminKelas), academicHandler.GetExamCard)
+	academic.Post("/exams", middleware.RequireAdminDev(), academicHandler.CreateExam)
+	academic.Put("/exams/:id", middleware.RequireAdminDev(), academicHandler.UpdateExam)
+	academic.Delete("/exams/:id", middleware.RequireAdminDev(), academicHandler.DeleteExam)
+	academic.Get("/exams/:id/eligibility", middleware.RequireLecturer(), academicHandler.GetExamEligibility)
+	academic.Post("/exams/:id/seating", middleware.RequireLecturer(), academicHandler.GenerateSeating)
+	academic.Get("/exams/:id/seating", middleware.RequireLecturer(), academicHandler.GetSeating)
+	academic.Get("/exams/:id/seating/export", middleware.RequireLecturer(), academicHandler.ExportSeating)
+
+	// Calendar subscriptions
+	calendar := protected.Group("/calendar")
+	calendar.Get("/subscription", calendarHandler.GetSubscription)
+	calendar.Post("/subscription/rotate", calendarHandler.RotateSubscription)
+	calendar.Delete("/subscription", calendarHandler.RevokeSubscription)
+
+	// Notifications (in-app inbox, channel preferences, delivery status)
+	notifications := protected.Group("/notifications")
+	notifications.Get("", notificationHandler.GetNotifications)
+	notifications.Get("/unread-count", notificationHandler.GetNotificationUnreadCount)
+	notifications.Post("/read-all", notificationHandler.MarkAllNotificationsRead)
+	notifications.Get("/preferences", notificationHandler.GetNotificationPreferences)
+	notifications.Put("/preferences", notificationHandler.UpdateNotificationPreferences)
+	notifications.Post("/test", notificationHandler.SendTestNotification)
+	notifications.Get("/deliveries", middleware.RequireAdminDev(), notificationHandler.GetDeliveries)
+	notifications.Post("/deliveries/:id/retry", middleware.RequireAdminDev(), notificationHandler.RetryDelivery)
+	notifications.Post("/:id/read", notificationHandler.MarkNotificationRead)
+	notifications.Delete("/:id", notificationHandler.DeleteNotification)
+
+	// Announcements
+	announcement := protected.Group("/announcements")
+	announcement.Get("", announcementsHandler.GetAnnouncements)
+	announcement.Get("/unread-count", announcementsHandler.GetUnreadCount)
+	announcement.Post("/read-all", announcementsHandler.MarkAllRead)
+	announcement.Get("/:id", announcementsHandler.GetAnnouncement)
+	announcement.Post("/:id/read", announcementsHandler.MarkRead)
+	announcement.Get("/:id/reads", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), announcementsHandler.GetReadReceipts)
+	announcement.Post("", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), announcementsHandler.CreateAnnouncement)
+	announcement.Put("/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), announcementsHandler.UpdateAnnouncement)
+	announcement.Patch("/:id/pin", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), announcementsHandler.PinAnnouncement)
+	announcement.Delete("/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), announcementsHandler.DeleteAnnouncement)
+
+	// Competitions
+	competition := protected.Group("/competitions")
+	competition.Get("", competitionHandler.GetCompetitions)
+	competition.Post("", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), competitionHandler.CreateCompetition)
+	competition.Get("/:id", competitionHandler.GetCompetition)
+	competition.Put("/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), competitionHandler.UpdateCompetition)
+	competition.Delete("/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), competitionHandler.DeleteCompetition)
+	competition.Post("/:id/bookmark", competitionHandler.BookmarkCompetition)
+	competition.Delete("/:id/bookmark", competitionHandler.UnbookmarkCompetition)
+	competition.Get("/:id/teams", competitionHandler.GetTeams)
+	competition.Post("/:id/teams", competitionHandler.RegisterTeam)
+	competition.Delete("/:id/teams/:teamId", competitionHandler.DeleteTeam)
+
+	// Achievements (registry, review and leaderboard)
+	achievement := protected.Group("/achievements")
+	achievement.Get("", achievementHandler.GetAchievements)
+	achievement.Get("/leaderboard", achievementHandler.GetAchievementLeaderboard)
+	achievement.Post("", achievementHandler.CreateAchievement)
+	achievement.Get("/:id", achievementHandler.GetAchievement)
+	achievement.Put("/:id", achievementHandler.UpdateAchievement)
+	achievement.Delete("/:id", achievementHandler.DeleteAchievement)
+	achievement.Post("/:id/certificate", achievementHandler.UploadCertificate)
+	achievement.Post("/:id/review", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminDosen), achievementHandler.ReviewAchievement)
+
+	// Participation points (ledger, rules and leaderboards)
+	pointsGrp := protected.Group("/points")
+	pointsGrp.Get("/rules", pointsHandler.GetPointRules)
+	pointsGrp.Put("/rules/:key", middleware.RequireAdminDev(), pointsHandler.UpdatePointRule)
+	pointsGrp.Post("/recompute", middleware.RequireAdminDev(), pointsHandler.RecomputePoints)
+	pointsGrp.Get("/leaderboard", pointsHandler.GetPointsLeaderboard)
+	pointsGrp.Get("/history", pointsHandler.GetMyPointsHistory)
+	pointsGrp.Get("/history/:studentId", pointsHandler.GetStudentPointsHistory)
 
 	// Repository
 	repo := protected.Group("/repository")
 	repo.Get("/semesters", repoHandler.GetSemesters)
+	repo.Get("/semesters/:id/courses", repoHandler.GetCourses)
 	repo.Get("/files", repoHandler.GetFiles)
-	repo.Post("/upload-drive", repoHandler.UploadToDrive)
+	repo.Post("/upload-drive", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), repoHandler.UploadToDrive)
+	repo.Put("/materials/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), repoHandler.UpdateMaterial)
+	repo.Delete("/materials/:id", middleware.RequireRole(models.RoleAdminDev, models.RoleAdminKelas, models.RoleAdminDosen), repoHandler.DeleteMaterial)
 	repo.Get("/download/:id", repoHandler.DownloadMaterial)
 
 	// Export
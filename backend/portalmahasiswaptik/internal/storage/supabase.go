@@ -56,3 +56,37 @@ func (s *SupabaseStorage) UploadFile(fileName string, contentType string, body i
 	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.URL, s.Bucket, fileName)
 	return publicURL, nil
 }
+
+// ObjectPath returns the object name inside the bucket for a public URL produced by
+// UploadFile, and false for URLs that point elsewhere (other buckets, Google Drive, ...)
+func (s *SupabaseStorage) ObjectPath(publicURL string) (string, bool) {
+	prefix := fmt.Sprintf("%s/storage/v1/object/public/%s/", s.URL, s.Bucket)
+	if !strings.HasPrefix(publicURL, prefix) || len(publicURL) == len(prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(publicURL, prefix), true
+}
+
+func (s *SupabaseStorage) DeleteFile(fileName string) error {
+	deleteURL := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.URL, s.Bucket, fileName)
+
+	req, err := http.NewRequest("DELETE", deleteURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Key)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Already gone counts as deleted
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("supabase storage error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
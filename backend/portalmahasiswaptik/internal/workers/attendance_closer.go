@@ -0,0 +1,194 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/enrollment"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/realtime"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// attendanceCloserLock is the advisory lock key shared by every instance running the closer
+const attendanceCloserLock = 728301
+
+// Sessions older than this are never finalized automatically, so deploying the closer
+// does not rewrite old semesters that predate it
+const finalizeLookback = 7 * 24 * time.Hour
+
+// lateCutoffMinutes is the late cutoff when neither the session nor the subject sets one
+// (handlers.DefaultLateCutoffMinutes)
+const lateCutoffMinutes = 60
+
+// AttendanceCloser closes expired sessions and writes alpa records for students who
+// never scanned. Approved leave requests turn the record into izin/sakit instead. Only the
+// newest session of a meeting is finalized this way; older QR codes are simply retired.
+type AttendanceCloser struct {
+	DB       *gorm.DB
+	Live     *realtime.Hub
+	Interval time.Duration
+	BatchMax int
+}
+
+func NewAttendanceCloser(db *gorm.DB, live *realtime.Hub) *AttendanceCloser {
+	return &AttendanceCloser{
+		DB:       db,
+		Live:     live,
+		Interval: time.Minute,
+		BatchMax: 100,
+	}
+}
+
+// Start runs the closer in the background until the process exits
+func (w *AttendanceCloser) Start() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			if closed, err := w.RunOnce(); err != nil {
+				log.Printf("⚠️ Attendance closer: %v", err)
+			} else if closed > 0 {
+				log.Printf("✅ Attendance closer: %d sesi ditutup", closed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// supersededSQL retires sessions replaced by a newer QR for the same meeting and class.
+// They get no alpa rows: the newest session decides for the whole meeting.
+const supersededSQL = `
+	UPDATE attendance_sessions s SET is_active = false, finalized_at = now()
+	WHERE s.finalized_at IS NULL
+	  AND s.created_at > ?
+	  AND EXISTS (
+		SELECT 1 FROM attendance_sessions n
+		WHERE n.meeting_id = s.meeting_id AND n.class_id = s.class_id AND n.created_at > s.created_at
+	  )`
+
+// dueSQL claims the newest session of each meeting and class once both its QR expiry and
+// its attendance cutoff have passed. The cutoff mirrors handlers.resolveTiming: session
+// overrides, then the subject rule, measured from the scheduled start; the absent cutoff
+// when set, the late cutoff otherwise.
+const dueSQL = `
+	UPDATE attendance_sessions SET is_active = false, finalized_at = now()
+	WHERE id IN (
+		SELECT s.id FROM attendance_sessions s
+		JOIN meetings m ON m.id = s.meeting_id
+		LEFT JOIN subject_timing_rules tr ON tr.subject_id = m.subject_id
+		WHERE s.finalized_at IS NULL
+		  AND s.created_at > ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM attendance_sessions n
+			WHERE n.meeting_id = s.meeting_id AND n.class_id = s.class_id AND n.created_at > s.created_at
+		  )
+		  AND now() > GREATEST(s.expires_at,
+			COALESCE(
+				s.scheduled_start,
+				((s.created_at AT TIME ZONE 'Asia/Jakarta')::date + tr.start_time::time) AT TIME ZONE 'Asia/Jakarta',
+				s.created_at
+			) + make_interval(mins => COALESCE(
+				NULLIF(COALESCE(s.absent_cutoff_minutes, tr.absent_cutoff_minutes), 0),
+				COALESCE(s.late_cutoff_minutes, tr.late_cutoff_minutes, ?)
+			)))
+		ORDER BY s.expires_at
+		LIMIT ?
+		FOR UPDATE OF s SKIP LOCKED
+	)
+	RETURNING id`
+
+// RunOnce finalizes one batch of sessions. Safe to call concurrently from several
+// instances: only the holder of the advisory lock does any work, and each session is
+// claimed by the same UPDATE that finalizes it.
+func (w *AttendanceCloser) RunOnce() (int, error) {
+	var finalized []uuid.UUID
+	since := time.Now().Add(-finalizeLookback)
+
+	err := w.DB.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", attendanceCloserLock).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		if err := tx.Exec(supersededSQL, since).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(dueSQL, since, lateCutoffMinutes, w.BatchMax).Scan(&finalized).Error; err != nil {
+			return err
+		}
+
+		if len(finalized) > 0 {
+			// Students with a record in any session of the meeting (an earlier QR included)
+			// already have their attendance for it
+			if err := tx.Exec(`
+				INSERT INTO attendance_records (session_id, student_id, status, method, scanned_at)
+				SELECT s.id, p.user_id, COALESCE(l.type, ?), 'auto', now()
+				FROM attendance_sessions s
+				JOIN meetings m ON m.id = s.meeting_id
+				JOIN profiles p ON `+enrollment.RosterSQL+` AND p.role IN ?
+				LEFT JOIN LATERAL (
+					SELECT lr.type FROM leave_requests lr
+					WHERE lr.student_id = p.user_id
+					  AND lr.status = ?
+					  AND (COALESCE(s.scheduled_start, s.created_at) AT TIME ZONE 'Asia/Jakarta')::date
+					      BETWEEN lr.start_date AND lr.end_date
+					ORDER BY lr.reviewed_at DESC NULLS LAST
+					LIMIT 1
+				) l ON true
+				WHERE s.id IN ?
+				  AND NOT EXISTS (
+					SELECT 1 FROM attendance_records r
+					JOIN attendance_sessions rs ON rs.id = r.session_id
+					WHERE rs.meeting_id = s.meeting_id AND rs.class_id = s.class_id
+					  AND r.student_id = p.user_id
+				  )`,
+				models.AttendanceAlpa,
+				[]models.AppRole{models.RoleMahasiswa, models.RoleAdminKelas},
+				models.LeaveApproved,
+				finalized,
+			).Error; err != nil {
+				return err
+			}
+		}
+
+		// A meeting counts once per student: drop generated rows where the student also has a
+		// real record in another session of the same meeting (reopened with a new QR)
+		return tx.Exec(`
+			DELETE FROM attendance_records a
+			USING attendance_sessions sa
+			WHERE sa.id = a.session_id
+			  AND a.method = 'auto'
+			  AND sa.created_at > ?
+			  AND EXISTS (
+				SELECT 1 FROM attendance_records r
+				JOIN attendance_sessions rs ON rs.id = r.session_id
+				WHERE rs.meeting_id = sa.meeting_id AND rs.class_id = sa.class_id
+				  AND r.student_id = a.student_id
+				  AND r.id <> a.id
+				  AND r.method <> 'auto'
+			  )`, since).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Lecturers still watching the live feed get the final tally
+	if w.Live != nil {
+		for _, id := range finalized {
+			if err := w.Live.Publish(realtime.AttendanceEvent{
+				Type:      realtime.EventSessionClosed,
+				SessionID: id,
+			}); err != nil {
+				log.Printf("⚠️ Live feed publish failed: %v", err)
+			}
+		}
+	}
+
+	return len(finalized), nil
+}
@@ -0,0 +1,115 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompetitionReminder tells users who bookmarked a competition that its registration
+// deadline is near. Each bookmark is reminded once (again if the deadline moves).
+type CompetitionReminder struct {
+	DB       *gorm.DB
+	Notify   *notify.Service
+	Interval time.Duration
+	LeadDays int // remind this many days before the deadline
+}
+
+func NewCompetitionReminder(db *gorm.DB, notifier *notify.Service) *CompetitionReminder {
+	return &CompetitionReminder{
+		DB:       db,
+		Notify:   notifier,
+		Interval: time.Hour,
+		LeadDays: 3,
+	}
+}
+
+// Start runs the reminder in the background until the process exits
+func (w *CompetitionReminder) Start() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := w.RunOnce(time.Now()); err != nil {
+				log.Printf("⚠️ Competition reminder: %v", err)
+			} else if n > 0 {
+				log.Printf("🏆 Competition reminder: %d pengingat deadline dikirim", n)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce reminds every bookmark whose deadline falls within LeadDays. Bookmarks are claimed
+// by the UPDATE that marks them, so several instances never remind twice.
+func (w *CompetitionReminder) RunOnce(now time.Time) (int, error) {
+	local := now.In(notify.WIB)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	var claimed []models.CompetitionBookmark
+	err := w.DB.Raw(`
+		UPDATE competition_bookmarks b SET reminded_at = now()
+		FROM competitions c
+		WHERE c.id = b.competition_id
+		  AND b.reminded_at IS NULL
+		  AND c.deadline BETWEEN ? AND ?
+		RETURNING b.*`,
+		today, today.AddDate(0, 0, w.LeadDays)).
+		Scan(&claimed).Error
+	if err != nil || len(claimed) == 0 {
+		return 0, err
+	}
+
+	byCompetition := make(map[uuid.UUID][]uuid.UUID)
+	for _, b := range claimed {
+		byCompetition[b.CompetitionID] = append(byCompetition[b.CompetitionID], b.UserID)
+	}
+	for competitionID, userIDs := range byCompetition {
+		var comp models.Competition
+		if err := w.DB.Where("id = ?", competitionID).First(&comp).Error; err != nil {
+			continue
+		}
+
+		// Users already in a team get their team name; the rest a nudge to register
+		var teams []struct {
+			StudentID uuid.UUID
+			Name      string
+		}
+		w.DB.Table("competition_team_members m").
+			Select("m.student_id, t.name").
+			Joins("JOIN competition_teams t ON t.id = m.team_id").
+			Where("m.competition_id = ? AND m.student_id IN ?", competitionID, userIDs).
+			Scan(&teams)
+		teamOf := make(map[uuid.UUID]string, len(teams))
+		for _, t := range teams {
+			teamOf[t.StudentID] = t.Name
+		}
+		byTeam := make(map[string][]uuid.UUID)
+		for _, id := range userIDs {
+			byTeam[teamOf[id]] = append(byTeam[teamOf[id]], id)
+		}
+
+		for team, ids := range byTeam {
+			if err := w.Notify.Publish(notify.Event{
+				Type:    notify.EventCompetitionDeadline,
+				UserIDs: ids,
+				Link:    "/dashboard/competitions",
+				Data: map[string]interface{}{
+					"title":     comp.Title,
+					"organizer": comp.Organizer,
+					"deadline":  comp.Deadline.Format("02/01/2006"),
+					"days":      int(comp.Deadline.Sub(today).Hours() / 24),
+					"team":      team,
+				},
+			}); err != nil {
+				log.Printf("⚠️ Competition reminder %s: %v", competitionID, err)
+			}
+		}
+	}
+	return len(claimed), nil
+}
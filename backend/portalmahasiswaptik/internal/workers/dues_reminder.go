@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/dues"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// DuesReminder reminds students about unpaid weekly dues and sends arrears digests to class
+// treasurers. Throttling lives in the dues package, so running it hourly is safe.
+type DuesReminder struct {
+	DB       *gorm.DB
+	Notify   *notify.Service
+	Interval time.Duration
+	FromHour int // reminders only go out between FromHour and ToHour WIB
+	ToHour   int
+}
+
+func NewDuesReminder(db *gorm.DB, notifier *notify.Service) *DuesReminder {
+	return &DuesReminder{
+		DB:       db,
+		Notify:   notifier,
+		Interval: time.Hour,
+		FromHour: 8,
+		ToHour:   20,
+	}
+}
+
+// Start runs the reminder in the background until the process exits
+func (w *DuesReminder) Start() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			if report, err := w.RunOnce(time.Now()); err != nil && !errors.Is(err, dues.ErrBusy) {
+				log.Printf("⚠️ Dues reminder: %v", err)
+			} else if report != nil && len(report.Reminders) > 0 {
+				log.Printf("💸 Dues reminder: %d pengingat dikirim", len(report.Reminders))
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce sends the reminders that are due, or nothing outside the sending hours
+func (w *DuesReminder) RunOnce(now time.Time) (*dues.Report, error) {
+	if hour := now.In(dues.WIB).Hour(); hour < w.FromHour || hour >= w.ToHour {
+		return nil, nil
+	}
+	return dues.Run(w.DB, w.Notify, now, false)
+}
@@ -0,0 +1,129 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// Delivery retry schedule: the n-th failed attempt waits deliveryBackoff[n-1]. After the last
+// one the delivery is marked failed.
+var deliveryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+// A claimed delivery is hidden from other instances for this long while it is being sent
+const deliveryLease = 5 * time.Minute
+
+// NotificationDispatcher sends queued email/WhatsApp deliveries with retries and announces
+// scheduled announcements once their publish_at passes
+type NotificationDispatcher struct {
+	DB       *gorm.DB
+	Notify   *notify.Service
+	Interval time.Duration
+	BatchMax int
+}
+
+func NewNotificationDispatcher(db *gorm.DB, notifier *notify.Service) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		DB:       db,
+		Notify:   notifier,
+		Interval: 30 * time.Second,
+		BatchMax: 50,
+	}
+}
+
+// Start runs the dispatcher in the background until the process exits
+func (w *NotificationDispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := w.publishDueAnnouncements(); err != nil {
+				log.Printf("⚠️ Notification dispatcher (announcements): %v", err)
+			} else if n > 0 {
+				log.Printf("📣 Notification dispatcher: %d pengumuman terjadwal diterbitkan", n)
+			}
+			if sent, failed, err := w.RunOnce(); err != nil {
+				log.Printf("⚠️ Notification dispatcher: %v", err)
+			} else if sent+failed > 0 {
+				log.Printf("✅ Notification dispatcher: %d terkirim, %d gagal", sent, failed)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// RunOnce sends one batch of due deliveries. Each delivery is claimed by an UPDATE that
+// pushes its next attempt out by the lease, so several instances never send the same one.
+func (w *NotificationDispatcher) RunOnce() (sent, failed int, err error) {
+	var claimed []models.NotificationDelivery
+	err = w.DB.Raw(`
+		UPDATE notification_deliveries SET attempts = attempts + 1, next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = ? AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		time.Now().Add(deliveryLease), models.DeliveryPending, w.BatchMax).
+		Scan(&claimed).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i := range claimed {
+		d := &claimed[i]
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sendErr := w.Notify.Deliver(ctx, d)
+		cancel()
+
+		updates := map[string]interface{}{}
+		switch {
+		case sendErr == nil:
+			now := time.Now()
+			updates["status"] = models.DeliverySent
+			updates["sent_at"] = now
+			updates["last_error"] = ""
+			sent++
+		case d.Attempts > len(deliveryBackoff):
+			updates["status"] = models.DeliveryFailed
+			updates["last_error"] = sendErr.Error()
+			failed++
+		default:
+			updates["next_attempt_at"] = time.Now().Add(deliveryBackoff[d.Attempts-1])
+			updates["last_error"] = sendErr.Error()
+		}
+		if err := w.DB.Model(&models.NotificationDelivery{}).Where("id = ?", d.ID).Updates(updates).Error; err != nil {
+			log.Printf("⚠️ Notification dispatcher: delivery %s: %v", d.ID, err)
+		}
+	}
+	return sent, failed, nil
+}
+
+// publishDueAnnouncements notifies the audience of scheduled announcements whose publish_at
+// has passed. Announcements scheduled more than a day ago are not announced retroactively.
+func (w *NotificationDispatcher) publishDueAnnouncements() (int, error) {
+	var due []models.Announcement
+	err := w.DB.Raw(`
+		UPDATE announcements SET notified_at = now()
+		WHERE notified_at IS NULL
+		  AND publish_at IS NOT NULL AND publish_at <= now() AND publish_at > now() - interval '1 day'
+		  AND (expires_at IS NULL OR expires_at > now())
+		RETURNING *`).
+		Scan(&due).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range due {
+		if err := w.Notify.PublishAnnouncement(&due[i]); err != nil {
+			log.Printf("⚠️ Notification dispatcher: announcement %s: %v", due[i].ID, err)
+		}
+	}
+	return len(due), nil
+}
@@ -0,0 +1,138 @@
+package workers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/models"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify"
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/notify/notifytest"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func publishTest(t *testing.T, svc *notify.Service, userIDs ...uuid.UUID) {
+	t.Helper()
+	err := svc.Publish(notify.Event{
+		Type:    notify.EventTest,
+		UserIDs: userIDs,
+		Data:    map[string]interface{}{"name": "Mahasiswa"},
+		Link:    "/dashboard/notifications",
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func reload(t *testing.T, db *gorm.DB, d models.NotificationDelivery) models.NotificationDelivery {
+	t.Helper()
+	if err := db.Where("id = ?", d.ID).First(&d).Error; err != nil {
+		t.Fatalf("reload delivery: %v", err)
+	}
+	return d
+}
+
+func TestRunOnceSendsDueDeliveries(t *testing.T) {
+	db := notifytest.DB(t)
+	sink := notifytest.NewSMTPSink(t)
+	gateway := notifytest.NewGateway(t, "secret", 0)
+	w := &NotificationDispatcher{DB: db, Notify: notifytest.Service(db, sink, gateway), BatchMax: 10}
+
+	awake := notifytest.Preference(t, db, models.NotificationPreference{
+		EmailEnabled: true, Email: "a@example.com", WhatsAppEnabled: true, Phone: "081234567890",
+	})
+	local := time.Now().In(notify.WIB)
+	sleeping := notifytest.Preference(t, db, models.NotificationPreference{
+		EmailEnabled: true, Email: "b@example.com",
+		QuietStart: local.Add(-time.Hour).Format("15:04"), QuietEnd: local.Add(time.Hour).Format("15:04"),
+	})
+	publishTest(t, w.Notify, awake, sleeping)
+
+	sent, failed, err := w.RunOnce()
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if sent != 2 || failed != 0 {
+		t.Fatalf("RunOnce = %d sent, %d failed; want 2 sent, 0 failed", sent, failed)
+	}
+
+	for channel, d := range notifytest.Deliveries(t, db, awake) {
+		if d.Status != models.DeliverySent || d.SentAt == nil || d.Attempts != 1 || d.LastError != "" {
+			t.Errorf("%s delivery = %+v, want sent after one attempt", channel, d)
+		}
+	}
+	if msgs := sink.Messages(); len(msgs) != 1 || !strings.Contains(msgs[0], "http://portal.test/dashboard/notifications") {
+		t.Errorf("sink received %q, want one message with the portal link", msgs)
+	}
+	if got := gateway.Received(); len(got) != 1 || got[0]["to"] != "6281234567890" {
+		t.Errorf("gateway received %v, want one message to 6281234567890", got)
+	}
+
+	quiet := notifytest.Deliveries(t, db, sleeping)[models.ChannelEmail]
+	if quiet.Status != models.DeliveryPending || quiet.Attempts != 0 {
+		t.Errorf("delivery in quiet hours = %+v, want pending and untouched", quiet)
+	}
+}
+
+func TestRunOnceRetriesOnBackoffThenFails(t *testing.T) {
+	db := notifytest.DB(t)
+	gateway := notifytest.NewGateway(t, "secret", -1)
+	w := &NotificationDispatcher{DB: db, Notify: notifytest.Service(db, notifytest.NewSMTPSink(t), gateway), BatchMax: 10}
+
+	user := notifytest.Preference(t, db, models.NotificationPreference{WhatsAppEnabled: true, Phone: "081234567890"})
+	publishTest(t, w.Notify, user)
+	d := notifytest.Deliveries(t, db, user)[models.ChannelWhatsApp]
+
+	for i, wait := range deliveryBackoff {
+		start := time.Now()
+		sent, failed, err := w.RunOnce()
+		end := time.Now()
+		if err != nil {
+			t.Fatalf("attempt %d: RunOnce: %v", i+1, err)
+		}
+		if sent != 0 || failed != 0 {
+			t.Fatalf("attempt %d: RunOnce = %d sent, %d failed; want a retry", i+1, sent, failed)
+		}
+
+		d = reload(t, db, d)
+		if d.Status != models.DeliveryPending || d.Attempts != i+1 || !strings.Contains(d.LastError, "503") {
+			t.Fatalf("attempt %d: delivery = %+v, want pending with the gateway error", i+1, d)
+		}
+		if d.NextAttemptAt.Before(start.Add(wait).Truncate(time.Microsecond)) || d.NextAttemptAt.After(end.Add(wait+time.Microsecond)) {
+			t.Fatalf("attempt %d: next attempt at %s, want %s after the attempt", i+1, d.NextAttemptAt, wait)
+		}
+
+		// Not due yet: a second run leaves it alone
+		if _, _, err := w.RunOnce(); err != nil {
+			t.Fatalf("attempt %d: RunOnce: %v", i+1, err)
+		}
+		if calls := gateway.Calls(); calls != i+1 {
+			t.Fatalf("attempt %d: gateway saw %d requests, want %d", i+1, calls, i+1)
+		}
+
+		// Skip the wait
+		db.Model(&models.NotificationDelivery{}).Where("id = ?", d.ID).Update("next_attempt_at", time.Now().Add(-time.Second))
+	}
+
+	sent, failed, err := w.RunOnce()
+	if err != nil {
+		t.Fatalf("last attempt: RunOnce: %v", err)
+	}
+	if sent != 0 || failed != 1 {
+		t.Fatalf("last attempt: RunOnce = %d sent, %d failed; want 1 failed", sent, failed)
+	}
+	d = reload(t, db, d)
+	if d.Status != models.DeliveryFailed || d.Attempts != len(deliveryBackoff)+1 || d.SentAt != nil {
+		t.Errorf("delivery = %+v, want failed after %d attempts", d, len(deliveryBackoff)+1)
+	}
+
+	// Failed deliveries are never picked up again
+	db.Model(&models.NotificationDelivery{}).Where("id = ?", d.ID).Update("next_attempt_at", time.Now().Add(-time.Second))
+	if _, _, err := w.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if calls := gateway.Calls(); calls != len(deliveryBackoff)+1 {
+		t.Errorf("gateway saw %d requests, want %d", calls, len(deliveryBackoff)+1)
+	}
+}
@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"errors"
+	"log"
+	"maps"
+	"time"
+
+	"github.com/SyafikhAL010907/portalmahasiswaptik/backend/internal/points"
+	"gorm.io/gorm"
+)
+
+// PointsRecompute rebuilds the points ledger periodically so new scans, dues payments and
+// verified achievements show up on the leaderboards without a manual recompute
+type PointsRecompute struct {
+	DB       *gorm.DB
+	Interval time.Duration
+}
+
+func NewPointsRecompute(db *gorm.DB) *PointsRecompute {
+	return &PointsRecompute{
+		DB:       db,
+		Interval: 15 * time.Minute,
+	}
+}
+
+// Start runs the rebuild in the background until the process exits
+func (w *PointsRecompute) Start() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		// Only rebuilds that change the ledger's shape are logged
+		var last *points.Report
+		for {
+			if report, err := points.Recompute(w.DB); err != nil && !errors.Is(err, points.ErrBusy) {
+				log.Printf("⚠️ Points recompute: %v", err)
+			} else if report != nil {
+				if last == nil || report.Entries != last.Entries || !maps.Equal(report.ByRule, last.ByRule) {
+					log.Printf("🏅 Points recompute: %d baris poin (%d ms)", report.Entries, report.DurationMS)
+				}
+				last = report
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -18,6 +18,16 @@ export interface Student {
   is_misslock?: boolean | null;
 }
 
+// Backend statuses (hadir/terlambat/alpa/izin/sakit) and the legacy English ones
+export const normalizeAttendanceStatus = (raw?: string | null): Student['status'] => {
+  switch (raw) {
+    case 'present': case 'hadir': case 'late': case 'terlambat': return 'present';
+    case 'excused': case 'izin': case 'sakit': case 'permit': return 'excused';
+    case 'absent': case 'alpa': case 'alpha': return 'absent';
+    default: return 'pending';
+  }
+};
+
 export interface Semester {
   id: number;
   name: string;
@@ -280,10 +290,7 @@ export function useAttendance() {
       const mappedStudents: Student[] = filteredProfiles.map(p => {
         const record = recordMap.get(p.user_id);
         const rawStatus = record?.status;
-        let status: 'present' | 'excused' | 'absent' | 'pending' = 'pending';
-        if (rawStatus === 'present' || rawStatus === 'hadir') status = 'present';
-        else if (rawStatus === 'excused' || rawStatus === 'izin') status = 'excused';
-        else if (rawStatus === 'absent' || rawStatus === 'alpha') status = 'absent';
+        const status = normalizeAttendanceStatus(rawStatus);
 
         return {
           id: p.user_id,
@@ -343,10 +350,7 @@ export function useAttendance() {
           setStudents(currentStudents =>
             currentStudents.map(s => {
               if (s.id === newRecord.student_id) {
-                let newStatus: 'present' | 'excused' | 'absent' | 'pending' = 'pending';
-                if (newRecord.status === 'present' || newRecord.status === 'hadir') newStatus = 'present';
-                else if (newRecord.status === 'excused' || newRecord.status === 'izin') newStatus = 'excused';
-                else if (newRecord.status === 'absent' || newRecord.status === 'alpha') newStatus = 'absent';
+                const newStatus = normalizeAttendanceStatus(newRecord.status);
 
                 return {
                   ...s,
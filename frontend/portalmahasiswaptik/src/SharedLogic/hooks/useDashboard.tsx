@@ -123,7 +123,8 @@ export function useDashboard() {
       setIsLoadingRank(true);
       const { data, error } = await supabase
         .from('class_achievements')
-        .select(`classes ( name )`);
+        .select(`classes ( name )`)
+        .eq('status', 'verified');
 
       if (error) throw error;
 
@@ -232,7 +233,8 @@ export function useDashboard() {
     try {
       const { data } = await supabase
         .from('class_achievements')
-        .select(`classes ( name )`);
+        .select(`classes ( name )`)
+        .eq('status', 'verified');
 
       const stats: Record<string, number> = {};
       data?.forEach((row: any) => {
@@ -87,6 +87,7 @@ export function useLeaderboard() {
             name
           )
         `)
+        .eq('status', 'verified') // pending and rejected submissions stay off the leaderboard
         .order('event_date', { ascending: false });
 
       if (achError) throw achError;
@@ -204,45 +204,44 @@ export function useRepository() {
     setIsLoading(true);
     try {
       const { data: { session } } = await supabase.auth.refreshSession();
-      const driveFolderId = selectedCourse!.drive_folder_id || PARENT_FOLDER_ID;
       const filesToProcess = isEditingMaterial ? [filesToUpload[0]].filter(Boolean) : filesToUpload;
 
       for (let i = 0; i < filesToProcess.length; i++) {
         const currentFile = filesToProcess[i];
-        let finalFileUrl = '';
-        let storageType: 'supabase' | 'google_drive' = 'supabase';
         let type = 'pdf'; // Default
 
         const fileExt = currentFile.name.split('.').pop()?.toLowerCase() || '';
         if (['jpg', 'jpeg', 'png'].includes(fileExt)) type = 'image';
         else if (fileExt === 'mp4') type = 'video';
-        else type = 'pdf';
+        else if (fileExt !== 'pdf') type = 'other';
+
+        let finalTitle = isEditingMaterial ? materialForm.title.trim() : (filesToProcess.length > 1 || !materialForm.title.trim() ? currentFile.name.split('.').slice(0, -1).join('.') : materialForm.title.trim());
+        if (!finalTitle.toLowerCase().endsWith('.' + fileExt)) finalTitle += '.' + fileExt;
 
         if (currentFile.size > 2 * 1024 * 1024) {
-          const formData = new FormData(); formData.append('file', currentFile); formData.append('parent_id', driveFolderId);
-          const response = await fetch(`${API_BASE_URL}/api/repository/upload-drive`, { method: 'POST', headers: { 'Authorization': `Bearer ${session?.access_token}` }, body: formData });
+          // Large files go through the backend, which stores the file and saves the material row itself
+          const formData = new FormData();
+          formData.append('file', currentFile); formData.append('subject_id', selectedCourse.id);
+          formData.append('title', finalTitle); formData.append('description', materialForm.description || '');
+          const endpoint = isEditingMaterial ? `/api/repository/materials/${materialForm.id}` : '/api/repository/upload-drive';
+          const response = await fetch(`${API_BASE_URL}${endpoint}`, { method: isEditingMaterial ? 'PUT' : 'POST', headers: { 'Authorization': `Bearer ${session?.access_token}` }, body: formData });
           const result = await response.json();
           if (!response.ok) throw new Error(result.error);
-          storageType = 'google_drive'; finalFileUrl = result.webViewLink;
-        } else {
-          const fileName = `${Date.now()}_${Math.random().toString(36).substring(7)}.${fileExt}`;
-          const filePath = `${selectedSemester.id}/${selectedCourse.code}/${fileName}`;
-          const { error } = await supabase.storage.from('materials').upload(filePath, currentFile);
-          if (error) throw error;
-          const { data: { publicUrl } } = supabase.storage.from('materials').getPublicUrl(filePath);
-          finalFileUrl = publicUrl; storageType = 'supabase';
+          const saved = result.data;
+          setMaterials(prev => isEditingMaterial ? prev.map(m => m.id === saved.id ? { ...m, ...saved } : m) : [saved, ...prev]);
+          continue;
         }
 
-        let finalTitle = isEditingMaterial ? materialForm.title.trim() : (filesToProcess.length > 1 || !materialForm.title.trim() ? currentFile.name.split('.').slice(0, -1).join('.') : materialForm.title.trim());
-        if (!finalTitle.toLowerCase().endsWith('.' + fileExt)) finalTitle += '.' + fileExt;
-
+        const fileName = `${Date.now()}_${Math.random().toString(36).substring(7)}.${fileExt}`;
+        const filePath = `${selectedSemester.id}/${selectedCourse.code}/${fileName}`;
+        const { error: uploadError } = await supabase.storage.from('materials').upload(filePath, currentFile);
+        if (uploadError) throw uploadError;
+        const { data: { publicUrl } } = supabase.storage.from('materials').getPublicUrl(filePath);
         const insertData: any = {
           subject_id: selectedCourse.id, semester: selectedSemester.id, title: finalTitle,
-          description: materialForm.description, file_type: type, file_url: finalFileUrl,
-          file_size: storageType === 'google_drive' ? null : currentFile.size, uploaded_by: userId,
-          storage_type: storageType, is_pinned: storageType === 'google_drive'
+          description: materialForm.description, file_type: type, file_url: publicUrl,
+          file_size: currentFile.size, uploaded_by: userId, storage_type: 'supabase', is_pinned: false
         };
-        if (storageType === 'google_drive') insertData.external_url = `https://drive.google.com/drive/folders/${driveFolderId}`;
 
         if (isEditingMaterial) {
           const { error } = await supabase.from('materials').update(insertData).eq('id', materialForm.id);
@@ -1,5 +1,6 @@
 import { useState, useEffect } from 'react';
 import { supabase } from '@/integrations/supabase/client';
+import { normalizeAttendanceStatus } from '@/SharedLogic/hooks/useAttendance';
 
 export interface AttendanceStats {
     percentage: number;
@@ -125,9 +126,10 @@ export const useAttendanceStats = (userId: string | undefined): AttendanceStats
 
                 let totalHadir = 0, totalIzin = 0, totalAlpha = 0;
                 semesterRecords.forEach(record => {
-                    if (record.status === 'present' || record.status === 'hadir') totalHadir++;
-                    else if (record.status === 'excused' || record.status === 'izin') totalIzin++;
-                    else if (record.status === 'absent' || record.status === 'alpha') totalAlpha++;
+                    const status = normalizeAttendanceStatus(record.status);
+                    if (status === 'present') totalHadir++;
+                    else if (status === 'excused') totalIzin++;
+                    else if (status === 'absent') totalAlpha++;
                 });
 
                 const totalMeetings = totalHadir + totalIzin + totalAlpha;
@@ -146,6 +146,13 @@ export type Database = {
           event_date: string
           created_at: string
           created_by: string | null
+          competition_id: string | null
+          level: string
+          certificate_url: string | null
+          status: string
+          reviewed_by: string | null
+          reviewed_at: string | null
+          review_note: string | null
         }
         Insert: {
           id?: string
@@ -156,6 +163,13 @@ export type Database = {
           event_date: string
           created_at?: string
           created_by?: string | null
+          competition_id?: string | null
+          level?: string
+          certificate_url?: string | null
+          status?: string
+          reviewed_by?: string | null
+          reviewed_at?: string | null
+          review_note?: string | null
         }
         Update: {
           id?: string
@@ -166,6 +180,13 @@ export type Database = {
           event_date?: string
           created_at?: string
           created_by?: string | null
+          competition_id?: string | null
+          level?: string
+          certificate_url?: string | null
+          status?: string
+          reviewed_by?: string | null
+          reviewed_at?: string | null
+          review_note?: string | null
         }
         Relationships: [
           {
@@ -0,0 +1,16 @@
+-- Migration: Allow the lateness-aware attendance statuses
+-- Created at: 2026-10-18 09:00:00
+
+-- Scans now record hadir / terlambat / alpa / izin / sakit. The legacy values
+-- (present, late, absent, excused) stay valid for existing rows and for the manual
+-- attendance editor.
+ALTER TABLE public.attendance_records DROP CONSTRAINT IF EXISTS attendance_records_status_check;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'attendance_records_status_valid') THEN
+        ALTER TABLE public.attendance_records
+        ADD CONSTRAINT attendance_records_status_valid
+        CHECK (status IN ('hadir', 'terlambat', 'alpa', 'izin', 'sakit', 'present', 'late', 'absent', 'excused'));
+    END IF;
+END $$;
@@ -0,0 +1,16 @@
+-- Migration: Check meeting number uniqueness at commit
+-- Created at: 2026-10-18 10:00:00
+
+-- Reordering the meetings of a subject, or closing the gap after deleting one, swaps
+-- meeting numbers inside a single transaction. The CHECK (1..14) leaves no room for a
+-- temporary offset, so UNIQUE (subject_id, meeting_number) becomes deferrable; the
+-- backend defers it for the renumbering only.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'meetings_subject_id_meeting_number_key' AND condeferrable) THEN
+        ALTER TABLE public.meetings DROP CONSTRAINT IF EXISTS meetings_subject_id_meeting_number_key;
+        ALTER TABLE public.meetings
+        ADD CONSTRAINT meetings_subject_id_meeting_number_key
+        UNIQUE (subject_id, meeting_number) DEFERRABLE INITIALLY IMMEDIATE;
+    END IF;
+END $$;
@@ -0,0 +1,26 @@
+-- Migration: Stamp weekly dues with their payment time
+-- Created at: 2026-10-18 11:00:00
+
+-- The dues punctuality points compare paid_at with the week's due date. The finance pages
+-- upsert weekly_dues straight from the browser without paid_at, so the database sets it
+-- whenever a row becomes paid (paid/lunas) and clears it when the payment is undone.
+ALTER TABLE public.weekly_dues ADD COLUMN IF NOT EXISTS paid_at TIMESTAMPTZ;
+
+CREATE OR REPLACE FUNCTION public.set_weekly_due_paid_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status IN ('paid', 'lunas') THEN
+        IF NEW.paid_at IS NULL OR (TG_OP = 'UPDATE' AND COALESCE(OLD.status, '') NOT IN ('paid', 'lunas')) THEN
+            NEW.paid_at = now();
+        END IF;
+    ELSE
+        NEW.paid_at = NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql SET search_path = public;
+
+DROP TRIGGER IF EXISTS set_weekly_dues_paid_at ON public.weekly_dues;
+CREATE TRIGGER set_weekly_dues_paid_at
+    BEFORE INSERT OR UPDATE OF status, paid_at ON public.weekly_dues
+    FOR EACH ROW EXECUTE FUNCTION public.set_weekly_due_paid_at();